@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Session actions
+
+// actionMsg reports the outcome of an action run from the TUI.
+type actionMsg struct {
+	notice string
+	err    error
+}
+
+// launchSession opens a new tmux window in dir and starts cmd in it.
+func launchSession(dir, cmd string) tea.Cmd {
+	return func() tea.Msg {
+		dir = expandPath(dir)
+		out, err := tmuxCommand("new-window", "-P", "-F", "#{pane_id}", "-c", dir).Output()
+		if err != nil {
+			return actionMsg{err: fmt.Errorf("new-window: %w", err)}
+		}
+		pane := strings.TrimSpace(string(out))
+		for _, args := range restartCmds(pane, cmd) {
+			if out, err := tmuxCommand(args...).CombinedOutput(); err != nil {
+				return actionMsg{err: fmt.Errorf("%s: %s", args[0], strings.TrimSpace(string(out)))}
+			}
+		}
+		return actionMsg{notice: "Launched " + cmd + " in " + shortenPath(dir)}
+	}
+}
+
+// expandPath reverses shortenPath, turning a leading ~ into the home directory.
+func expandPath(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return strings.TrimRight(home, "/") + path[1:]
+}
+
+// errPaneGone reports that a selected pane has closed since the last scan.
+var errPaneGone = errors.New("pane no longer exists")
+
+// paneExists reports whether tmux still knows pane.
+func paneExists(r CommandRunner, pane string) bool {
+	_, err := r.Output("tmux", "display-message", "-p", "-t", pane, "#{pane_id}")
+	return err == nil
+}
+
+// followUp is what a selecting key does to the pane once csm has switched
+// to it, or on_select has run: zoom it for z, answer its prompt for Y and
+// scoped_keys. --print-id and --replay hand the pane over untouched.
+type followUp struct {
+	zoom   bool
+	answer bool   // type text, if any, then Enter
+	text   string // the answer
+}
+
+// paneMsg reports whether the pane of a session being chosen still exists.
+type paneMsg struct {
+	session ClaudeSession
+	then    followUp
+	exists  bool
+}
+
+// checkPane looks up s's pane for choose, off the Update path.
+func checkPane(s ClaudeSession, then followUp) tea.Cmd {
+	return func() tea.Msg {
+		return paneMsg{session: s, then: then, exists: backend.Exists(sysRunner, s.PaneID)}
+	}
+}
+
+// followUpCmds returns the tmux invocations runFollowUp makes for then, as
+// --dry-run shows them. The zoom is skipped if the window is already zoomed.
+func followUpCmds(pane string, then followUp) [][]string {
+	switch {
+	case then.zoom:
+		return [][]string{{"resize-pane", "-Z", "-t", pane}}
+	case then.answer:
+		return quickAnswerCmds(pane, then.text)
+	}
+	return nil
+}
+
+// runFollowUp does then to pane.
+func runFollowUp(r CommandRunner, pane string, then followUp) error {
+	switch {
+	case then.zoom:
+		return zoomPane(r, pane)
+	case then.answer:
+		return runTmuxCmds(r, followUpCmds(pane, then))
+	}
+	return nil
+}
+
+// runTmuxCmds runs each tmux invocation in turn. Unlike runTmux, it stops
+// at the first that fails and returns its error.
+func runTmuxCmds(r CommandRunner, cmds [][]string) error {
+	for _, args := range cmds {
+		if _, err := r.Output("tmux", args...); err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+// selectMsg picks a pane and quits, as enter does.
+type selectMsg struct {
+	pane string
+}
+
+// recreateSession starts cmd in a new window at dir in place of a closed
+// pane, then selects the new pane.
+func recreateSession(dir, cmd string) tea.Cmd {
+	return func() tea.Msg {
+		out, err := sysRunner.Output("tmux", "new-window", "-P", "-F", "#{pane_id}", "-c", expandPath(dir))
+		if err != nil {
+			return actionMsg{err: fmt.Errorf("new-window: %w", err)}
+		}
+		pane := strings.TrimSpace(string(out))
+		if err := runTmuxCmds(sysRunner, restartCmds(pane, cmd)); err != nil {
+			return actionMsg{err: err}
+		}
+		return selectMsg{pane: pane}
+	}
+}
+
+// zoomPane zooms pane, leaving an already zoomed window as is so the
+// action never unzooms.
+func zoomPane(r CommandRunner, pane string) error {
+	out, err := r.Output("tmux", "display-message", "-p", "-t", pane, "#{window_zoomed_flag}")
+	if err == nil && strings.TrimSpace(string(out)) == "1" {
+		return nil
+	}
+	if _, err := r.Output("tmux", "resize-pane", "-Z", "-t", pane); err != nil {
+		return fmt.Errorf("resize-pane: %w", err)
+	}
+	return nil
+}
+
+// quickAnswerGuard reports why the quick answer may not be sent to s.
+func quickAnswerGuard(s ClaudeSession) error {
+	if !cfg.EnableQuickAnswer {
+		return fmt.Errorf("quick answer is off (set enable_quick_answer)")
+	}
+	if s.Status != StatusWaiting {
+		return fmt.Errorf("quick answer only applies to waiting sessions")
+	}
+	return nil
+}
+
+// sendBuffer is the tmux buffer multi-line text is pasted from.
+const sendBuffer = "csm-send"
+
+// sendTextCmds returns the tmux invocations that type text into pane
+// literally. A single line goes through send-keys -l. Text with newlines
+// is pasted from a buffer instead, bracketed when the application asked
+// for it, so it arrives as one input rather than submitting at the first
+// newline; the buffer is deleted after pasting.
+func sendTextCmds(pane, text string) [][]string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.Contains(text, "\n") {
+		return [][]string{{"send-keys", "-t", pane, "-l", "--", tmuxLiteral(text)}}
+	}
+	return [][]string{
+		{"set-buffer", "-b", sendBuffer, "--", tmuxLiteral(text)},
+		{"paste-buffer", "-p", "-d", "-b", sendBuffer, "-t", pane},
+	}
+}
+
+// tmuxLiteral escapes a trailing ";" in arg, which tmux would otherwise
+// take as a command separator and drop.
+func tmuxLiteral(arg string) string {
+	if strings.HasSuffix(arg, ";") {
+		return arg[:len(arg)-1] + `\;`
+	}
+	return arg
+}
+
+// quickAnswerCmds returns the tmux invocations that answer pane's prompt:
+// the answer typed literally, if any, then Enter.
+func quickAnswerCmds(pane, answer string) [][]string {
+	var cmds [][]string
+	if answer != "" {
+		cmds = sendTextCmds(pane, answer)
+	}
+	return append(cmds, []string{"send-keys", "-t", pane, "Enter"})
+}
+
+// cancelGuard reports why Escape may not be sent to s.
+func cancelGuard(s ClaudeSession) error {
+	if s.Status != StatusWorking {
+		return fmt.Errorf("only working sessions can be cancelled")
+	}
+	return nil
+}
+
+// cancelArgs returns the tmux invocation that sends Claude's cancel key.
+func cancelArgs(pane string) []string {
+	return []string{"send-keys", "-t", pane, "Escape"}
+}
+
+// cancelSession interrupts the work in pane without switching to it.
+func cancelSession(pane string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := sysRunner.Output("tmux", cancelArgs(pane)...); err != nil {
+			return actionMsg{err: fmt.Errorf("send-keys: %w", err)}
+		}
+		return actionMsg{notice: "Sent Escape to " + pane}
+	}
+}
+
+// restartGuard reports why Claude may not be restarted in s.
+func restartGuard(s ClaudeSession) error {
+	if s.Status != StatusExited {
+		return fmt.Errorf("only exited sessions can be restarted")
+	}
+	return nil
+}
+
+// restartCmds returns the tmux invocations that type cmd into pane and run it.
+func restartCmds(pane, cmd string) [][]string {
+	return append(sendTextCmds(pane, cmd), []string{"send-keys", "-t", pane, "Enter"})
+}
+
+// restartSession runs the launch command again in pane.
+func restartSession(pane, cmd string) tea.Cmd {
+	return func() tea.Msg {
+		if err := runTmuxCmds(sysRunner, restartCmds(pane, cmd)); err != nil {
+			return actionMsg{err: err}
+		}
+		return actionMsg{notice: "Restarted " + cmd + " in " + pane}
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuickAnswerGuard(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		status  int
+		ok      bool
+	}{
+		{true, StatusWaiting, true},
+		{false, StatusWaiting, false}, // opt-in only
+		{true, StatusIdle, false},
+		{true, StatusWorking, false},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.EnableQuickAnswer = tt.enabled })
+		err := quickAnswerGuard(testSession("a", tt.status))
+		if (err == nil) != tt.ok {
+			t.Errorf("enabled %v, status %d: guard = %v", tt.enabled, tt.status, err)
+		}
+	}
+}
+
+func TestQuickAnswerCmds(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"", "[[send-keys -t %1 Enter]]"},
+		{"2", "[[send-keys -t %1 -l -- 2] [send-keys -t %1 Enter]]"},
+		{"yes;", `[[send-keys -t %1 -l -- yes\;] [send-keys -t %1 Enter]]`},
+		{"a\nb", "[[set-buffer -b csm-send -- a\nb] [paste-buffer -p -d -b csm-send -t %1] [send-keys -t %1 Enter]]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(quickAnswerCmds("%1", tt.answer)); got != tt.want {
+			t.Errorf("quickAnswerCmds(%q) = %s, want %s", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestQuickAnswerKey(t *testing.T) {
+	setConfig(t, func(c *Config) { c.EnableQuickAnswer, c.QuickAnswer = true, "1" })
+	useRunner(t, &fakeRunner{captures: map[string]string{"w:0.0": ""}})
+	idle, waiting := testSession("i", StatusIdle), testSession("w", StatusWaiting)
+	m := update(newModel(), scanned(idle, waiting))
+
+	next, cmd := m.Update(press("Y"))
+	if cmd != nil || next.(model).notice == "" {
+		t.Errorf("Y on an idle session: cmd %v, notice %q; want a notice only", cmd != nil, next.(model).notice)
+	}
+
+	m = update(m, press("j"))
+	_, cmd = m.Update(press("Y"))
+	if cmd == nil {
+		t.Fatal("Y on a waiting session did nothing")
+	}
+	msg, ok := cmd().(paneMsg)
+	if !ok || !msg.exists || msg.then != (followUp{answer: true, text: "1"}) {
+		t.Errorf("Y looked up %+v, want the waiting pane answered with 1", msg)
+	}
+}
+
+func TestCancelGuard(t *testing.T) {
+	for _, status := range []int{StatusIdle, StatusWorking, StatusWaiting} {
+		err := cancelGuard(testSession("a", status))
+		if (err == nil) != (status == StatusWorking) {
+			t.Errorf("status %d: guard = %v", status, err)
+		}
+	}
+	if got := fmt.Sprint(cancelArgs("%1")); got != "[send-keys -t %1 Escape]" {
+		t.Errorf("cancelArgs = %s", got)
+	}
+}
+
+func TestCancelKey(t *testing.T) {
+	setConfig(t, nil)
+	f := &fakeRunner{}
+	useRunner(t, f)
+	m := update(newModel(), scanned(testSession("i", StatusIdle), testSession("w", StatusWorking)))
+
+	m = update(m, press("x"))
+	if m.mode != modeNormal || m.notice == "" {
+		t.Errorf("x on an idle session: mode %d, notice %q; want a notice only", m.mode, m.notice)
+	}
+
+	m = update(m, press("j"), press("x"))
+	if m.mode != modeConfirm || !strings.Contains(m.helpLine(), "Send Escape to w:0.0") {
+		t.Fatalf("x on a working session: mode %d, help %q", m.mode, m.helpLine())
+	}
+	if declined := update(m, press("n")); declined.mode != modeNormal {
+		t.Errorf("n left mode %d", declined.mode)
+	}
+	next, cmd := m.Update(press("y"))
+	if cmd == nil || next.(model).mode != modeNormal {
+		t.Fatalf("y: cmd %v, mode %d", cmd != nil, next.(model).mode)
+	}
+	if msg := cmd().(actionMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if !reflect.DeepEqual(f.calls, []string{"tmux send-keys -t w:0.0 Escape"}) {
+		t.Errorf("y ran %q", f.calls)
+	}
+}
+
+func TestRestartGuard(t *testing.T) {
+	for _, status := range []int{StatusIdle, StatusWorking, StatusWaiting, StatusExited} {
+		err := restartGuard(testSession("a", status))
+		if (err == nil) != (status == StatusExited) {
+			t.Errorf("status %d: guard = %v", status, err)
+		}
+	}
+}
+
+func TestSendTextCmds(t *testing.T) {
+	tests := []struct {
+		text string
+		want [][]string
+	}{
+		{"fix it", [][]string{{"send-keys", "-t", "%1", "-l", "--", "fix it"}}},
+		{"-v", [][]string{{"send-keys", "-t", "%1", "-l", "--", "-v"}}}, // not taken for a flag
+		{"a; b;", [][]string{{"send-keys", "-t", "%1", "-l", "--", `a; b\;`}}},
+		{"$HOME 'q' \"dq\" `x`", [][]string{{"send-keys", "-t", "%1", "-l", "--", "$HOME 'q' \"dq\" `x`"}}},
+		{"line one\nline two;", [][]string{
+			{"set-buffer", "-b", sendBuffer, "--", "line one\nline two\\;"},
+			{"paste-buffer", "-p", "-d", "-b", sendBuffer, "-t", "%1"},
+		}},
+		{"crlf\r\nending\r\n", [][]string{
+			{"set-buffer", "-b", sendBuffer, "--", "crlf\nending\n"},
+			{"paste-buffer", "-p", "-d", "-b", sendBuffer, "-t", "%1"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := sendTextCmds("%1", tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sendTextCmds(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMultiLineAnswerRuns(t *testing.T) {
+	f := &fakeRunner{}
+	if err := runFollowUp(f, "%1", followUp{answer: true, text: "first\nsecond"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"tmux set-buffer -b csm-send -- first\nsecond",
+		"tmux paste-buffer -p -d -b csm-send -t %1",
+		"tmux send-keys -t %1 Enter",
+	}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("ran %q, want %q", f.calls, want)
+	}
+
+	// A failed paste does not submit whatever was typed before it.
+	f = &fakeRunner{fail: map[string]bool{"paste-buffer": true}}
+	if err := runFollowUp(f, "%1", followUp{answer: true, text: "a\nb"}); err == nil || !strings.HasPrefix(err.Error(), "paste-buffer:") {
+		t.Errorf("runFollowUp = %v, want the paste-buffer failure", err)
+	}
+	if len(f.ran("tmux send-keys")) != 0 {
+		t.Errorf("sent keys after a failed paste: %q", f.calls)
+	}
+}
+
+func TestRestartCmds(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"claude", "[[send-keys -t %1 -l -- claude] [send-keys -t %1 Enter]]"},
+		{"claude --continue", "[[send-keys -t %1 -l -- claude --continue] [send-keys -t %1 Enter]]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(restartCmds("%1", tt.cmd)); got != tt.want {
+			t.Errorf("restartCmds(%q) = %s, want %s", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestRestartOfferedForExited(t *testing.T) {
+	// The exited pane's title survives at a shell prompt.
+	f := &fakeRunner{
+		panes:    paneLine("a:0.0", "✳ task") + withCommand(paneLine("b:0.0", "✳ task"), "zsh"),
+		captures: map[string]string{"a:0.0": "❯ \n", "b:0.0": "$ \n"},
+	}
+	for _, show := range []bool{false, true} {
+		setConfig(t, func(c *Config) { c.ShowExited, c.LaunchCmd = show, "claude --continue" })
+		sessions, _ := detect(f)
+		if show != (len(sessions) == 2) {
+			t.Fatalf("show_exited %v: detected %d sessions", show, len(sessions))
+		}
+		if !show {
+			continue
+		}
+		m := update(newModel(), scanned(sessions...))
+		if m = update(m, press("R")); m.mode != modeNormal || m.notice == "" {
+			t.Errorf("R on a live session: mode %d, notice %q", m.mode, m.notice)
+		}
+		m = update(m, press("j"), press("R"))
+		if m.sessions[m.cursor].Status != StatusExited || !strings.Contains(m.helpLine(), "Run claude --continue again in b:0.0?") {
+			t.Errorf("R on the exited session: help %q", m.helpLine())
+		}
+		useRunner(t, f)
+		_, cmd := m.Update(press("y"))
+		if msg := cmd().(actionMsg); msg.err != nil {
+			t.Fatal(msg.err)
+		}
+		want := []string{"tmux send-keys -t b:0.0 -l -- claude --continue", "tmux send-keys -t b:0.0 Enter"}
+		if got := f.ran("tmux send-keys"); !reflect.DeepEqual(got, want) {
+			t.Errorf("y ran %q, want %q", got, want)
+		}
+	}
+}
+
+func TestRecreateSession(t *testing.T) {
+	setConfig(t, nil)
+	f := &fakeRunner{newPane: "%7"}
+	useRunner(t, f)
+	if msg := recreateSession("/src/api", "claude")(); msg != (selectMsg{pane: "%7"}) {
+		t.Fatalf("recreateSession = %+v, want the new pane selected", msg)
+	}
+	want := []string{
+		"tmux new-window -P -F #{pane_id} -c /src/api",
+		"tmux send-keys -t %7 -l -- claude",
+		"tmux send-keys -t %7 Enter",
+	}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("ran %q, want %q", f.calls, want)
+	}
+
+	// A failed new-window types nothing.
+	f = &fakeRunner{fail: map[string]bool{"new-window": true}}
+	useRunner(t, f)
+	if msg, ok := recreateSession("/src/api", "claude")().(actionMsg); !ok || msg.err == nil {
+		t.Fatalf("recreateSession = %+v, want the new-window error", msg)
+	}
+	if len(f.ran("tmux send-keys")) != 0 {
+		t.Errorf("typed into a window that was not opened: %q", f.calls)
+	}
+}
+
+func TestChooseMissingPane(t *testing.T) {
+	gone := testSession("gone", StatusIdle)
+	moved := testSession("moved", StatusIdle)
+	moved.PathMissing = true
+	tests := []struct {
+		name    string
+		session ClaudeSession
+		mode    int
+		quit    bool
+		help    string
+	}{
+		{"open", testSession("live", StatusIdle), modeNormal, true, ""},
+		{"closed", gone, modeConfirm, false, "gone:0.0 is gone. Start claude in ~/gone?"},
+		{"closed and moved", moved, modeNormal, false, "moved:0.0 is gone and ~/moved no longer exists"},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.LaunchCmd = "claude" })
+		f := &fakeRunner{captures: map[string]string{"live:0.0": ""}}
+		useRunner(t, f)
+		m := update(newModel(), scanned(tt.session))
+		_, cmd := m.Update(press("enter"))
+		if cmd == nil {
+			t.Fatalf("%s: enter did nothing", tt.name)
+		}
+		msg := cmd()
+		if len(f.ran("tmux display-message -p -t "+tt.session.PaneID)) != 1 {
+			t.Errorf("%s: the pane was not looked up; ran %q", tt.name, f.calls)
+		}
+		m = update(m, msg)
+		if m.mode != tt.mode || m.quitting != tt.quit || !strings.Contains(m.helpLine(), tt.help) {
+			t.Errorf("%s: mode %d, quitting %v, help %q; want %d, %v, %q", tt.name, m.mode, m.quitting, m.helpLine(), tt.mode, tt.quit, tt.help)
+		}
+		if tt.quit && m.selectedID != tt.session.PaneID {
+			t.Errorf("%s: selected %q", tt.name, m.selectedID)
+		}
+	}
+}
+
+func TestSwitchToMissingPane(t *testing.T) {
+	f := &fakeRunner{captures: map[string]string{"live:0.0": ""}}
+	if err := (tmuxBackend{}).Switch(f, "gone:0.0"); !errors.Is(err, errPaneGone) {
+		t.Errorf("Switch to a closed pane = %v, want errPaneGone", err)
+	}
+	if len(f.ran("tmux switch-client")) != 0 {
+		t.Error("switched to a closed pane")
+	}
+	if err := (tmuxBackend{}).Switch(f, "live:0.0"); err != nil {
+		t.Errorf("Switch to an open pane: %v", err)
+	}
+	if got := f.ran("tmux switch-client"); len(got) != 1 || got[0] != "tmux switch-client -t live:0.0" {
+		t.Errorf("ran %q", got)
+	}
+}
+
+func TestScopedKeyOverridesGlobal(t *testing.T) {
+	setConfig(t, func(c *Config) {
+		c.EnableQuickAnswer, c.QuickAnswer = true, "1"
+		c.ScopedKeys = []ScopedKeys{{Glob: "~/api", Keys: map[string]string{"Y": "2"}}}
+	})
+	useRunner(t, &fakeRunner{captures: map[string]string{"api:0.0": "", "web:0.0": ""}})
+	tests := []struct {
+		session ClaudeSession
+		want    followUp
+	}{
+		{testSession("api", StatusWaiting), followUp{answer: true, text: "2"}},
+		{testSession("web", StatusWaiting), followUp{answer: true, text: "1"}}, // falls back to quick_answer
+	}
+	for _, tt := range tests {
+		m := update(newModel(), scanned(tt.session))
+		_, cmd := m.Update(press("Y"))
+		if cmd == nil {
+			t.Fatalf("%s: Y did nothing", tt.session.SessionName)
+		}
+		if msg, ok := cmd().(paneMsg); !ok || msg.then != tt.want {
+			t.Errorf("%s: Y chose %+v, want %+v", tt.session.SessionName, msg.then, tt.want)
+		}
+	}
+
+	m := update(newModel(), scanned(testSession("api", StatusIdle)))
+	if next, cmd := m.Update(press("Y")); cmd != nil || !strings.Contains(next.(model).notice, "only while they wait") {
+		t.Errorf("a scoped key on an idle session: cmd %v, notice %q", cmd != nil, next.(model).notice)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Other tmux servers (--all-users)
+//
+// With --all-users, detection also lists every tmux server socket under
+// $TMUX_TMPDIR (or /tmp) in tmux-*/, which covers other users' servers
+// and this user's servers on other sockets. Their sessions are tagged with
+// the socket's owner and get a PaneID qualified by that tag, so they never
+// collide with panes of the current server. A client cannot switch to
+// another server, so those sessions are shown but not acted on. Sockets we
+// may not connect to are skipped, with a note in the debug log.
+
+// socketRoot is the directory holding the tmux-UID socket directories.
+func socketRoot() string {
+	if dir := os.Getenv("TMUX_TMPDIR"); dir != "" {
+		return dir
+	}
+	return "/tmp"
+}
+
+// discoverSockets returns the tmux server sockets under root, sorted.
+// Unreadable directories are skipped.
+func discoverSockets(root string) []string {
+	matches, _ := filepath.Glob(filepath.Join(root, "tmux-*", "*"))
+	var socks []string
+	for _, p := range matches {
+		fi, err := os.Lstat(p)
+		if err == nil && fi.Mode()&fs.ModeSocket != 0 {
+			socks = append(socks, p)
+		}
+	}
+	sort.Strings(socks)
+	return socks
+}
+
+// socketTag names the server at sock after its owner, from the tmux-UID
+// directory (the bare UID if it has no user name), adding the socket name
+// unless it is "default": "alice" or "alice/work".
+func socketTag(sock string) string {
+	owner := filepath.Base(filepath.Dir(sock))
+	if uid, ok := strings.CutPrefix(owner, "tmux-"); ok {
+		owner = uid
+		if u, err := user.LookupId(uid); err == nil {
+			owner = u.Username
+		}
+	}
+	if name := filepath.Base(sock); name != "default" {
+		return owner + "/" + name
+	}
+	return owner
+}
+
+// socketRunner sends tmux commands to the server at sock.
+type socketRunner struct {
+	r    CommandRunner
+	sock string
+}
+
+func (s socketRunner) Output(name string, args ...string) ([]byte, error) {
+	if name == "tmux" {
+		args = append([]string{"-S", s.sock}, args...)
+	}
+	return s.r.Output(name, args...)
+}
+
+// ownSocket returns the socket path of the server r talks to, or "".
+func ownSocket(r CommandRunner) string {
+	out, err := tolerantOutput(r, "tmux", "display-message", "-p", "#{socket_path}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// detectAllUsers is detect over the current server and every other
+// server socket found.
+func detectAllUsers(r CommandRunner) ([]ClaudeSession, scanStats) {
+	sessions, stats := detect(r)
+	own := ownSocket(r)
+	for _, sock := range discoverSockets(socketRoot()) {
+		if sock == own {
+			continue
+		}
+		found, st := detect(socketRunner{r: r, sock: sock})
+		if !st.listed {
+			debugLog.Printf("all-users: %s: cannot list panes", sock)
+			continue
+		}
+		sessions = append(sessions, tagSessions(found, sock)...)
+		stats.panes += st.panes
+		stats.titled += st.titled
+		stats.exited += st.exited
+		stats.sessions += st.sessions
+		stats.unread += st.unread
+	}
+	return sessions, stats
+}
+
+// tagSessions marks sessions as found on the server at sock.
+func tagSessions(sessions []ClaudeSession, sock string) []ClaudeSession {
+	tag := socketTag(sock)
+	for i := range sessions {
+		sessions[i].Socket = sock
+		sessions[i].Owner = tag
+		sessions[i].PaneID = tag + "/" + sessions[i].PaneID
+		sessions[i].Key = tag + "/" + sessions[i].Key
+	}
+	return sessions
+}
+
+// actsOnPane reports whether key, pressed on s, would run tmux commands
+// on its pane. Switching keys are caught by choose instead.
+func actsOnPane(key string, s ClaudeSession) bool {
+	switch key {
+	case "z", "Y", "x", "R":
+		return true
+	}
+	_, ok := scopedAnswer(cfg.ScopedKeys, s.Path, key)
+	return ok
+}
+
+// foreignGuard reports why s, on another tmux server, cannot be acted on.
+func foreignGuard(s ClaudeSession) error {
+	if s.Socket == "" {
+		return nil
+	}
+	return fmt.Errorf("%s is on %s's tmux server; attach with: tmux -S %s attach -t %s",
+		s.SessionName, s.Owner, s.Socket, s.SessionName)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// serversRunner routes tmux commands to a fake server by their -S socket,
+// the current server taking those without one.
+type serversRunner struct {
+	own     *fakeRunner
+	ownSock string
+	servers map[string]*fakeRunner
+}
+
+func (s serversRunner) Output(name string, args ...string) ([]byte, error) {
+	if name != "tmux" || len(args) < 2 || args[0] != "-S" {
+		if slices.Equal(args, []string{"display-message", "-p", "#{socket_path}"}) {
+			return []byte(s.ownSock + "\n"), nil
+		}
+		return s.own.Output(name, args...)
+	}
+	f, ok := s.servers[args[1]]
+	if !ok {
+		return nil, errors.New("fake: permission denied")
+	}
+	return f.Output(name, args[2:]...)
+}
+
+// listenSocket creates a unix socket at path, closed when the test ends.
+func listenSocket(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { l.Close() })
+}
+
+func TestDiscoverSockets(t *testing.T) {
+	root := t.TempDir()
+	for _, sock := range []string{"tmux-1001/default", "tmux-1000/work", "tmux-1000/default"} {
+		listenSocket(t, filepath.Join(root, sock))
+	}
+	// Not sockets: a stray file, and a socket outside a tmux-* directory.
+	if err := os.WriteFile(filepath.Join(root, "tmux-1000", "notes"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	listenSocket(t, filepath.Join(root, "other", "default"))
+
+	got := discoverSockets(root)
+	want := []string{
+		filepath.Join(root, "tmux-1000/default"),
+		filepath.Join(root, "tmux-1000/work"),
+		filepath.Join(root, "tmux-1001/default"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("discoverSockets = %q, want %q", got, want)
+	}
+	if got := discoverSockets(filepath.Join(root, "missing")); got != nil {
+		t.Errorf("discoverSockets of a missing root = %q", got)
+	}
+}
+
+func TestSocketTag(t *testing.T) {
+	tests := []struct{ sock, want string }{
+		{"/tmp/tmux-0/default", "root"},
+		{"/tmp/tmux-0/work", "root/work"},
+		{"/tmp/tmux-987654/default", "987654"}, // no such user
+		{"/run/odd/default", "odd"},
+	}
+	for _, tt := range tests {
+		if got := socketTag(tt.sock); got != tt.want {
+			t.Errorf("socketTag(%q) = %q, want %q", tt.sock, got, tt.want)
+		}
+	}
+}
+
+func TestDetectAllUsers(t *testing.T) {
+	setConfig(t, nil)
+	root := t.TempDir()
+	t.Setenv("TMUX_TMPDIR", root)
+	own, other, locked := filepath.Join(root, "tmux-0/default"), filepath.Join(root, "tmux-987654/default"), filepath.Join(root, "tmux-987655/default")
+	for _, sock := range []string{own, other, locked} {
+		listenSocket(t, sock)
+	}
+	r := serversRunner{
+		own:     &fakeRunner{panes: paneLine("a:0.0", "✳ mine"), captures: map[string]string{"a:0.0": "❯ \n"}},
+		ownSock: own,
+		servers: map[string]*fakeRunner{
+			other: {panes: paneLine("b:0.0", "✳ theirs"), captures: map[string]string{"b:0.0": "❯ \n"}},
+			own:   {panes: paneLine("dup:0.0", "✳ listed twice")},
+			// locked is left out: connecting to it fails.
+		},
+	}
+	sessions, stats := detectAllUsers(r)
+	if len(sessions) != 2 || stats.sessions != 2 || stats.panes != 2 {
+		t.Fatalf("detected %d sessions, stats %+v", len(sessions), stats)
+	}
+	if s := sessions[0]; s.PaneID != "a:0.0" || s.Socket != "" {
+		t.Errorf("own session %+v", s)
+	}
+	s := sessions[1]
+	if s.PaneID != "987654/b:0.0" || s.Owner != "987654" || s.Socket != other || s.Title != "theirs" {
+		t.Errorf("other server's session %+v", s)
+	}
+	if err := foreignGuard(s); err == nil || err.Error() != "b is on 987654's tmux server; attach with: tmux -S "+other+" attach -t b" {
+		t.Errorf("foreignGuard = %v", err)
+	}
+	if err := foreignGuard(sessions[0]); err != nil {
+		t.Errorf("foreignGuard on the current server = %v", err)
+	}
+}
+
+func TestActsOnPane(t *testing.T) {
+	setConfig(t, func(c *Config) {
+		c.ScopedKeys = []ScopedKeys{{Glob: "~/api", Keys: map[string]string{"2": "no"}}}
+	})
+	api := testSession("api", StatusWaiting)
+	tests := []struct {
+		key  string
+		s    ClaudeSession
+		want bool
+	}{
+		{"x", api, true},
+		{"Y", api, true},
+		{"2", api, true}, // a scoped answer
+		{"2", testSession("web", StatusWaiting), false},
+		{"i", api, false},
+	}
+	for _, tt := range tests {
+		if got := actsOnPane(tt.key, tt.s); got != tt.want {
+			t.Errorf("actsOnPane(%q, %s) = %v, want %v", tt.key, tt.s.Path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "strings"
+
+// Plain ASCII output (--ascii)
+//
+// For terminals and fonts that draw the status glyphs, pointer and box
+// edges poorly, --ascii swaps them for ASCII: status glyphs through
+// asciiGlyphs, everything else by passing the chrome through asciiOnly
+// where it is built. Titles, paths, output and other text from the panes
+// are never rewritten, so an arrow in a title stays an arrow. Every
+// substitute is one cell wide, like the glyph it replaces, so columns stay
+// aligned. Detection is unaffected: it reads Claude's own markers, not
+// csm's output.
+
+// asciiGlyphs are the status glyphs under --ascii.
+var asciiGlyphs = map[int]string{StatusWorking: "*", StatusWaiting: "!", StatusIdle: ".", StatusExited: "x"}
+
+// asciiChrome replaces the non-ASCII characters csm draws.
+var asciiChrome = strings.NewReplacer(
+	// Pointer, marks and icons.
+	"▸", ">", "•", "o", "★", "+", "⊘", "~", "⚠", "!", "◉", "#",
+	"✎", "e", "⇣", "f", "✓", "+", "✗", "-", "−", "-", "▰", "#", "▱", "-",
+	// Punctuation.
+	"·", "|", "–", "-", "×", "x", "…", "*", "→", ">", "↑", "^", "↓", "v", "█", "_",
+	// Claude's markers, as the e panel quotes them.
+	"❯", ">", "✳", "*",
+	// Box edges.
+	"╭", "+", "╮", "+", "╰", "+", "╯", "+", "─", "-", "│", "|",
+)
+
+// asciiOnly rewrites the chrome s to ASCII when --ascii is on. s must be
+// csm's own text, never a session's title, path or output.
+func asciiOnly(s string) string {
+	if !cfg.ascii {
+		return s
+	}
+	return asciiChrome.Replace(s)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// useASCII turns --ascii on for the rest of the test.
+func useASCII(t *testing.T, edit func(c *Config)) {
+	t.Helper()
+	setConfig(t, edit)
+	cfg.ascii = true
+	saved := statusGlyphs
+	statusGlyphs = asciiGlyphs
+	t.Cleanup(func() { statusGlyphs = saved })
+}
+
+func nonASCII(s string) []rune {
+	var out []rune
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func TestASCIISubstitutesKeepWidths(t *testing.T) {
+	for _, glyph := range []string{"▸", "•", "★", "⊘", "✓", "✗", "·", "–", "…", "█", "❯", "✳", "╭", "─", "│"} {
+		got := asciiChrome.Replace(glyph)
+		if len(nonASCII(got)) != 0 || lipgloss.Width(got) != lipgloss.Width(glyph) {
+			t.Errorf("%q becomes %q, %d cells; want one ASCII cell", glyph, got, lipgloss.Width(got))
+		}
+	}
+	for st, glyph := range asciiGlyphs {
+		if len(glyph) != 1 || glyph[0] > unicode.MaxASCII {
+			t.Errorf("status %d: glyph %q", st, glyph)
+		}
+	}
+
+	setConfig(t, nil)
+	if got := asciiOnly("▸ a · b"); got != "▸ a · b" {
+		t.Errorf("asciiOnly without --ascii = %q", got)
+	}
+}
+
+func TestASCIIView(t *testing.T) {
+	for _, border := range []bool{false, true} {
+		useASCII(t, func(c *Config) { c.Border, c.ShowAge = border, true })
+		sessions := []ClaudeSession{
+			testSession("work", StatusWorking), testSession("wait", StatusWaiting),
+			testSession("idle", StatusIdle), testSession("gone", StatusExited),
+		}
+		m := update(newModel(), tea.WindowSizeMsg{Width: 80, Height: 20}, scanned(sessions...))
+		view := m.View()
+		if bad := nonASCII(view); len(bad) != 0 {
+			t.Errorf("border %v: non-ASCII %q in\n%s", border, string(bad), view)
+		}
+		for _, want := range []string{"> ", "!", "*"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("border %v: no %q in\n%s", border, want, view)
+			}
+		}
+
+		// The panels and the confirmation box are chrome too.
+		cfg.ConfirmStyle = "modal"
+		sessions[1] = testSession("wait", StatusIdle)
+		m = update(m, scanned(sessions...), press("H"), press("D"), press("i"), press("e"))
+		m.ask(confirmation{prompt: "Sure?"})
+		view = m.View()
+		if bad := nonASCII(view); len(bad) != 0 {
+			t.Errorf("border %v, panels: non-ASCII %q in\n%s", border, string(bad), view)
+		}
+		for _, want := range []string{"Recent transitions", "Since ", "Sure?", "+---"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("border %v, panels: no %q in\n%s", border, want, view)
+			}
+		}
+	}
+}
+
+func TestASCIIKeepsUserText(t *testing.T) {
+	for _, border := range []bool{false, true} {
+		useASCII(t, func(c *Config) { c.Border, c.Columns = border, append(defaultColumns, "path") })
+		s := testSession("work", StatusWaiting)
+		s.Title, s.Path = "deploy → prod…", "~/src/a·b"
+		m := update(newModel(), tea.WindowSizeMsg{Width: 100, Height: 20}, scanned(s))
+		m.pinned[s.Path] = true
+		view := m.View()
+		for _, want := range []string{"+ deploy → prod…", "~/src/a·b", "> 1  ! Waiting"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("border %v: no %q in\n%s", border, want, view)
+			}
+		}
+		if bad := string(nonASCII(view)); bad != "→…·" {
+			t.Errorf("border %v: non-ASCII %q beyond the title and path in\n%s", border, bad, view)
+		}
+	}
+}
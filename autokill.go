@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Idle auto-kill
+//
+// With auto_kill_idle set, the footer counts sessions idle for longer than
+// the threshold and K offers to kill them. Nothing is killed without an
+// explicit y at the confirmation prompt, and only sessions still idle past
+// the threshold at that moment.
+
+// killCandidates returns the idle sessions idle since before now-threshold.
+// The pane being viewed and sessions the user already declined are skipped.
+func killCandidates(sessions []ClaudeSession, since map[string]time.Time, now time.Time,
+	threshold time.Duration, current string, declined map[string]bool) []ClaudeSession {
+	var out []ClaudeSession
+	for _, s := range sessions {
+		if s.Status != StatusIdle || s.Socket != "" || s.PaneID == current || declined[s.Key] {
+			continue
+		}
+		if t, ok := since[s.Key]; ok && now.Sub(t) >= threshold {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// killTarget returns the stable ID to kill s by: the #{pane_id} at the end
+// of its Key (see paneKey), such as "%3", which keeps naming the same pane
+// when windows are renumbered, or its PaneID when there is none.
+func killTarget(s ClaudeSession) string {
+	if s.Key != s.PaneID {
+		if i := strings.LastIndex(s.Key, "/"); i >= 0 {
+			return s.Key[i+1:]
+		}
+	}
+	return s.PaneID
+}
+
+// killPanes kills each session's pane and reports how many were killed.
+func killPanes(sessions []ClaudeSession) tea.Cmd {
+	return func() tea.Msg {
+		var failed []string
+		for _, s := range sessions {
+			if err := backend.Kill(sysRunner, killTarget(s)); err != nil {
+				debugLog.Printf("auto-kill %s: %v", s.PaneID, err)
+				failed = append(failed, s.PaneID)
+			}
+		}
+		if len(failed) > 0 {
+			return actionMsg{err: fmt.Errorf("kill-pane failed for %s", strings.Join(failed, ", "))}
+		}
+		return actionMsg{notice: fmt.Sprintf("Killed %d idle session(s)", len(sessions))}
+	}
+}
+
+// paneIDs returns the PaneIDs of sessions.
+func paneIDs(sessions []ClaudeSession) []string {
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.PaneID
+	}
+	return ids
+}
+
+// autoKillCandidates returns the sessions the policy would kill as of the
+// last scan, or none when auto_kill_idle is unset.
+func (m model) autoKillCandidates() []ClaudeSession {
+	if cfg.autoKillIdle <= 0 {
+		return nil
+	}
+	return killCandidates(m.all, m.track.since, m.now, cfg.autoKillIdle, m.current, m.declined)
+}
+
+// forgetDeclines forgets declines for sessions that are no longer idle, or
+// gone, so a session that works again and goes idle again is offered afresh.
+func (m *model) forgetDeclines() {
+	for key := range m.declined {
+		if st, ok := m.track.status[key]; !ok || st != StatusIdle {
+			delete(m.declined, key)
+		}
+	}
+}
+
+// offerAutoKill opens the kill confirmation for the policy's candidates.
+// The sessions are looked up again by Key when the user answers y, so one
+// that went back to work meanwhile is spared.
+func (m *model) offerAutoKill() {
+	if cfg.autoKillIdle <= 0 {
+		m.notice = "auto_kill_idle is not set"
+		return
+	}
+	c := m.autoKillCandidates()
+	if len(c) == 0 {
+		m.notice = "No sessions idle past auto_kill_idle"
+		return
+	}
+	panes := paneIDs(c)
+	keys := make([]string, len(c))
+	for i, s := range c {
+		keys[i] = s.Key
+	}
+	m.ask(confirmation{
+		prompt: fmt.Sprintf("Kill %d idle session(s): %s?", len(panes), strings.Join(panes, ", ")),
+		build: func(m *model) tea.Cmd {
+			var still []ClaudeSession
+			for _, s := range m.autoKillCandidates() {
+				if slices.Contains(keys, s.Key) {
+					still = append(still, s)
+				}
+			}
+			if len(still) == 0 {
+				m.notice = "No idle sessions left to kill"
+				return nil
+			}
+			return killPanes(still)
+		},
+		decline: func(m *model) {
+			for _, key := range keys {
+				m.declined[key] = true
+			}
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKillCandidates(t *testing.T) {
+	remote := testSession("remote", StatusIdle)
+	remote.Socket = "/tmp/tmux-1001/default"
+	sessions := []ClaudeSession{
+		testSession("old", StatusIdle),
+		testSession("fresh", StatusIdle),
+		testSession("busy", StatusWorking),
+		testSession("waits", StatusWaiting),
+		testSession("here", StatusIdle),
+		testSession("declined", StatusIdle),
+		testSession("unseen", StatusIdle), // no idle-since yet
+		remote,
+	}
+	long := testTime.Add(-time.Hour)
+	since := map[string]time.Time{}
+	for _, s := range sessions {
+		if s.SessionName != "unseen" {
+			since[s.Key] = long
+		}
+	}
+	since[testSession("fresh", 0).Key] = testTime.Add(-time.Minute)
+	declined := map[string]bool{testSession("declined", 0).Key: true}
+
+	var names []string
+	for _, s := range killCandidates(sessions, since, testTime, 30*time.Minute, "here:0.0", declined) {
+		names = append(names, s.SessionName)
+	}
+	if got := strings.Join(names, " "); got != "old" {
+		t.Errorf("candidates %q, want old only", got)
+	}
+}
+
+func TestAutoKillNeedsConfirmation(t *testing.T) {
+	setConfig(t, func(c *Config) { c.AutoKillIdle = "10m" })
+	f := &fakeRunner{}
+	useRunner(t, f)
+	a := testSession("a", StatusIdle)
+	m := update(newModel(), scannedAt(testTime, a), scannedAt(testTime.Add(5*time.Minute), a), press("K"))
+	if m.mode == modeConfirm {
+		t.Fatal("offered a session idle for 5m")
+	}
+	m = update(m, scannedAt(testTime.Add(11*time.Minute), a))
+	if m.mode == modeConfirm {
+		t.Fatal("a scan opened the kill prompt unasked")
+	}
+	if got := m.footer(""); !strings.Contains(got, "1 idle past auto_kill_idle (K to kill)") {
+		t.Errorf("footer %q does not count the candidate", got)
+	}
+	m = update(m, press("K"))
+	if m.mode != modeConfirm || !strings.Contains(m.confirm.prompt, "a:0.0") {
+		t.Fatalf("K after 11m idle: mode %d, prompt %q", m.mode, m.confirm.prompt)
+	}
+	if len(f.ran("tmux kill-pane")) != 0 {
+		t.Fatal("killed before the confirmation was answered")
+	}
+
+	// n remembers the answer until the session stops being idle.
+	m = update(m, press("n"), scannedAt(testTime.Add(12*time.Minute), a), press("K"))
+	if m.mode == modeConfirm {
+		t.Fatal("offered a declined session again")
+	}
+	busy := testSession("a", StatusWorking)
+	m = update(m, scannedAt(testTime.Add(13*time.Minute), busy),
+		scannedAt(testTime.Add(14*time.Minute), a), scannedAt(testTime.Add(25*time.Minute), a), press("K"))
+	if m.mode != modeConfirm {
+		t.Fatal("a session idle again after working was not offered afresh")
+	}
+
+	// The kill goes by the stable pane_id, not the window position.
+	_, cmd := m.Update(press("y"))
+	if msg := cmd().(actionMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if got := f.ran("tmux kill-pane"); len(got) != 1 || got[0] != "tmux kill-pane -t %a" {
+		t.Errorf("y ran %q", got)
+	}
+}
+
+func TestAutoKillRechecksOnConfirm(t *testing.T) {
+	setConfig(t, func(c *Config) { c.AutoKillIdle = "10m" })
+	f := &fakeRunner{}
+	useRunner(t, f)
+	a, b := testSession("a", StatusIdle), testSession("b", StatusIdle)
+	m := update(newModel(), scannedAt(testTime, a, b), scannedAt(testTime.Add(11*time.Minute), a, b), press("K"))
+	if m.mode != modeConfirm {
+		t.Fatal("K did not offer the idle sessions")
+	}
+
+	// a goes back to work while the prompt is open: only b is killed.
+	m = update(m, scannedAt(testTime.Add(12*time.Minute), testSession("a", StatusWorking), b))
+	_, cmd := m.Update(press("y"))
+	if msg := cmd().(actionMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if got := f.ran("tmux kill-pane"); len(got) != 1 || got[0] != "tmux kill-pane -t %b" {
+		t.Errorf("y ran %q, want only b killed", got)
+	}
+
+	// With nothing left to kill, y runs nothing.
+	m = update(newModel(), scannedAt(testTime, a), scannedAt(testTime.Add(11*time.Minute), a), press("K"),
+		scannedAt(testTime.Add(12*time.Minute), testSession("a", StatusWorking)))
+	next, cmd := m.Update(press("y"))
+	if cmd != nil {
+		t.Error("y ran a kill with no candidates left")
+	}
+	if got := next.(model).notice; got != "No idle sessions left to kill" {
+		t.Errorf("notice %q", got)
+	}
+}
+
+func TestKillTarget(t *testing.T) {
+	for _, c := range []struct{ key, pane, want string }{
+		{"work/%3", "work:1.0", "%3"},
+		{"work:1.0", "work:1.0", "work:1.0"}, // no pane_id reported
+		{"ws/7", "7", "7"},                   // wezterm
+	} {
+		if got := killTarget(ClaudeSession{Key: c.key, PaneID: c.pane}); got != c.want {
+			t.Errorf("killTarget(%q, %q) = %q, want %q", c.key, c.pane, got, c.want)
+		}
+	}
+}
+
+func TestAutoKillPolicyOff(t *testing.T) {
+	setConfig(t, nil)
+	a := testSession("a", StatusIdle)
+	m := update(newModel(), scannedAt(testTime, a), scannedAt(testTime.Add(24*time.Hour), a), press("K"))
+	if m.mode == modeConfirm {
+		t.Error("offered a kill with auto_kill_idle unset")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Backends
+//
+// A backend is the terminal multiplexer csm finds Claude in and switches
+// to. tmux is the default, and the only one with the extras built on its
+// commands: popups, window marks, control mode, sending keys. Outside tmux,
+// a shell in a wezterm pane ($WEZTERM_PANE) uses wezterm's multiplexer.
+
+// Backend detects sessions and drives their panes.
+type Backend interface {
+	// Detect lists the Claude sessions and the counts behind the result.
+	Detect(r CommandRunner) ([]ClaudeSession, scanStats)
+	// Current returns the pane being viewed, or "" if unknown.
+	Current(r CommandRunner) string
+	// Capture returns the last capture_lines of pane.
+	Capture(r CommandRunner, pane string) (string, error)
+	// Exists reports whether pane is still open.
+	Exists(r CommandRunner, pane string) bool
+	// Switch brings pane into view, failing with errPaneGone if it closed.
+	Switch(r CommandRunner, pane string) error
+	// SwitchCommand is the command line Switch runs, for --dry-run.
+	SwitchCommand(pane string) []string
+	// Kill closes pane.
+	Kill(r CommandRunner, pane string) error
+}
+
+// backend is the backend in use; see selectBackend.
+var backend Backend = tmuxBackend{}
+
+// selectBackend picks wezterm when csm runs in a wezterm pane outside
+// tmux, and tmux otherwise.
+func selectBackend() Backend {
+	if os.Getenv("TMUX") == "" && os.Getenv("WEZTERM_PANE") != "" {
+		return weztermBackend{}
+	}
+	return tmuxBackend{}
+}
+
+// checkBackend reports a clear error when the backend's CLI is missing.
+func checkBackend() error {
+	if _, ok := backend.(weztermBackend); ok {
+		return checkWezterm()
+	}
+	return checkTmux()
+}
+
+// tmuxOnly reports why a tmux feature is unavailable, or nil under tmux.
+func tmuxOnly(feature string) error {
+	if _, ok := backend.(tmuxBackend); ok {
+		return nil
+	}
+	return fmt.Errorf("%s needs tmux", feature)
+}
+
+// tmuxBackend runs every call through tmux; see tmuxArgv.
+type tmuxBackend struct{}
+
+func (tmuxBackend) Detect(r CommandRunner) ([]ClaudeSession, scanStats) {
+	if cfg.allUsers {
+		return detectAllUsers(r)
+	}
+	return detect(r)
+}
+
+func (tmuxBackend) Current(r CommandRunner) string { return currentPane(r) }
+
+func (tmuxBackend) Capture(r CommandRunner, pane string) (string, error) {
+	return capturePane(r, pane)
+}
+
+func (tmuxBackend) Exists(r CommandRunner, pane string) bool { return paneExists(r, pane) }
+
+func (tmuxBackend) Switch(r CommandRunner, pane string) error {
+	if !paneExists(r, pane) {
+		return fmt.Errorf("%s: %w", pane, errPaneGone)
+	}
+	if _, err := r.Output("tmux", "switch-client", "-t", pane); err != nil {
+		return fmt.Errorf("switch-client: %w", err)
+	}
+	return nil
+}
+
+func (tmuxBackend) SwitchCommand(pane string) []string {
+	bin, args := tmuxArgv([]string{"switch-client", "-t", pane})
+	return append([]string{bin}, args...)
+}
+
+func (tmuxBackend) Kill(r CommandRunner, pane string) error {
+	if _, err := r.Output("tmux", "kill-pane", "-t", pane); err != nil {
+		return fmt.Errorf("kill-pane: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// Command line
+
+// version is set at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
+// command is a csm subcommand. Commands without a tmux client (list, json,
+// watch) only need a running tmux server, not $TMUX.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+	flags   func() *flag.FlagSet // the command's flags, for completion; nil if none
+}
+
+var commands = []command{
+	{"list", "Print detected sessions as a table", runList,
+		func() *flag.FlagSet { f, _ := listFlags(); return f }},
+	{"json", "Print detected sessions as JSON", runJSON,
+		func() *flag.FlagSet { f, _ := jsonFlags(); return f }},
+	{"watch", "Print the session table whenever it changes", runWatch,
+		func() *flag.FlagSet { f, _, _ := watchFlags(); return f }},
+	{"serve", "Answer LIST and SWITCH requests on a Unix socket", runServe,
+		func() *flag.FlagSet { f, _, _ := serveFlags(); return f }},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// run parses global flags, loads the config and dispatches to a subcommand,
+// or the TUI when none is given. It returns the process exit code.
+func run(args []string) int {
+	// Flags are parsed before any tmux check so --help and --version
+	// work anywhere.
+	flags := flag.NewFlagSet("csm", flag.ContinueOnError)
+	showVersion := flags.Bool("version", false, "print version and exit")
+	prompt := flags.Bool("prompt", false, "print the waiting count for a shell prompt, e.g. ◐2, or nothing, and exit")
+	configFile := flags.String("config", configPath(), "path to the config `file`")
+	minStatus := flags.String("min-status", "", "hide sessions below `status` (idle, working or waiting)")
+	reset := flags.Bool("reset-state", false, "forget UI state saved by previous runs")
+	clearMutes := flags.Bool("clear-mutes", false, "unmute all muted sessions")
+	noWrap := flags.Bool("no-wrap", false, "stop j/k at the ends of the list instead of wrapping")
+	noNumbers := flags.Bool("no-numbers", false, "hide the quick-select number column")
+	density := flags.String("density", "", "row `spacing`: compact or comfortable (blank line between rows)")
+	noSound := flags.Bool("no-sound", false, "do not play sound_file when a session starts waiting")
+	theme := flags.String("theme", "", "color `preset`: dark, light, high-contrast or cb-safe")
+	ascii := flags.Bool("ascii", false, "draw status glyphs, pointer and borders in plain ASCII")
+	cbSafe := flags.Bool("cb-safe", false, "color-blind safe colors and status shapes (same as --theme cb-safe)")
+	debugFile := flags.String("debug-log", "", "append diagnostics such as hook failures to `file`")
+	deepDetect := flags.Bool("deep-detect", false, "also find Claude in untitled panes by walking their process trees (slower)")
+	allUsers := flags.Bool("all-users", false, "also list sessions on other tmux servers under /tmp/tmux-*/, including other users' (view only)")
+	motionDetect := flags.Bool("motion-detect", false, "treat idle-looking panes whose content changes between two captures as working (slower)")
+	tmuxBin := flags.String("tmux-bin", "", "tmux executable `path` (overrides tmux_bin)")
+	tmuxArgs := flags.String("tmux-args", "", "space-separated `args` put before every tmux command, e.g. \"-L work\" (overrides tmux_args)")
+	replayDir := flags.String("replay", "", "read panes and captures from fixture `dir` instead of tmux")
+	record := flags.String("record", "", "log the picker's messages to `file` for --replay-events")
+	replayEventsFile := flags.String("replay-events", "", "replay a --record log without tmux, print the last frame and check the final state")
+	sequential := flags.Bool("sequential", false, "inspect panes one at a time instead of in parallel (for debugging)")
+	dryRun := flags.Bool("dry-run", false, "print the command that would switch to the chosen session instead of running it")
+	controlMode := flags.Bool("control-mode", false, "rescan on tmux control-mode notifications instead of every second (experimental; needs tmux 3.2)")
+	printID := flags.Bool("print-id", false, "print the chosen pane ID instead of switching to it; exit 1 if none was chosen")
+	autoSwitch := flags.Bool("auto-switch-single", false, "with exactly one session, switch to it without opening the picker")
+	execCmd := flags.String("exec", "", "run `cmd` instead of switching on selection ({pane}, {path}, {name} are substituted)")
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "Usage: csm [global flags] [command] [flags]\n\n")
+		fmt.Fprintf(out, "Without a command, csm opens the interactive session picker.\n\n")
+		fmt.Fprintf(out, "Commands:\n")
+		for _, c := range commands {
+			fmt.Fprintf(out, "  %-10s %s\n", c.name, c.summary)
+		}
+		fmt.Fprintf(out, "  %-10s %s\n", "completion", "Print a shell completion script (bash, zsh or fish)")
+		fmt.Fprintf(out, "\nGlobal flags:\n")
+		printVisibleDefaults(flags)
+		fmt.Fprintf(out, "\nRun 'csm <command> --help' for command flags.\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Println("csm", version)
+		return 0
+	}
+
+	rest := flags.Args()
+	name := ""
+	if len(rest) > 0 {
+		name = rest[0]
+	}
+	if name == "help" {
+		if len(rest) > 1 {
+			if c, ok := findCommand(rest[1]); ok {
+				return c.run([]string{"--help"})
+			}
+		}
+		flags.Usage()
+		return 0
+	}
+	// Like help, completion needs neither tmux nor a valid config.
+	if name == "completion" {
+		return runCompletion(flags, rest[1:])
+	}
+	if *debugFile != "" {
+		f, err := openDebugLog(*debugFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --debug-log: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+	}
+
+	c, err := loadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *minStatus != "" {
+		c.MinStatus = *minStatus
+		if err := c.validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	if *noWrap {
+		c.WrapNavigation = false
+	}
+	if *noNumbers {
+		c.ShowNumbers = false
+	}
+	if *noSound {
+		c.SoundFile = ""
+	}
+	if *cbSafe && *theme == "" {
+		*theme = "cb-safe"
+	}
+	if *theme != "" {
+		c.Theme = *theme
+		if err := c.validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --theme: %v\n", err)
+			return 2
+		}
+	}
+	if *density != "" {
+		c.Density = *density
+		if err := c.validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --density: %v\n", err)
+			return 2
+		}
+	}
+	if *execCmd != "" {
+		c.OnSelect = *execCmd
+		if err := c.validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --exec: %v\n", err)
+			return 2
+		}
+	}
+	if *tmuxBin != "" {
+		c.TmuxBin = *tmuxBin
+	}
+	if *tmuxArgs != "" {
+		c.TmuxArgs = strings.Fields(*tmuxArgs)
+	}
+	c.sequential = *sequential
+	c.deepDetect = *deepDetect
+	c.motionDetect = *motionDetect
+	c.allUsers = *allUsers
+	c.ascii = *ascii
+	cfg = c
+	applyTheme(cfg.Theme, cfg.Colors)
+	if cfg.ascii {
+		statusGlyphs = asciiGlyphs
+	}
+
+	// Replaying an event log needs no tmux.
+	if *replayEventsFile != "" {
+		return replayEvents(*replayEventsFile)
+	}
+	if *replayDir != "" {
+		r, err := openReplay(*replayDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		sysRunner = r
+	} else {
+		backend = selectBackend()
+		if err := checkBackend(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	if *prompt {
+		return runPrompt(*replayDir == "")
+	}
+	if name == "" {
+		return runTUI(tuiOptions{resetState: *reset, clearMutes: *clearMutes, minStatus: *minStatus, replay: *replayDir != "",
+			dryRun: *dryRun, printID: *printID, autoSwitch: *autoSwitch, control: *controlMode, record: *record})
+	}
+	if cmd, ok := findCommand(name); ok {
+		return cmd.run(rest[1:])
+	}
+	fmt.Fprintf(os.Stderr, "csm: unknown command %q (see csm --help)\n", name)
+	return 2
+}
+
+// hiddenFlags are debugging flags left out of --help and completion.
+var hiddenFlags = map[string]bool{"replay": true}
+
+// printVisibleDefaults is flags.PrintDefaults without hiddenFlags.
+func printVisibleDefaults(flags *flag.FlagSet) {
+	visible := flag.NewFlagSet(flags.Name(), flag.ContinueOnError)
+	visible.SetOutput(flags.Output())
+	flags.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	visible.PrintDefaults()
+}
+
+// usage returns a flag.Usage func printing a synopsis, description and flags.
+func usage(flags *flag.FlagSet, synopsis, description string) func() {
+	return func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "Usage: %s\n\n%s\n", synopsis, description)
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(out, "\nFlags:\n")
+			flags.PrintDefaults()
+		}
+	}
+}
+
+// Exit codes under --attention-exit-code, so shell prompts can react to
+// sessions without parsing output.
+const (
+	exitWaiting   = 0 // a session is waiting
+	exitNoWaiting = 1 // sessions, but none waiting
+	exitNoSession = 2 // no sessions
+)
+
+// attentionExitCode maps the listed sessions to an exit code.
+func attentionExitCode(sessions []ClaudeSession) int {
+	switch {
+	case statusCounts(sessions)[StatusWaiting] > 0:
+		return exitWaiting
+	case len(sessions) > 0:
+		return exitNoWaiting
+	default:
+		return exitNoSession
+	}
+}
+
+const attentionExitUsage = "exit 0 if a session is waiting, 1 if none is, 2 if there are no sessions"
+
+func listFlags() (flags *flag.FlagSet, attentionExit *bool) {
+	flags = flag.NewFlagSet("list", flag.ExitOnError)
+	attentionExit = flags.Bool("attention-exit-code", false, attentionExitUsage)
+	flags.Usage = usage(flags, "csm list [flags]", "Print detected sessions as a table.")
+	return flags, attentionExit
+}
+
+func runList(args []string) int {
+	flags, attentionExit := listFlags()
+	flags.Parse(args)
+
+	sessions := filterMinStatus(detectSessions(sysRunner), cfg.minStatus)
+	writeTable(os.Stdout, sessions)
+	if *attentionExit {
+		return attentionExitCode(sessions)
+	}
+	return 0
+}
+
+func jsonFlags() (flags *flag.FlagSet, attentionExit *bool) {
+	flags = flag.NewFlagSet("json", flag.ExitOnError)
+	attentionExit = flags.Bool("attention-exit-code", false, attentionExitUsage)
+	flags.Usage = usage(flags, "csm json [flags]", "Print detected sessions and their counts as a versioned JSON object.")
+	return flags, attentionExit
+}
+
+func runJSON(args []string) int {
+	flags, attentionExit := jsonFlags()
+	flags.Parse(args)
+
+	sessions := filterMinStatus(detectSessions(sysRunner), cfg.minStatus)
+	if err := writeJSON(os.Stdout, sessions); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *attentionExit {
+		return attentionExitCode(sessions)
+	}
+	return 0
+}
+
+func watchFlags() (flags *flag.FlagSet, interval *time.Duration, metricsAddr *string) {
+	flags = flag.NewFlagSet("watch", flag.ExitOnError)
+	interval = flags.Duration("interval", time.Second, "time between scans")
+	metricsAddr = flags.String("metrics-addr", "", "serve Prometheus metrics on `addr` (e.g. :9100)")
+	flags.Usage = usage(flags, "csm watch [flags]", "Print the session table whenever it changes. Stop with Ctrl+C.")
+	return flags, interval, metricsAddr
+}
+
+func runWatch(args []string) int {
+	flags, interval, metricsAddr := watchFlags()
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var gauges *metrics
+	if *metricsAddr != "" {
+		gauges = &metrics{}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", gauges)
+		srv := &http.Server{Addr: *metricsAddr, Handler: mux}
+		errc := make(chan error, 1)
+		go func() { errc <- srv.ListenAndServe() }()
+		defer func() {
+			shutdown, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdown)
+		}()
+		// Surface bind errors before the first scan.
+		select {
+		case err := <-errc:
+			fmt.Fprintf(os.Stderr, "Error: metrics: %v\n", err)
+			return 1
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	windows := newWindowMarker(sysRunner, cfg.WaitingWindowStyle)
+	var last []ClaudeSession
+	first := true
+	for {
+		all := detectSessions(sysRunner)
+		windows.update(all).run(sysRunner)
+		if gauges != nil {
+			gauges.update(all)
+		}
+		sessions := filterMinStatus(all, cfg.minStatus)
+		if first || !reflect.DeepEqual(sessions, last) {
+			if !first {
+				fmt.Println()
+			}
+			fmt.Println(time.Now().Format("15:04:05"))
+			writeTable(os.Stdout, sessions)
+			last, first = sessions, false
+		}
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// writeTable prints sessions as aligned plain-text columns.
+func writeTable(w io.Writer, sessions []ClaudeSession) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range sessions {
+		fmt.Fprintf(tw, "%s\t%s %s\t%s\t%s\t%s\n",
+			s.PaneID, statusSymbol(s.Status), statusLabel(s.Status), s.SessionName, s.Path, s.Title)
+	}
+	tw.Flush()
+}
+
+// jsonSession is the JSON representation of a ClaudeSession.
+type jsonSession struct {
+	PaneID      string `json:"pane_id"`
+	SessionName string `json:"session"`
+	Title       string `json:"title"`
+	Path        string `json:"path"`
+	Status      string `json:"status"`
+	Clients     int    `json:"clients,omitempty"`
+	Group       string `json:"group,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Background  bool   `json:"background,omitempty"`
+}
+
+// jsonVersion is the envelope's schema version. Adding fields keeps it;
+// renaming or removing a field, or changing a field's type or meaning,
+// bumps it.
+const jsonVersion = 1
+
+// jsonOutput is the envelope printed by csm json.
+type jsonOutput struct {
+	Version  int           `json:"version"`
+	Counts   jsonCounts    `json:"counts"`
+	Sessions []jsonSession `json:"sessions"`
+}
+
+// jsonCounts tallies the listed sessions by status.
+type jsonCounts struct {
+	Total   int `json:"total"`
+	Waiting int `json:"waiting"`
+	Working int `json:"working"`
+	Idle    int `json:"idle"`
+	Exited  int `json:"exited"`
+}
+
+func writeJSON(w io.Writer, sessions []ClaudeSession) error {
+	counts := statusCounts(sessions)
+	out := jsonOutput{
+		Version: jsonVersion,
+		Counts: jsonCounts{
+			Total:   len(sessions),
+			Waiting: counts[StatusWaiting],
+			Working: counts[StatusWorking],
+			Idle:    counts[StatusIdle],
+			Exited:  counts[StatusExited],
+		},
+		Sessions: make([]jsonSession, len(sessions)),
+	}
+	for i, s := range sessions {
+		out.Sessions[i] = jsonSession{
+			PaneID:      s.PaneID,
+			SessionName: s.SessionName,
+			Title:       s.Title,
+			Path:        s.Path,
+			Status:      strings.ToLower(statusLabel(s.Status)),
+			Clients:     s.Clients,
+			Group:       s.Group,
+			Owner:       s.Owner,
+			Background:  s.Background,
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
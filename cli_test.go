@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outsideTmux runs the rest of the test as if csm were started outside
+// tmux with no tmux on $PATH, its output discarded and the globals run
+// sets restored afterwards.
+func outsideTmux(t *testing.T) {
+	t.Helper()
+	setConfig(t, nil)
+	t.Setenv("TMUX", "")
+	t.Setenv("WEZTERM_PANE", "")
+	t.Setenv("PATH", t.TempDir())
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, savedBackend, savedRunner := os.Stdout, os.Stderr, backend, sysRunner
+	os.Stdout, os.Stderr = null, null
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr, backend, sysRunner = stdout, stderr, savedBackend, savedRunner
+		null.Close()
+	})
+}
+
+// fixtureDir writes a --replay fixture of files, by name, and returns its
+// directory. An empty panes.tsv is added if files has none.
+func fixtureDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, ok := files["panes.tsv"]; !ok {
+		files["panes.tsv"] = ""
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRunWithoutTmux(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"--help"}, 0},
+		{[]string{"--version"}, 0},
+		{[]string{"help"}, 0},
+		{[]string{"completion", "bash"}, 0},
+		{[]string{"list"}, 1}, // needs tmux
+		{nil, 1},              // the picker needs tmux
+	}
+	for _, tt := range tests {
+		outsideTmux(t)
+		config := filepath.Join(t.TempDir(), "config.json")
+		if got := run(append([]string{"--config", config}, tt.args...)); got != tt.code {
+			t.Errorf("run(%q) = %d, want %d", tt.args, got, tt.code)
+		}
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{"--no-such-flag"},
+		{"frobnicate"},
+		{"--min-status", "busy"},
+		{"--theme", "neon"},
+		{"--density", "tight"},
+		{"completion"},
+		{"completion", "tcsh"},
+	}
+	for _, args := range tests {
+		outsideTmux(t)
+		dir := fixtureDir(t, map[string]string{})
+		config := filepath.Join(t.TempDir(), "config.json")
+		if got := run(append([]string{"--config", config, "--replay", dir}, args...)); got != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, got)
+		}
+	}
+}
+
+func TestHiddenFlagsLeftOutOfHelp(t *testing.T) {
+	flags := flag.NewFlagSet("csm", flag.ContinueOnError)
+	flags.Bool("replay", false, "")
+	flags.Bool("version", false, "print version and exit")
+	var out strings.Builder
+	flags.SetOutput(&out)
+	printVisibleDefaults(flags)
+	if strings.Contains(out.String(), "replay") || !strings.Contains(out.String(), "version") {
+		t.Errorf("help lists:\n%s", out.String())
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	for _, c := range commands {
+		if got, ok := findCommand(c.name); !ok || got.name != c.name {
+			t.Errorf("findCommand(%q) = %q, %v", c.name, got.name, ok)
+		}
+	}
+	if _, ok := findCommand("completion"); ok {
+		t.Error("completion is dispatched by run, not the command table")
+	}
+}
+
+func TestAttentionExitCode(t *testing.T) {
+	tests := []struct {
+		sessions []ClaudeSession
+		want     int
+	}{
+		{[]ClaudeSession{testSession("a", StatusIdle), testSession("b", StatusWaiting)}, exitWaiting},
+		{[]ClaudeSession{testSession("a", StatusIdle), testSession("b", StatusWorking)}, exitNoWaiting},
+		{nil, exitNoSession},
+	}
+	for _, tt := range tests {
+		if got := attentionExitCode(tt.sessions); got != tt.want {
+			t.Errorf("attentionExitCode(%s) = %d, want %d", names(tt.sessions), got, tt.want)
+		}
+	}
+}
+
+func TestAttentionExitCodeFlag(t *testing.T) {
+	waiting := map[string]string{
+		"panes.tsv": paneLine("a:0.0", "✳ task"),
+		"a_0.0.txt": "❯ go\n\n Do you want to proceed?\n ❯ 1. Yes\n Esc to cancel\n",
+	}
+	idle := map[string]string{"panes.tsv": paneLine("a:0.0", "✳ task"), "a_0.0.txt": "❯ \n"}
+	tests := []struct {
+		name    string
+		fixture map[string]string
+		args    []string
+		want    int
+	}{
+		{"waiting", waiting, []string{"list", "--attention-exit-code"}, 0},
+		{"idle", idle, []string{"list", "--attention-exit-code"}, 1},
+		{"none", map[string]string{}, []string{"list", "--attention-exit-code"}, 2},
+		{"json", idle, []string{"json", "--attention-exit-code"}, 1},
+		// without the flag, scripts keep seeing success
+		{"opt-in", map[string]string{}, []string{"list"}, 0},
+		{"opt-in json", idle, []string{"json"}, 0},
+	}
+	for _, tt := range tests {
+		outsideTmux(t)
+		dir := fixtureDir(t, tt.fixture)
+		config := filepath.Join(t.TempDir(), "config.json")
+		if got := run(append([]string{"--config", config, "--replay", dir}, tt.args...)); got != tt.want {
+			t.Errorf("%s: run(%q) = %d, want %d", tt.name, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestJSONEnvelope(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []ClaudeSession
+	}{
+		{"none", nil},
+		{"mixed", []ClaudeSession{testSession("a", StatusWaiting), testSession("b", StatusWorking),
+			testSession("c", StatusIdle), testSession("d", StatusIdle), testSession("e", StatusExited)}},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := writeJSON(&b, tt.sessions); err != nil {
+			t.Fatal(err)
+		}
+		// Decode loosely, as a consumer would, to check the shape itself.
+		var out struct {
+			Version  *int             `json:"version"`
+			Counts   map[string]int   `json:"counts"`
+			Sessions []map[string]any `json:"sessions"`
+		}
+		if err := json.Unmarshal([]byte(b.String()), &out); err != nil {
+			t.Fatalf("%s: %v\n%s", tt.name, err, b.String())
+		}
+		if out.Version == nil || *out.Version != jsonVersion {
+			t.Errorf("%s: version %v, want %d", tt.name, out.Version, jsonVersion)
+		}
+		if out.Sessions == nil || len(out.Sessions) != len(tt.sessions) {
+			t.Errorf("%s: sessions %v, want an array of %d", tt.name, out.Sessions, len(tt.sessions))
+		}
+		byStatus := map[string]int{}
+		for _, s := range out.Sessions {
+			byStatus[s["status"].(string)]++
+		}
+		for _, status := range []string{"waiting", "working", "idle", "exited"} {
+			if out.Counts[status] != byStatus[status] {
+				t.Errorf("%s: counts[%s] = %d, but the array has %d", tt.name, status, out.Counts[status], byStatus[status])
+			}
+		}
+		if out.Counts["total"] != len(out.Sessions) {
+			t.Errorf("%s: total %d, but the array has %d", tt.name, out.Counts["total"], len(out.Sessions))
+		}
+	}
+}
+
+func TestJSONSessionFields(t *testing.T) {
+	s := testSession("api", StatusWaiting)
+	s.Clients = 2
+	var b strings.Builder
+	if err := writeJSON(&b, []ClaudeSession{s, testSession("web", StatusIdle)}); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Sessions []map[string]any `json:"sessions"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"pane_id": "api:0.0", "session": "api", "title": "api task", "path": "~/api",
+		"status": "waiting", "clients": 2.0}
+	if !reflect.DeepEqual(out.Sessions[0], want) {
+		t.Errorf("session %v, want %v", out.Sessions[0], want)
+	}
+	if _, ok := out.Sessions[1]["clients"]; ok {
+		t.Error("clients is listed for a session no one is attached to")
+	}
+}
+
+func TestAutoSwitchSingle(t *testing.T) {
+	outsideTmux(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := fixtureDir(t, map[string]string{
+		"panes.tsv":   paneLine("api:0.0", "✳ fix the bug"),
+		"api_0.0.txt": "❯ \n",
+	})
+	var code int
+	out := stdoutOf(t, func() { code = run([]string{"--replay", dir, "--auto-switch-single"}) })
+	if code != 0 || out != "api:0.0\n" {
+		t.Errorf("one session: exit %d, printed %q; want it chosen without the picker", code, out)
+	}
+	if st := loadState(statePath()); len(st.Recent) != 1 || st.Recent[0] != "api:0.0" {
+		t.Errorf("recent after an auto-switch: %q", st.Recent)
+	}
+}
+
+func TestFinishSelection(t *testing.T) {
+	s := testSession("api", StatusWaiting)
+	tests := []struct {
+		name     string
+		onSelect string
+		then     followUp
+		opts     tuiOptions
+		want     string
+	}{
+		{"print-id", "", followUp{zoom: true}, tuiOptions{printID: true}, "api:0.0\n"},
+		{"replay", "", followUp{}, tuiOptions{replay: true}, "api:0.0\n"},
+		{"dry-run", "", followUp{}, tuiOptions{dryRun: true}, "tmux switch-client -t api:0.0\n"},
+		{"dry-run zoom", "", followUp{zoom: true}, tuiOptions{dryRun: true},
+			"tmux switch-client -t api:0.0\ntmux resize-pane -Z -t api:0.0\n"},
+		{"dry-run on_select", "code {path}", followUp{}, tuiOptions{dryRun: true}, "code '" + expandPath("~/api") + "'\n"},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.OnSelect = tt.onSelect })
+		var code int
+		out := stdoutOf(t, func() { code = finishSelection(s, tt.then, tt.opts) })
+		if code != 0 || out != tt.want {
+			t.Errorf("%s: exit %d, printed %q; want %q", tt.name, code, out, tt.want)
+		}
+	}
+}
+
+func TestPrintIDAfterEveryChoice(t *testing.T) {
+	useRunner(t, &fakeRunner{captures: map[string]string{"api:0.0": ""}})
+	tests := []struct {
+		key  string
+		then followUp
+	}{
+		{"enter", followUp{}},
+		{"z", followUp{zoom: true}},
+		{"Y", followUp{answer: true, text: "1"}},
+		{"N", followUp{answer: true, text: "no"}}, // a scoped key
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) {
+			c.EnableZoom, c.EnableQuickAnswer, c.QuickAnswer = true, true, "1"
+			c.ScopedKeys = []ScopedKeys{{Glob: "~/api", Keys: map[string]string{"N": "no"}}}
+		})
+		m := update(newModel(), scanned(testSession("api", StatusWaiting)))
+		_, cmd := m.Update(press(tt.key))
+		if cmd == nil {
+			t.Fatalf("%s did nothing", tt.key)
+		}
+		m = update(m, cmd())
+		if !m.quitting || m.selectedID != "api:0.0" || m.then != tt.then {
+			t.Errorf("%s: quitting %v, chose %q then %+v; want %+v", tt.key, m.quitting, m.selectedID, m.then, tt.then)
+		}
+		// --print-id hands the pane over without zooming or answering it.
+		var code int
+		out := stdoutOf(t, func() { code = finishSelection(testSession("api", StatusWaiting), m.then, tuiOptions{printID: true}) })
+		if code != 0 || out != "api:0.0\n" {
+			t.Errorf("%s --print-id: exit %d, printed %q", tt.key, code, out)
+		}
+	}
+}
+
+func TestBellRingsWherePickerDraws(t *testing.T) {
+	setConfig(t, func(c *Config) { c.Bell, c.DesktopNotify = true, false })
+	var picker strings.Builder
+	saved := tuiOutput
+	tuiOutput = &picker
+	defer func() { tuiOutput = saved }()
+	out := stdoutOf(t, func() { alert([]ClaudeSession{testSession("a", StatusWaiting)})() })
+	if out != "" || picker.String() != "\a" {
+		t.Errorf("stdout %q, picker output %q; want the bell on the picker's output only", out, picker.String())
+	}
+}
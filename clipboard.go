@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Clipboard
+
+// errNoClipboard is returned when no clipboard tool could take the text.
+var errNoClipboard = errors.New("no clipboard tool found (install pbcopy, wl-copy, xclip or xsel)")
+
+// clipboardCommands lists candidate clipboard writers in preference order.
+// tmux load-buffer -w comes last: it forwards to the outer terminal via
+// OSC 52, which not every terminal honors.
+func clipboardCommands() [][]string {
+	var cmds [][]string
+	if runtime.GOOS == "darwin" {
+		cmds = append(cmds, []string{"pbcopy"})
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	cmds = append(cmds,
+		[]string{"xclip", "-selection", "clipboard"},
+		[]string{"xsel", "--clipboard", "--input"},
+		[]string{"tmux", "load-buffer", "-w", "-"},
+	)
+	return cmds
+}
+
+// copyToClipboard writes text with the first available clipboard tool.
+func copyToClipboard(text string) error {
+	for _, args := range clipboardCommands() {
+		cmd := exec.Command(args[0], args[1:]...)
+		if args[0] == "tmux" {
+			cmd = tmuxCommand(args[1:]...)
+		}
+		if _, err := exec.LookPath(cmd.Args[0]); err != nil {
+			continue
+		}
+		cmd.Stdin = strings.NewReader(text)
+		if cmd.Run() == nil {
+			return nil
+		}
+	}
+	return errNoClipboard
+}
+
+// copyPromptGuard reports why s's prompt cannot be copied with P.
+func copyPromptGuard(s ClaudeSession) error {
+	if s.Status != StatusWaiting {
+		return fmt.Errorf("only waiting sessions have a prompt to copy")
+	}
+	if s.Prompt == "" {
+		return fmt.Errorf("no question found in %s", s.PaneID)
+	}
+	return nil
+}
+
+// copyCmd copies text and reports the result under label.
+func copyCmd(text, label string) tea.Cmd {
+	return func() tea.Msg {
+		if err := copyToClipboard(text); err != nil {
+			return actionMsg{err: err}
+		}
+		return actionMsg{notice: "Copied " + label}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// fakeClipboard puts an xclip that saves its input on an otherwise empty
+// $PATH, and returns the file it saves to.
+func fakeClipboard(t *testing.T) string {
+	t.Helper()
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip(err)
+	}
+	bin, saved := t.TempDir(), filepath.Join(t.TempDir(), "clipboard")
+	script := "#!/bin/sh\nexec " + cat + " > " + saved + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "xclip"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("WAYLAND_DISPLAY", "")
+	return saved
+}
+
+func TestCopySwitchCommand(t *testing.T) {
+	setConfig(t, nil)
+	saved := fakeClipboard(t)
+	m := update(newModel(), scanned(testSession("a", StatusIdle)))
+	_, cmd := m.Update(press("y"))
+	if cmd == nil {
+		t.Fatal("y did nothing")
+	}
+	msg, ok := cmd().(actionMsg)
+	if !ok || msg.err != nil || msg.notice != "Copied switch command: tmux switch-client -t 'a:0.0'" {
+		t.Errorf("y reported %+v", msg)
+	}
+	if data, _ := os.ReadFile(saved); string(data) != "tmux switch-client -t 'a:0.0'" {
+		t.Errorf("clipboard holds %q", data)
+	}
+}
+
+func TestCopyWithoutClipboardTool(t *testing.T) {
+	setConfig(t, nil)
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("WAYLAND_DISPLAY", "")
+	if err := copyToClipboard("x"); !errors.Is(err, errNoClipboard) {
+		t.Errorf("copyToClipboard = %v, want errNoClipboard", err)
+	}
+	msg := copyCmd("x", "x")().(actionMsg)
+	if !errors.Is(msg.err, errNoClipboard) {
+		t.Errorf("copyCmd reported %+v, want errNoClipboard", msg)
+	}
+}
+
+func TestCopyPrompt(t *testing.T) {
+	asking := testSession("a", StatusWaiting)
+	asking.Prompt = "Do you want to create fix.go?"
+	silent := testSession("b", StatusWaiting)
+	idle := testSession("c", StatusIdle)
+	idle.Prompt = "stale question"
+	tests := []struct {
+		s      ClaudeSession
+		notice string // "" when P copies
+	}{
+		{asking, ""},
+		{silent, "no question found in b:0.0"},
+		{idle, "only waiting sessions have a prompt to copy"},
+	}
+	for _, tt := range tests {
+		setConfig(t, nil)
+		saved := fakeClipboard(t)
+		m := update(newModel(), scanned(tt.s))
+		next, cmd := m.Update(press("P"))
+		if tt.notice != "" {
+			if cmd != nil || next.(model).notice != tt.notice {
+				t.Errorf("P on %s: notice %q, want %q", tt.s.PaneID, next.(model).notice, tt.notice)
+			}
+			continue
+		}
+		if cmd == nil {
+			t.Fatalf("P on %s did nothing", tt.s.PaneID)
+		}
+		if msg := cmd().(actionMsg); msg.err != nil || msg.notice != "Copied prompt of a:0.0" {
+			t.Errorf("P reported %+v", msg)
+		}
+		if data, _ := os.ReadFile(saved); string(data) != tt.s.Prompt {
+			t.Errorf("clipboard holds %q, want %q", data, tt.s.Prompt)
+		}
+	}
+}
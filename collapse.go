@@ -0,0 +1,48 @@
+package main
+
+import "fmt"
+
+// Collapsed idle sessions
+//
+// With the c toggle on, idle sessions are folded into one summary row after
+// the listed sessions. The summary row is a cursor position of its own:
+// m.sessions holds only the listed sessions, and index len(m.sessions) is
+// the summary row when there is one.
+
+// collapseIdle removes idle sessions from sessions, except pinned ones,
+// and returns how many it removed.
+func collapseIdle(sessions []ClaudeSession, pinned map[string]bool) ([]ClaudeSession, int) {
+	var shown []ClaudeSession
+	hidden := 0
+	for _, s := range sessions {
+		if s.Status == StatusIdle && !pinned[s.Path] {
+			hidden++
+			continue
+		}
+		shown = append(shown, s)
+	}
+	return shown, hidden
+}
+
+// rowCount is the number of cursor positions: sessions plus the summary row.
+func (m model) rowCount() int {
+	if m.collapsedIdle > 0 {
+		return len(m.sessions) + 1
+	}
+	return len(m.sessions)
+}
+
+// onSummaryRow reports whether the cursor is on the collapsed idle row.
+func (m model) onSummaryRow() bool {
+	return m.collapsedIdle > 0 && m.cursor == len(m.sessions)
+}
+
+// summaryRow renders the collapsed idle row.
+func (m model) summaryRow() string {
+	noun := "sessions"
+	if m.collapsedIdle == 1 {
+		noun = "session"
+	}
+	return statusStyles[StatusIdle].Render(fmt.Sprintf("%s %d idle %s", statusSymbol(StatusIdle), m.collapsedIdle, noun)) +
+		dimStyle.Render("  (enter or c to expand)")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollapseIdle(t *testing.T) {
+	sessions := []ClaudeSession{
+		testSession("a", StatusIdle),
+		testSession("b", StatusWaiting),
+		testSession("c", StatusIdle),
+		testSession("d", StatusWorking),
+	}
+	tests := []struct {
+		pinned map[string]bool
+		shown  string
+		hidden int
+	}{
+		{nil, "b d", 2},
+		{map[string]bool{"~/c": true}, "b c d", 1}, // pinned idle sessions stay listed
+	}
+	for _, tt := range tests {
+		shown, hidden := collapseIdle(sessions, tt.pinned)
+		if names(shown) != tt.shown || hidden != tt.hidden {
+			t.Errorf("pinned %v: shown %q, hidden %d; want %q, %d", tt.pinned, names(shown), hidden, tt.shown, tt.hidden)
+		}
+	}
+}
+
+func TestCollapseNavigation(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusWaiting),
+		testSession("c", StatusIdle), testSession("d", StatusWorking)), press("c"))
+	if got := names(m.sessions); got != "b d" || m.rowCount() != 3 {
+		t.Fatalf("collapsed: listed %q, %d rows; want b d and the summary row", got, m.rowCount())
+	}
+	tests := []struct {
+		key     string
+		cursor  int
+		summary bool
+	}{
+		{"j", 1, false},
+		{"j", 2, true}, // the summary row is the last cursor position
+		{"j", 0, false},
+		{"k", 2, true},
+	}
+	for _, tt := range tests {
+		m = update(m, press(tt.key))
+		if m.cursor != tt.cursor || m.onSummaryRow() != tt.summary {
+			t.Errorf("%s: cursor %d, summary %v; want %d, %v", tt.key, m.cursor, m.onSummaryRow(), tt.cursor, tt.summary)
+		}
+	}
+	rows := m.renderRows()
+	if last := rows[len(rows)-1]; !strings.Contains(last, "2 idle sessions") {
+		t.Errorf("summary row %q", last)
+	}
+
+	m = update(m, press("enter"))
+	if m.collapse || len(m.sessions) != 4 || m.sessions[m.cursor].Status != StatusIdle {
+		t.Errorf("enter on the summary row: collapse %v, %d listed, cursor on %s", m.collapse, len(m.sessions), m.sessions[m.cursor].SessionName)
+	}
+
+	// Collapsing under an idle row keeps the cursor on a real position.
+	m = update(m, press("G"), press("c"))
+	if m.cursor >= m.rowCount() {
+		t.Errorf("cursor %d past %d rows", m.cursor, m.rowCount())
+	}
+}
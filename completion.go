@@ -0,0 +1,228 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Shell completion
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// flagSpec describes one flag for a completion script.
+type flagSpec struct {
+	name   string
+	usage  string
+	values bool // takes an argument (not a bool flag)
+}
+
+func flagSpecs(flags *flag.FlagSet) []flagSpec {
+	var specs []flagSpec
+	if flags == nil {
+		return specs
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		if hiddenFlags[f.Name] {
+			return
+		}
+		_, usage := flag.UnquoteUsage(f)
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		specs = append(specs, flagSpec{name: f.Name, usage: usage, values: !(ok && b.IsBoolFlag())})
+	})
+	return specs
+}
+
+// completionCommand is a subcommand as seen by the completion scripts.
+type completionCommand struct {
+	name, summary string
+	flags         []flagSpec
+	args          []string // fixed positional words, e.g. the shells
+}
+
+func completionCommands() []completionCommand {
+	var cmds []completionCommand
+	for _, c := range commands {
+		cc := completionCommand{name: c.name, summary: c.summary}
+		if c.flags != nil {
+			cc.flags = flagSpecs(c.flags())
+		}
+		cmds = append(cmds, cc)
+	}
+	return append(cmds,
+		completionCommand{name: "completion", summary: "Print a shell completion script", args: completionShells},
+		completionCommand{name: "help", summary: "Show help for a command", args: commandNames()},
+	)
+}
+
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return names
+}
+
+func runCompletion(root *flag.FlagSet, args []string) int {
+	flags := flag.NewFlagSet("completion", flag.ContinueOnError)
+	flags.Usage = usage(flags, "csm completion bash|zsh|fish",
+		"Print a completion script for the given shell. For example:\n\n"+
+			"  source <(csm completion bash)\n"+
+			"  csm completion zsh > \"${fpath[1]}/_csm\"\n"+
+			"  csm completion fish > ~/.config/fish/completions/csm.fish")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if err := writeCompletion(os.Stdout, flags.Arg(0), flagSpecs(root), completionCommands()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+func writeCompletion(w io.Writer, shell string, global []flagSpec, cmds []completionCommand) error {
+	switch shell {
+	case "bash":
+		writeBash(w, global, cmds)
+	case "zsh":
+		writeZsh(w, global, cmds)
+	case "fish":
+		writeFish(w, global, cmds)
+	default:
+		return fmt.Errorf("unknown shell %q: want one of %s", shell, strings.Join(completionShells, ", "))
+	}
+	return nil
+}
+
+func flagWords(specs []flagSpec) string {
+	words := make([]string, len(specs))
+	for i, f := range specs {
+		words[i] = "--" + f.name
+	}
+	return strings.Join(words, " ")
+}
+
+func writeBash(w io.Writer, global []flagSpec, cmds []completionCommand) {
+	var valued []string
+	for _, f := range global {
+		if f.values {
+			valued = append(valued, "--"+f.name, "-"+f.name)
+		}
+	}
+	names := make([]string, len(cmds))
+	for i, c := range cmds {
+		names[i] = c.name
+	}
+
+	fmt.Fprintf(w, "# bash completion for csm\n")
+	fmt.Fprintf(w, "_csm() {\n")
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" cmd=\"\" opts i\n")
+	fmt.Fprintf(w, "    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(w, "        case \"${COMP_WORDS[i]}\" in\n")
+	if len(valued) > 0 {
+		fmt.Fprintf(w, "        %s) ((i++)) ;;\n", strings.Join(valued, "|"))
+	}
+	fmt.Fprintf(w, "        -*) ;;\n")
+	fmt.Fprintf(w, "        *) cmd=\"${COMP_WORDS[i]}\"; break ;;\n")
+	fmt.Fprintf(w, "        esac\n")
+	fmt.Fprintf(w, "    done\n")
+	fmt.Fprintf(w, "    case \"$cmd\" in\n")
+	fmt.Fprintf(w, "    \"\") opts=%s ;;\n", shellQuote(strings.Join(names, " ")+" "+flagWords(global)))
+	for _, c := range cmds {
+		words := strings.TrimSpace(flagWords(c.flags) + " " + strings.Join(c.args, " "))
+		fmt.Fprintf(w, "    %s) opts=%s ;;\n", c.name, shellQuote(words))
+	}
+	fmt.Fprintf(w, "    *) opts=\"\" ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "    COMPREPLY=($(compgen -W \"$opts\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -F _csm csm\n")
+}
+
+// zshEscape makes s safe inside a single-quoted _arguments or _describe spec.
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func zshFlags(specs []flagSpec) string {
+	var b strings.Builder
+	for _, f := range specs {
+		if f.values {
+			fmt.Fprintf(&b, " \\\n        '--%s=[%s]:value:'", f.name, zshEscape(f.usage))
+		} else {
+			fmt.Fprintf(&b, " \\\n        '--%s[%s]'", f.name, zshEscape(f.usage))
+		}
+	}
+	return b.String()
+}
+
+func writeZsh(w io.Writer, global []flagSpec, cmds []completionCommand) {
+	fmt.Fprintf(w, "#compdef csm\n\n")
+	fmt.Fprintf(w, "_csm() {\n")
+	fmt.Fprintf(w, "    local -a commands\n")
+	fmt.Fprintf(w, "    commands=(\n")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "        '%s:%s'\n", c.name, strings.ReplaceAll(c.summary, "'", `'\''`))
+	}
+	fmt.Fprintf(w, "    )\n")
+	fmt.Fprintf(w, "    local state line\n")
+	fmt.Fprintf(w, "    _arguments -C%s \\\n", zshFlags(global))
+	fmt.Fprintf(w, "        '1: :->command' \\\n")
+	fmt.Fprintf(w, "        '*:: :->args'\n")
+	fmt.Fprintf(w, "    case $state in\n")
+	fmt.Fprintf(w, "    command) _describe 'command' commands ;;\n")
+	fmt.Fprintf(w, "    args)\n")
+	fmt.Fprintf(w, "        case $line[1] in\n")
+	for _, c := range cmds {
+		switch {
+		case len(c.flags) > 0:
+			fmt.Fprintf(w, "        %s) _arguments%s ;;\n", c.name, zshFlags(c.flags))
+		case len(c.args) > 0:
+			fmt.Fprintf(w, "        %s) _values '%s' %s ;;\n", c.name, c.name, strings.Join(c.args, " "))
+		}
+	}
+	fmt.Fprintf(w, "        esac ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "_csm \"$@\"\n")
+}
+
+// fishQuote single-quotes s for fish, which escapes only \ and '.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func fishFlags(w io.Writer, cond string, specs []flagSpec) {
+	for _, f := range specs {
+		r := ""
+		if f.values {
+			r = " -r"
+		}
+		fmt.Fprintf(w, "complete -c csm -n %s -l %s%s -d %s\n", fishQuote(cond), f.name, r, fishQuote(f.usage))
+	}
+}
+
+func writeFish(w io.Writer, global []flagSpec, cmds []completionCommand) {
+	fmt.Fprintf(w, "# fish completion for csm\n")
+	fmt.Fprintf(w, "complete -c csm -f\n")
+	fishFlags(w, "__fish_use_subcommand", global)
+	for _, c := range cmds {
+		fmt.Fprintf(w, "complete -c csm -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+	}
+	for _, c := range cmds {
+		cond := "__fish_seen_subcommand_from " + c.name
+		fishFlags(w, cond, c.flags)
+		if len(c.args) > 0 {
+			fmt.Fprintf(w, "complete -c csm -n %s -a %s\n", fishQuote(cond), fishQuote(strings.Join(c.args, " ")))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stdoutOf returns what fn writes to os.Stdout.
+func stdoutOf(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saved := os.Stdout
+	os.Stdout = f
+	fn()
+	os.Stdout = saved
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			setConfig(t, nil)
+			code := 0
+			script := stdoutOf(t, func() { code = run([]string{"completion", shell}) })
+			if code != 0 {
+				t.Fatalf("exit %d", code)
+			}
+			for _, want := range []string{"csm", "list", "watch", "serve", "min-status", "attention-exit-code"} {
+				if !strings.Contains(script, want) {
+					t.Errorf("script does not mention %s", want)
+				}
+			}
+			if strings.Contains(script, "replay\n") || strings.Contains(script, "--replay ") {
+				t.Error("script completes the hidden --replay flag")
+			}
+			// Let the shell itself check the syntax where it is installed.
+			bin, err := exec.LookPath(shell)
+			if err != nil {
+				return
+			}
+			file := filepath.Join(t.TempDir(), "csm."+shell)
+			if err := os.WriteFile(file, []byte(script), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if out, err := exec.Command(bin, "-n", file).CombinedOutput(); err != nil {
+				t.Errorf("%s -n: %v\n%s", shell, err, out)
+			}
+		})
+	}
+}
+
+func TestCompletionUnknownShell(t *testing.T) {
+	var out strings.Builder
+	if err := writeCompletion(&out, "tcsh", nil, nil); err == nil || out.Len() != 0 {
+		t.Errorf("writeCompletion(tcsh) = %v, wrote %q", err, out.String())
+	}
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Config holds user settings loaded from the config file.
+// Every field is optional; missing keys keep their defaults.
+type Config struct {
+	// Tags tint session names by path. The first matching tag wins.
+	Tags []ColorTag `json:"tags"`
+
+	// CursorFollow controls the cursor across rescans: "id" keeps the same
+	// session selected, "row" keeps the cursor on the same row.
+	CursorFollow string `json:"cursor_follow"`
+
+	// LaunchCmd is typed into new windows opened with the launcher.
+	LaunchCmd string `json:"launch_cmd"`
+
+	// WaitPatterns classify Waiting sessions; checked before the built-in table.
+	WaitPatterns []WaitPattern `json:"wait_patterns"`
+
+	// MinStatus hides sessions needing less attention: idle, working or waiting.
+	MinStatus string `json:"min_status"`
+
+	// CaptureConcurrency caps concurrent capture-pane processes per scan.
+	CaptureConcurrency int `json:"capture_concurrency"`
+
+	// LoadThreshold is the 1-minute load average above which scans back
+	// off: less often and with fewer concurrent captures. 0 disables it.
+	LoadThreshold float64 `json:"load_threshold"`
+
+	// EnableZoom enables the z key, which switches and zooms the pane.
+	EnableZoom bool `json:"enable_zoom"`
+
+	// WrapNavigation makes j/k wrap around the ends of the list.
+	WrapNavigation bool `json:"wrap_navigation"`
+
+	// DeepStatus also captures working panes so content parsers
+	// (turn_pattern) apply to them. Costs one capture-pane per working pane.
+	DeepStatus bool `json:"deep_status"`
+
+	// TurnPattern is a regular expression for a turn/token indicator in the
+	// pane content, e.g. "(\\d+k? tokens)". Empty disables it.
+	TurnPattern string `json:"turn_pattern"`
+
+	// ProgressPattern is a regular expression for a progress indicator in
+	// a working pane, with one capture group for a percentage or two for
+	// done/total, e.g. "step (\\d+)/(\\d+)". Needs deep_status. Empty
+	// disables it.
+	ProgressPattern string `json:"progress_pattern"`
+
+	// OnSelect, when set, runs instead of switch-client for the chosen
+	// session, with {pane}, {path} and {name} substituted.
+	OnSelect string `json:"on_select"`
+
+	// Theme is a color preset: dark, light, high-contrast or cb-safe.
+	Theme string `json:"theme"`
+
+	// Colors override individual palette keys on top of Theme.
+	Colors map[string]string `json:"colors"`
+
+	// ShowNumbers shows the 1-9 quick-select column.
+	ShowNumbers bool `json:"show_numbers"`
+
+	// NumberShortcuts enables the 1-9 keys, shown or not.
+	NumberShortcuts bool `json:"number_shortcuts"`
+
+	// EnableQuickAnswer allows Y to switch to a waiting session and
+	// answer it in one keystroke. Off by default since it approves blindly.
+	EnableQuickAnswer bool `json:"enable_quick_answer"`
+
+	// QuickAnswer is typed before Enter by Y; empty sends Enter alone,
+	// accepting the highlighted default.
+	QuickAnswer string `json:"quick_answer"`
+
+	// Bell rings the terminal bell when a session starts waiting.
+	Bell bool `json:"bell"`
+
+	// DesktopNotify posts a desktop notification when a session starts waiting.
+	DesktopNotify bool `json:"desktop_notify"`
+
+	// SoundFile is a sound played when a session starts waiting, with
+	// paplay, afplay or PowerShell. Empty disables it.
+	SoundFile string `json:"sound_file"`
+
+	// NotifyDebounce is the minimum time between alerts for one pane.
+	NotifyDebounce string `json:"notify_debounce"`
+
+	// SkipCurrent makes Tab/Shift+Tab pass over the pane being viewed.
+	SkipCurrent bool `json:"skip_current"`
+
+	// MatchWindowName falls back to #{window_name} when the pane title
+	// has no Claude marker.
+	MatchWindowName bool `json:"match_window_name"`
+
+	// CopyTemplate is the command copied by y; {pane}, {path} and {name}
+	// are substituted.
+	CopyTemplate string `json:"copy_template"`
+
+	// CaptureLines is how many lines of scrollback capture-pane reads.
+	CaptureLines int `json:"capture_lines"`
+
+	// AutoKillIdle offers to kill sessions idle longer than this duration,
+	// after confirmation. Empty disables it.
+	AutoKillIdle string `json:"auto_kill_idle"`
+
+	// Border frames the list in a rounded border sized to the terminal.
+	Border bool `json:"border"`
+
+	// Density is "compact", one line per row, or "comfortable", with a
+	// blank line between rows.
+	Density string `json:"density"`
+
+	// Sort is the initial sort mode: pane or age.
+	Sort string `json:"sort"`
+
+	// ShowAge adds a column with the age of each tmux session.
+	ShowAge bool `json:"show_age"`
+
+	// PassthroughCommands are foreground commands, like ssh or docker, that
+	// run Claude elsewhere; their panes are trusted on the title alone.
+	PassthroughCommands []string `json:"passthrough_commands"`
+
+	// OnWaiting runs, in the background, when a session starts waiting;
+	// {pane}, {path}, {name} and {prompt} are substituted.
+	OnWaiting string `json:"on_waiting"`
+
+	// Footer shows a status line with totals and the clock above the help.
+	Footer bool `json:"footer"`
+
+	// Columns lists the row columns in display order: number, symbol,
+	// label, session, age, path, branch and title.
+	Columns []string `json:"columns"`
+
+	// SearchFields are the fields the / filter matches: name, title,
+	// path and branch.
+	SearchFields []string `json:"search_fields"`
+
+	// WaitingMarkers are extra phrases that mark a session as Waiting when
+	// found after the last prompt, matched case-insensitively.
+	WaitingMarkers []string `json:"waiting_markers"`
+
+	// ReplyMarkers are extra line prefixes that mark Claude's replies,
+	// for telling a replied idle session from an empty prompt.
+	ReplyMarkers []string `json:"reply_markers"`
+
+	// IdleStaleAfter is how long a replied idle session counts as fresh,
+	// e.g. "10m". "0" keeps it fresh until the screen changes.
+	IdleStaleAfter string `json:"idle_stale_after"`
+
+	// PopupTitle publishes a waiting summary in the tmux option @csm_title
+	// while csm runs in a popup, for use in the popup border title.
+	PopupTitle bool `json:"popup_title"`
+
+	// IncludePaths, when set, limits sessions to those at or below one of
+	// these directories.
+	IncludePaths []string `json:"include_paths"`
+
+	// ExcludePaths hides sessions at or below these directories; it wins
+	// over IncludePaths.
+	ExcludePaths []string `json:"exclude_paths"`
+
+	// CommandTimeout bounds each tmux call made by a scan, e.g. "2s".
+	// "0" disables it.
+	CommandTimeout string `json:"command_timeout"`
+
+	// ShowExited lists panes whose Claude exited as StatusExited instead
+	// of dropping them.
+	ShowExited bool `json:"show_exited"`
+
+	// WaitingWindowStyle, when set, is applied as window-status-style to
+	// tmux windows holding a Waiting session, e.g. "bg=colour214,fg=black",
+	// so the tmux status bar shows them while csm is closed.
+	WaitingWindowStyle string `json:"waiting_window_style"`
+
+	// FlashDuration briefly flashes the row of a session that starts
+	// waiting, e.g. "2s". "0" disables it.
+	FlashDuration string `json:"flash_duration"`
+
+	// ScanOnKey rescans on every key press in the list, at most once per
+	// keyScanInterval, so the list is fresh whenever it is used.
+	ScanOnKey bool `json:"scan_on_key"`
+
+	// TmuxBin is the tmux executable, a name looked up on PATH or a path.
+	TmuxBin string `json:"tmux_bin"`
+
+	// TmuxArgs are put before every tmux command, e.g. ["-L", "work"] to
+	// talk to a server on another socket.
+	TmuxArgs []string `json:"tmux_args"`
+
+	// ScopedKeys are quick answers for sessions at matching paths; see
+	// scopedAnswer.
+	ScopedKeys []ScopedKeys `json:"scoped_keys"`
+
+	// ShowClients queries how many tmux clients are attached to each
+	// session and marks sessions that have any.
+	ShowClients bool `json:"show_clients"`
+
+	// Layouts are named tmux command sequences offered by L; see Layout.
+	Layouts []Layout `json:"layouts"`
+
+	// ConfirmStyle draws y/n questions "inline" in the help line or as a
+	// "modal" box over the list.
+	ConfirmStyle string `json:"confirm_style"`
+
+	minStatus  int            // parsed MinStatus
+	debounce   time.Duration  // parsed NotifyDebounce
+	turnRe     *regexp.Regexp // compiled TurnPattern
+	progressRe *regexp.Regexp // compiled ProgressPattern
+
+	autoKillIdle   time.Duration   // parsed AutoKillIdle; 0 disables
+	passthrough    map[string]bool // set of PassthroughCommands
+	sequential     bool            // set by --sequential: capture panes one at a time
+	deepDetect     bool            // set by --deep-detect: match untitled panes by process
+	motionDetect   bool            // set by --motion-detect: changing content means working
+	allUsers       bool            // set by --all-users: also scan other tmux server sockets
+	ascii          bool            // set by --ascii: draw ASCII instead of Unicode glyphs
+	includeRoots   []string        // cleaned, expanded IncludePaths
+	excludeRoots   []string        // cleaned, expanded ExcludePaths
+	commandTimeout time.Duration   // parsed CommandTimeout; 0 disables
+	flash          time.Duration   // parsed FlashDuration; 0 disables
+	idleStale      time.Duration   // parsed IdleStaleAfter; 0 disables
+}
+
+// ScopedKeys binds keys to answers for sessions whose path matches Glob.
+// Glob is matched like ColorTag.Glob.
+type ScopedKeys struct {
+	Glob string            `json:"glob"`
+	Keys map[string]string `json:"keys"` // key, as bubbletea names it, → answer
+}
+
+// ColorTag maps a path glob to a color for the session name.
+// Glob is matched with filepath.Match against the displayed Path
+// (home directory shortened to ~).
+type ColorTag struct {
+	Glob  string `json:"glob"`
+	Color string `json:"color"`
+}
+
+// cfg is the active configuration, set once at startup.
+var cfg = defaultConfig()
+
+func defaultConfig() Config {
+	return Config{
+		CursorFollow:        "id",
+		Density:             "compact",
+		ConfirmStyle:        "inline",
+		LaunchCmd:           "claude",
+		MinStatus:           "idle",
+		Theme:               "dark",
+		NotifyDebounce:      "30s",
+		CaptureConcurrency:  8,
+		EnableZoom:          true,
+		WrapNavigation:      true,
+		ShowNumbers:         true,
+		NumberShortcuts:     true,
+		CopyTemplate:        "tmux switch-client -t {pane}",
+		CaptureLines:        50,
+		Sort:                sortPane,
+		PassthroughCommands: slices.Clone(defaultPassthroughCommands),
+		Footer:              true,
+		Columns:             slices.Clone(defaultColumns),
+		SearchFields:        slices.Clone(defaultSearchFields),
+		CommandTimeout:      "2s",
+		FlashDuration:       "0",
+		IdleStaleAfter:      "10m",
+		TmuxBin:             "tmux",
+	}
+}
+
+// configPath returns $XDG_CONFIG_HOME/csm/config.json, falling back to ~/.config.
+func configPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "csm", "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "csm", "config.json")
+}
+
+// loadConfig reads the config file at path. A missing file is not an error.
+func loadConfig(path string) (Config, error) {
+	c := defaultConfig()
+	data, err := os.ReadFile(path)
+	switch {
+	case path == "" || errors.Is(err, fs.ErrNotExist):
+		return c, c.validate()
+	case err != nil:
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	switch c.CursorFollow {
+	case "id", "row":
+	default:
+		return fmt.Errorf("cursor_follow: want id or row, got %q", c.CursorFollow)
+	}
+	switch c.Density {
+	case "compact", "comfortable":
+	default:
+		return fmt.Errorf("density: want compact or comfortable, got %q", c.Density)
+	}
+	switch c.ConfirmStyle {
+	case "inline", "modal":
+	default:
+		return fmt.Errorf("confirm_style: want inline or modal, got %q", c.ConfirmStyle)
+	}
+	min, err := parseMinStatus(c.MinStatus)
+	if err != nil {
+		return err
+	}
+	c.minStatus = min
+	d, err := time.ParseDuration(c.NotifyDebounce)
+	if err != nil {
+		return fmt.Errorf("notify_debounce: %w", err)
+	}
+	c.debounce = d
+	timeout, err := time.ParseDuration(c.CommandTimeout)
+	if err != nil || timeout < 0 {
+		return fmt.Errorf("command_timeout: want a duration such as 2s (0 disables), got %q", c.CommandTimeout)
+	}
+	c.commandTimeout = timeout
+	flash, err := time.ParseDuration(c.FlashDuration)
+	if err != nil || flash < 0 {
+		return fmt.Errorf("flash_duration: want a duration such as 2s (0 disables), got %q", c.FlashDuration)
+	}
+	c.flash = flash
+	stale, err := time.ParseDuration(c.IdleStaleAfter)
+	if err != nil || stale < 0 {
+		return fmt.Errorf("idle_stale_after: want a duration such as 10m (0 disables), got %q", c.IdleStaleAfter)
+	}
+	c.idleStale = stale
+	c.autoKillIdle = 0
+	if c.AutoKillIdle != "" {
+		d, err := time.ParseDuration(c.AutoKillIdle)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("auto_kill_idle: want a positive duration, got %q", c.AutoKillIdle)
+		}
+		c.autoKillIdle = d
+	}
+	c.turnRe = nil
+	if c.TurnPattern != "" {
+		re, err := regexp.Compile(c.TurnPattern)
+		if err != nil {
+			return fmt.Errorf("turn_pattern: %w", err)
+		}
+		c.turnRe = re
+	}
+	c.progressRe = nil
+	if c.ProgressPattern != "" {
+		re, err := compileProgress(c.ProgressPattern)
+		if err != nil {
+			return fmt.Errorf("progress_pattern: %w", err)
+		}
+		c.progressRe = re
+	}
+	c.passthrough = make(map[string]bool, len(c.PassthroughCommands))
+	for _, cmd := range c.PassthroughCommands {
+		if strings.TrimSpace(cmd) == "" {
+			return fmt.Errorf("passthrough_commands: empty command name")
+		}
+		c.passthrough[cmd] = true
+	}
+	if len(c.SearchFields) == 0 {
+		return fmt.Errorf("search_fields: list at least one field")
+	}
+	for _, f := range c.SearchFields {
+		if _, ok := searchFields[f]; !ok {
+			return fmt.Errorf("search_fields: unknown field %q (want name, title, path or branch)", f)
+		}
+	}
+	if err := validateColumns(c.Columns); err != nil {
+		return err
+	}
+	if !validSortMode(c.Sort) {
+		return fmt.Errorf("sort: want one of %v, got %q", sortModes, c.Sort)
+	}
+	if c.CaptureLines < 1 {
+		return fmt.Errorf("capture_lines must be at least 1, got %d", c.CaptureLines)
+	}
+	if c.LoadThreshold < 0 {
+		return fmt.Errorf("load_threshold: want 0 (off) or a positive load average, got %g", c.LoadThreshold)
+	}
+	if c.CaptureConcurrency < 1 {
+		return fmt.Errorf("capture_concurrency must be at least 1, got %d", c.CaptureConcurrency)
+	}
+	if strings.TrimSpace(c.LaunchCmd) == "" {
+		return fmt.Errorf("launch_cmd must not be empty")
+	}
+	if err := validateTheme(c.Theme, c.Colors); err != nil {
+		return err
+	}
+	if err := validateTemplate(c.OnSelect, sessionPlaceholders); err != nil {
+		return fmt.Errorf("on_select: %w", err)
+	}
+	if err := validateTemplate(c.OnWaiting, sessionPlaceholders); err != nil {
+		return fmt.Errorf("on_waiting: %w", err)
+	}
+	if err := validateTemplate(c.CopyTemplate, sessionPlaceholders); err != nil {
+		return fmt.Errorf("copy_template: %w", err)
+	}
+	for _, p := range append(append([]string{}, c.IncludePaths...), c.ExcludePaths...) {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("include_paths/exclude_paths: empty path")
+		}
+	}
+	c.includeRoots = cleanRoots(c.IncludePaths)
+	c.excludeRoots = cleanRoots(c.ExcludePaths)
+	for _, m := range c.WaitingMarkers {
+		if strings.TrimSpace(m) == "" {
+			return fmt.Errorf("waiting_markers: empty marker")
+		}
+	}
+	for _, m := range c.ReplyMarkers {
+		if strings.TrimSpace(m) == "" {
+			return fmt.Errorf("reply_markers: empty marker")
+		}
+	}
+	for _, p := range c.WaitPatterns {
+		if _, ok := waitReasonNames[p.Reason]; !ok {
+			return fmt.Errorf("wait_patterns %q: unknown reason %q", p.Pattern, p.Reason)
+		}
+	}
+	for _, t := range c.Tags {
+		if _, err := filepath.Match(t.Glob, ""); err != nil {
+			return fmt.Errorf("tag %q: %w", t.Glob, err)
+		}
+	}
+	if err := validateLayouts(c.Layouts); err != nil {
+		return err
+	}
+	for _, sk := range c.ScopedKeys {
+		if _, err := filepath.Match(sk.Glob, ""); err != nil {
+			return fmt.Errorf("scoped_keys %q: %w", sk.Glob, err)
+		}
+		for key := range sk.Keys {
+			if key == "" || key == "ctrl+c" {
+				return fmt.Errorf("scoped_keys %q: cannot bind %q", sk.Glob, key)
+			}
+		}
+	}
+	return nil
+}
+
+// scopedAnswer resolves key for a session at path: the answer from the
+// first scoped_keys entry whose glob matches path and that binds key.
+// It reports false when no entry does, leaving key to the global bindings.
+func scopedAnswer(scopes []ScopedKeys, path, key string) (string, bool) {
+	for _, sk := range scopes {
+		if ok, _ := filepath.Match(sk.Glob, path); !ok {
+			continue
+		}
+		if answer, ok := sk.Keys[key]; ok {
+			return answer, true
+		}
+	}
+	return "", false
+}
+
+// tagColor returns the color of the first tag whose glob matches path.
+func tagColor(tags []ColorTag, path string) (lipgloss.Color, bool) {
+	for _, t := range tags {
+		if ok, _ := filepath.Match(t.Glob, path); ok {
+			return lipgloss.Color(t.Color), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// setConfig makes the default config, changed by edit and validated, the
+// active one for the rest of the test.
+func setConfig(t *testing.T, edit func(c *Config)) {
+	t.Helper()
+	c := defaultConfig()
+	if edit != nil {
+		edit(&c)
+	}
+	if err := c.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	saved := cfg
+	cfg = c
+	t.Cleanup(func() { cfg = saved })
+}
+
+func TestTagColor(t *testing.T) {
+	tags := []ColorTag{
+		{Glob: "~/work/api*", Color: "1"},
+		{Glob: "~/work/*", Color: "2"},
+		{Glob: "/tmp/*", Color: "3"},
+	}
+	tests := []struct {
+		path  string
+		color lipgloss.Color
+		ok    bool
+	}{
+		{"~/work/api-server", "1", true}, // first matching tag wins
+		{"~/work/web", "2", true},
+		{"/tmp/scratch", "3", true},
+		{"/tmp/a/b", "", false}, // * does not cross /
+		{"~/play", "", false},
+	}
+	for _, tt := range tests {
+		color, ok := tagColor(tags, tt.path)
+		if color != tt.color || ok != tt.ok {
+			t.Errorf("tagColor(%q) = %q, %v; want %q, %v", tt.path, color, ok, tt.color, tt.ok)
+		}
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	c := defaultConfig()
+	c.Tags = []ColorTag{{Glob: "[", Color: "1"}}
+	if err := c.validate(); err == nil {
+		t.Error("validate accepted a malformed tag glob")
+	}
+}
+
+func TestValidateColumns(t *testing.T) {
+	tests := []struct {
+		cols []string
+		ok   bool
+	}{
+		{defaultColumns, true},
+		{[]string{"title", "session"}, true},
+		{[]string{"output"}, true},
+		{nil, false},
+		{[]string{"session", "bogus"}, false},
+		{[]string{"title", "session", "title"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateColumns(tt.cols); (err == nil) != tt.ok {
+			t.Errorf("validateColumns(%q) = %v, want ok %v", tt.cols, err, tt.ok)
+		}
+	}
+}
+
+func TestScopedAnswer(t *testing.T) {
+	scopes := []ScopedKeys{
+		{Glob: "~/work/api", Keys: map[string]string{"Y": "2", "1": "yes"}},
+		{Glob: "~/work/*", Keys: map[string]string{"Y": "1", "2": "no"}},
+	}
+	tests := []struct {
+		path, key string
+		answer    string
+		ok        bool
+	}{
+		{"~/work/api", "Y", "2", true},     // the first matching scope wins
+		{"~/work/api", "2", "no", true},    // later scopes fill in keys it leaves out
+		{"~/work/web", "Y", "1", true},     // a broader scope
+		{"~/work/web", "1", "", false},     // the api scope does not apply
+		{"~/play", "Y", "", false},         // no scope: the global binding
+		{"~/work/api/sub", "Y", "", false}, // * does not cross /
+	}
+	for _, tt := range tests {
+		answer, ok := scopedAnswer(scopes, tt.path, tt.key)
+		if answer != tt.answer || ok != tt.ok {
+			t.Errorf("scopedAnswer(%q, %q) = %q, %v; want %q, %v", tt.path, tt.key, answer, ok, tt.answer, tt.ok)
+		}
+	}
+}
+
+func TestValidateScopedKeys(t *testing.T) {
+	for _, sk := range []ScopedKeys{
+		{Glob: "[", Keys: map[string]string{"Y": "1"}},
+		{Glob: "~/x", Keys: map[string]string{"ctrl+c": "1"}},
+		{Glob: "~/x", Keys: map[string]string{"": "1"}},
+	} {
+		c := defaultConfig()
+		c.ScopedKeys = []ScopedKeys{sk}
+		if err := c.validate(); err == nil {
+			t.Errorf("validate accepted %+v", sk)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Confirmations
+//
+// Every action that needs a y/n first goes through ask, which opens
+// modeConfirm. confirm_style chooses how the question is drawn: inline in
+// the help line, or as a box over the middle of the list.
+
+// confirmation is an action waiting for y/n in modeConfirm.
+type confirmation struct {
+	prompt  string               // question shown in the help line or box
+	run     tea.Cmd              // runs on y
+	build   func(*model) tea.Cmd // if set, makes the command to run on y from the model at that moment
+	decline func(*model)         // runs on n or Esc, if set, e.g. to remember the answer
+}
+
+// ask opens the confirmation c.
+func (m *model) ask(c confirmation) {
+	m.mode, m.confirm = modeConfirm, c
+}
+
+// updateConfirm handles y/n at a confirmation prompt.
+func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		c := m.confirm
+		m.mode, m.confirm = modeNormal, confirmation{}
+		if c.build != nil {
+			return m, c.build(&m)
+		}
+		return m, c.run
+	case "n", "N", "esc":
+		if m.confirm.decline != nil {
+			m.confirm.decline(&m)
+		}
+		m.mode, m.confirm = modeNormal, confirmation{}
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// confirmModal reports whether confirmations are drawn as a box.
+func confirmModal() bool {
+	return cfg.ConfirmStyle == "modal"
+}
+
+// confirmBox renders prompt in a rounded box at most width cells wide,
+// wrapping long prompts.
+func confirmBox(prompt string, width int) []string {
+	border := lipgloss.RoundedBorder()
+	if cfg.ascii {
+		border = lipgloss.ASCIIBorder()
+	}
+	style := lipgloss.NewStyle().Border(border).Padding(0, 2)
+	if width > 0 {
+		style = style.Width(min(width-2, max(lipgloss.Width(prompt)+4, 30)))
+	}
+	body := prompt + "\n\n" + dimStyle.Render(asciiOnly("y confirm · n cancel"))
+	return strings.Split(style.Render(body), "\n")
+}
+
+// overlayCenter replaces the middle lines of lines with box, centered in
+// width, adding lines when there are too few.
+func overlayCenter(lines, box []string, width int) []string {
+	out := append([]string(nil), lines...)
+	for len(out) < len(box) {
+		out = append(out, "")
+	}
+	top := (len(out) - len(box)) / 2
+	indent := strings.Repeat(" ", max(0, (width-maxWidth(box))/2))
+	for i, l := range box {
+		out[top+i] = indent + l
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// confirmedMsg is what the test's confirmation runs on y.
+type confirmedMsg struct{}
+
+func TestConfirmFlow(t *testing.T) {
+	run := func() tea.Msg { return confirmedMsg{} }
+	tests := []struct {
+		key      string
+		mode     int
+		ran      bool
+		declined bool
+	}{
+		{"y", modeNormal, true, false},
+		{"Y", modeNormal, true, false},
+		{"n", modeNormal, false, true},
+		{"N", modeNormal, false, true},
+		{"esc", modeNormal, false, true},
+		{"j", modeConfirm, false, false}, // other keys wait for an answer
+	}
+	for _, tt := range tests {
+		setConfig(t, nil)
+		m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusIdle)))
+		declined := false
+		m.ask(confirmation{prompt: "Really?", run: run, decline: func(m *model) { declined = true; m.notice = "kept" }})
+		next, cmd := m.Update(press(tt.key))
+		got := next.(model)
+		ran := cmd != nil && cmd() == (confirmedMsg{})
+		if got.mode != tt.mode || ran != tt.ran || declined != tt.declined {
+			t.Errorf("%s: mode %d, ran %v, declined %v; want %d, %v, %v", tt.key, got.mode, ran, declined, tt.mode, tt.ran, tt.declined)
+		}
+		if tt.declined && got.notice != "kept" {
+			t.Errorf("%s: decline's change to the model was lost", tt.key)
+		}
+		if tt.mode == modeNormal && got.confirm.run != nil {
+			t.Errorf("%s: the confirmation outlived its answer", tt.key)
+		}
+		if tt.key == "j" && got.cursor != 0 {
+			t.Errorf("j moved the cursor under a confirmation")
+		}
+	}
+
+	// A decline is optional.
+	m := newModel()
+	m.ask(confirmation{prompt: "Really?", run: run})
+	if m = update(m, press("n")); m.mode != modeNormal {
+		t.Errorf("n without a decline: mode %d", m.mode)
+	}
+}
+
+func TestCancelAsksFirst(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusWorking)))
+	next, cmd := m.Update(press("x"))
+	m = next.(model)
+	// The Escape is sent only on y: the command is not run here.
+	if cmd != nil || m.mode != modeConfirm || m.confirm.prompt != "Send Escape to a:0.0, interrupting its work?" || m.confirm.run == nil {
+		t.Errorf("x: mode %d, prompt %q, cmd %v", m.mode, m.confirm.prompt, cmd != nil)
+	}
+}
+
+func TestConfirmStyle(t *testing.T) {
+	const prompt = "Send Escape to a:0.0, interrupting its work?"
+	for _, style := range []string{"inline", "modal"} {
+		setConfig(t, func(c *Config) { c.ConfirmStyle = style })
+		m := update(newModel(), tea.WindowSizeMsg{Width: 100, Height: 20}, scanned(testSession("a", StatusWorking)), press("x"))
+		help, view := m.helpLine(), m.View()
+		boxed := strings.Contains(view, "│  "+prompt) && strings.Contains(view, "y confirm · n cancel")
+		if inHelp := strings.Contains(help, prompt); inHelp != (style == "inline") || boxed != (style == "modal") {
+			t.Errorf("%s: prompt in the help line %v, in a box %v\n%s", style, inHelp, boxed, view)
+		}
+	}
+	c := defaultConfig()
+	c.ConfirmStyle = "popup"
+	if err := c.validate(); err == nil {
+		t.Error("validate accepted confirm_style popup")
+	}
+}
+
+func TestOverlayCenter(t *testing.T) {
+	tests := []struct {
+		lines, box []string
+		width      int
+		want       []string
+	}{
+		{[]string{"1", "2", "3", "4", "5"}, []string{"ab", "cd"}, 6, []string{"1", "  ab", "  cd", "4", "5"}},
+		{[]string{"1"}, []string{"ab", "cd", "ef"}, 2, []string{"ab", "cd", "ef"}}, // too few lines
+	}
+	for _, tt := range tests {
+		got := overlayCenter(tt.lines, tt.box, tt.width)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("overlayCenter(%q, %q, %d) = %q, want %q", tt.lines, tt.box, tt.width, got, tt.want)
+		}
+		if tt.lines[0] != "1" {
+			t.Error("overlayCenter changed its input")
+		}
+	}
+}
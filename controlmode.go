@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Control-mode watching (--control-mode)
+//
+// Instead of scanning on every tick, csm can attach a read-only tmux
+// control-mode client (tmux -C) and subscribe to a format listing every
+// pane on the server with its title. tmux evaluates it once a second and
+// reports %subscription-changed only when it differs: when a pane opens
+// or closes, or a title, and with it Claude's spinner, changes. Each
+// report triggers a scan; ticks still drive the footer and scan at most
+// every controlPoll as a safety net for changes no title shows. When the
+// attached session goes away the client reconnects; if control mode never
+// works (tmux older than 3.2, no session to attach to) csm goes back to
+// polling.
+
+// controlPoll is how often ticks still scan while control mode is up.
+const controlPoll = 10 * time.Second
+
+// controlSubscription names the subscription; controlFormat is what it
+// watches: every pane's ID and title, across all sessions.
+const (
+	controlSubscription = "csm-panes"
+	controlFormat       = "#{S:#{W:#{P:#{pane_id} #{pane_title};}}}"
+)
+
+// controlEvents are the notifications that mean the pane list may have
+// changed.
+var controlEvents = map[string]bool{
+	"%subscription-changed":  true,
+	"%sessions-changed":      true,
+	"%window-add":            true,
+	"%window-close":          true,
+	"%unlinked-window-add":   true,
+	"%unlinked-window-close": true,
+	"%window-pane-changed":   true,
+	"%layout-change":         true,
+}
+
+// errControlExit reports that tmux ended the control client, as it does
+// when the attached session is destroyed.
+var errControlExit = errors.New("control client exited")
+
+// controlMsg reports that control mode stopped and csm polls again.
+type controlMsg struct {
+	err error
+}
+
+// controlArgs are the tmux arguments of the control client. It neither
+// resizes windows nor receives pane output.
+func controlArgs() []string {
+	return []string{"-C", "attach-session", "-f", "ignore-size,no-output,read-only"}
+}
+
+// subscribeCommand is the control-mode command that subscribes to
+// controlFormat.
+func subscribeCommand() string {
+	return "refresh-client -B '" + controlSubscription + "::" + controlFormat + "'"
+}
+
+// watchControl reads a control-mode stream from r. It calls ready once
+// the reply to the attach command has ended, so commands may be sent, and
+// changed for each event, until the stream ends. It returns how many
+// events it saw and why it stopped: errControlExit, a command's %error
+// output, or the end of the stream.
+func watchControl(r io.Reader, ready, changed func()) (int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var (
+		events  int
+		block   []string // output of the command reply being read
+		inBlock bool
+		started bool
+	)
+	for sc.Scan() {
+		line := sc.Text()
+		word, _, _ := strings.Cut(line, " ")
+		switch {
+		case word == "%begin":
+			inBlock, block = true, nil
+		case word == "%end":
+			inBlock = false
+			if !started {
+				started = true
+				ready()
+			}
+		case word == "%error":
+			return events, fmt.Errorf("tmux: %s", strings.Join(block, "; "))
+		case inBlock:
+			block = append(block, line)
+		case word == "%exit":
+			return events, errControlExit
+		case controlEvents[word]:
+			events++
+			changed()
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return events, err
+	}
+	return events, io.ErrUnexpectedEOF
+}
+
+// runControl keeps a control client connected until stop is closed,
+// calling changed for each event. A client that tmux ends after it
+// worked is replaced; it returns the error that ended one that never
+// reported an event, or nil once stopped.
+func runControl(changed func(), stop <-chan struct{}) error {
+	for {
+		events, err := controlSession(changed, stop)
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if events == 0 {
+			return err
+		}
+		debugLog.Printf("control mode: %v; reconnecting", err)
+		changed()
+		time.Sleep(time.Second)
+	}
+}
+
+// controlSession runs one control client until it ends or stop is closed.
+func controlSession(changed func(), stop <-chan struct{}) (int, error) {
+	cmd := tmuxCommand(controlArgs()...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return 0, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// Closing stdin detaches the client.
+		select {
+		case <-stop:
+		case <-done:
+		}
+		stdin.Close()
+	}()
+	events, err := watchControl(stdout, func() {
+		fmt.Fprintln(stdin, subscribeCommand())
+	}, changed)
+	stdin.Close()
+	cmd.Wait()
+	return events, err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchControl(t *testing.T) {
+	const attach = "%begin 1700000000 1 0\n%end 1700000000 1 0\n"
+	tests := []struct {
+		name   string
+		stream string
+		ready  int
+		events int
+		err    error  // errors.Is, or
+		msg    string // the error text
+	}{
+		{"events then exit", attach +
+			"%subscription-changed csm-panes $1 @1 1 %1 : %1 ✳ task;\n" +
+			"%output %1 ignored\n" +
+			"%window-add @2\n" +
+			"%exit\n", 1, 2, errControlExit, ""},
+		{"reply output is not an event", attach +
+			"%begin 1700000000 2 1\n%window-add looks like one\n%end 1700000000 2 1\n" +
+			"%sessions-changed\n", 1, 1, io.ErrUnexpectedEOF, ""},
+		{"subscribe refused", attach +
+			"%begin 1700000000 2 1\nunknown flag -B\n%error 1700000000 2 1\n", 1, 0, nil, "tmux: unknown flag -B"},
+		{"no session to attach to", "%exit no sessions\n", 0, 0, errControlExit, ""},
+		{"tmux gone", "", 0, 0, io.ErrUnexpectedEOF, ""},
+	}
+	for _, tt := range tests {
+		ready, changed := 0, 0
+		events, err := watchControl(strings.NewReader(tt.stream), func() { ready++ }, func() { changed++ })
+		if ready != tt.ready || events != tt.events || changed != tt.events {
+			t.Errorf("%s: ready %d times, %d events, %d changes; want %d, %d", tt.name, ready, events, changed, tt.ready, tt.events)
+		}
+		switch {
+		case tt.err != nil && !errors.Is(err, tt.err):
+			t.Errorf("%s: err %v, want %v", tt.name, err, tt.err)
+		case tt.msg != "" && (err == nil || err.Error() != tt.msg):
+			t.Errorf("%s: err %v, want %q", tt.name, err, tt.msg)
+		}
+	}
+}
+
+func TestControlSession(t *testing.T) {
+	// A tmux_bin that acts as a control client: it answers the attach,
+	// saves the command csm sends and reports a change.
+	sent := filepath.Join(t.TempDir(), "sent")
+	bin := filepath.Join(t.TempDir(), "tmux")
+	script := "#!/bin/sh\n" +
+		"echo \"$*\" > " + sent + ".args\n" +
+		"printf '%%begin 1 1 0\\n%%end 1 1 0\\n'\n" +
+		"read line; echo \"$line\" > " + sent + "\n" +
+		"printf '%%subscription-changed csm-panes\\n%%exit\\n'\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	setConfig(t, func(c *Config) { c.TmuxBin = bin })
+
+	changed := 0
+	events, err := controlSession(func() { changed++ }, make(chan struct{}))
+	if events != 1 || changed != 1 || !errors.Is(err, errControlExit) {
+		t.Errorf("controlSession = %d, %v after %d changes", events, err, changed)
+	}
+	if data, _ := os.ReadFile(sent + ".args"); strings.TrimSpace(string(data)) != strings.Join(controlArgs(), " ") {
+		t.Errorf("started as tmux %s", data)
+	}
+	if data, _ := os.ReadFile(sent); strings.TrimSpace(string(data)) != subscribeCommand() {
+		t.Errorf("sent %q, want %q", data, subscribeCommand())
+	}
+}
+
+func TestControlModeTicks(t *testing.T) {
+	setConfig(t, nil)
+	tests := []struct {
+		control bool
+		after   time.Duration
+		scans   bool
+	}{
+		{false, 2 * time.Second, true}, // polling scans every tick
+		{true, 2 * time.Second, false},
+		{true, controlPoll, true}, // the safety-net poll
+	}
+	for _, tt := range tests {
+		m := newModel()
+		m.control = tt.control
+		m = update(m, scanned(testSession("a", StatusIdle)), tickMsg(testTime.Add(tt.after)))
+		if m.scanning != tt.scans {
+			t.Errorf("control %v, tick after %v: scanning %v, want %v", tt.control, tt.after, m.scanning, tt.scans)
+		}
+	}
+
+	m := newModel()
+	m.control = true
+	m = update(m, scanned(testSession("a", StatusIdle)), refreshMsg{})
+	if !m.scanning {
+		t.Error("a control-mode event did not scan")
+	}
+	if !strings.Contains(m.View(), "· control mode") {
+		t.Error("the footer does not say control mode is up")
+	}
+	m = update(m, scanned(testSession("a", StatusIdle)), controlMsg{err: io.ErrUnexpectedEOF})
+	if m.control || !m.scanning || !strings.Contains(m.notice, "polling every second") {
+		t.Errorf("after control mode stopped: control %v, scanning %v, notice %q", m.control, m.scanning, m.notice)
+	}
+	if strings.Contains(m.View(), "· control mode") {
+		t.Error("the footer still says control mode is up")
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// Debug log
+
+// debugLog receives diagnostics that would disturb the TUI, such as failing
+// hooks. It discards everything unless --debug-log names a file.
+var debugLog = log.New(io.Discard, "", log.LstdFlags)
+
+// openDebugLog directs debugLog to path, appending.
+func openDebugLog(path string) (io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	debugLog.SetOutput(f)
+	return f, nil
+}
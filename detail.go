@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Detail panel
+
+// waitReasonName returns the config name of r, as used in wait_patterns.
+func waitReasonName(r WaitReason) string {
+	for name, v := range waitReasonNames {
+		if v == r && name != "other" {
+			return name
+		}
+	}
+	return "other"
+}
+
+// renderDetail shows the raw fields of s, one per line, for debugging
+// detection. since is when csm first saw its current status; zero if unknown.
+func renderDetail(s ClaudeSession, since, now time.Time) string {
+	var b strings.Builder
+	row := func(key, value string) {
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(&b, "  %s %s\n", dimStyle.Render(fmt.Sprintf("%-8s", key)), value)
+	}
+	b.WriteString(dimStyle.Render("  Session detail"))
+	b.WriteString("\n")
+	row("pane", s.PaneID)
+	row("session", s.SessionName)
+	row("path", expandPath(s.Path))
+	status := statusLabel(s.Status)
+	if s.Status == StatusWaiting {
+		status += " (" + waitReasonName(s.WaitReason) + ")"
+	}
+	if s.Status == StatusIdle {
+		status += " (" + idleReasonName(idleReason(s, since, now, cfg.idleStale)) + ")"
+	}
+	if !since.IsZero() {
+		status += fmt.Sprintf(" since %s (%s)", since.Format("15:04:05"), formatAge(now.Sub(since)))
+	}
+	row("status", statusStyles[s.Status].Render(status))
+	row("title", s.Title)
+	row("prompt", s.Prompt)
+	row("branch", s.GitBranch)
+	created := ""
+	if !s.Created.IsZero() {
+		created = s.Created.Format("2006-01-02 15:04:05")
+	}
+	row("created", created)
+	row("turns", s.Turns)
+	row("progress", progressBar(s.Progress))
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRenderDetail(t *testing.T) {
+	setConfig(t, nil)
+	s := testSession("api", StatusWaiting)
+	s.WaitReason, s.Prompt, s.GitBranch = WaitBash, "run the tests", "main"
+	got := renderDetail(s, testTime.Add(-90*time.Second), testTime)
+	want := strings.Join([]string{
+		"  Session detail",
+		"  pane     api:0.0",
+		"  session  api",
+		"  path     " + expandPath("~/api"),
+		"  status   Waiting (bash) since 15:02:35 (1m)",
+		"  title    api task",
+		"  prompt   run the tests",
+		"  branch   main",
+		"  created  -",
+		"  turns    -",
+		"  progress -",
+	}, "\n") + "\n"
+	if got != want {
+		t.Errorf("renderDetail:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderDetailUnknownSince(t *testing.T) {
+	setConfig(t, nil)
+	got := renderDetail(testSession("api", StatusWorking), time.Time{}, testTime)
+	if !strings.Contains(got, "status   Working\n") {
+		t.Errorf("renderDetail without a since time:\n%s", got)
+	}
+}
+
+func TestDetailPanelInEveryLayout(t *testing.T) {
+	layouts := []struct {
+		name   string
+		size   tea.WindowSizeMsg
+		border bool
+	}{
+		{"normal", tea.WindowSizeMsg{Width: 100, Height: 40}, false},
+		{"minimal", tea.WindowSizeMsg{Width: 30, Height: 40}, false},
+		{"bordered", tea.WindowSizeMsg{Width: 100, Height: 40}, true},
+	}
+	for _, l := range layouts {
+		setConfig(t, func(c *Config) { c.Border = l.border })
+		m := update(newModel(), l.size, scanned(testSession("alpha", StatusIdle), testSession("beta", StatusIdle)), press("j"))
+		if strings.Contains(m.View(), "Session detail") {
+			t.Errorf("%s: detail shown before i", l.name)
+		}
+		m = update(m, press("i"))
+		if v := m.View(); !strings.Contains(v, "Session detail") || !strings.Contains(v, "beta:0.0") {
+			t.Errorf("%s: i did not show the selected session's detail:\n%s", l.name, v)
+		}
+		if m = update(m, press("i")); strings.Contains(m.View(), "Session detail") {
+			t.Errorf("%s: a second i left the detail open", l.name)
+		}
+	}
+}
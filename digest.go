@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change digest (D, b)
+//
+// csm keeps a baseline snapshot, taken at the first scan and again on b.
+// With the digest on, rows are marked by how they differ from it and the
+// sessions that disappeared since are listed below the list.
+
+// change classifies a session against the baseline.
+type change int
+
+const (
+	changeNone   change = iota
+	changeNew           // not in the baseline
+	changeStatus        // in the baseline with another status
+)
+
+// baseline is the snapshot the digest compares against.
+type baseline struct {
+	at       time.Time
+	sessions map[string]ClaudeSession // by Key; nil before the first scan
+}
+
+func newBaseline(sessions []ClaudeSession, at time.Time) baseline {
+	b := baseline{at: at, sessions: make(map[string]ClaudeSession, len(sessions))}
+	for _, s := range sessions {
+		b.sessions[s.Key] = s
+	}
+	return b
+}
+
+// classify reports how s changed since the baseline.
+func (b baseline) classify(s ClaudeSession) change {
+	old, ok := b.sessions[s.Key]
+	switch {
+	case !ok:
+		return changeNew
+	case old.Status != s.Status:
+		return changeStatus
+	default:
+		return changeNone
+	}
+}
+
+// gone returns the baseline sessions missing from current, by Key.
+func (b baseline) gone(current []ClaudeSession) []ClaudeSession {
+	present := make(map[string]bool, len(current))
+	for _, s := range current {
+		present[s.Key] = true
+	}
+	var out []ClaudeSession
+	for id, s := range b.sessions {
+		if !present[id] {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PaneID < out[j].PaneID })
+	return out
+}
+
+// changeMarker prefixes the title of a changed row: + for new sessions,
+// and ~ with the old status for status changes.
+func (b baseline) changeMarker(s ClaudeSession) string {
+	switch b.classify(s) {
+	case changeNew:
+		return "+ "
+	case changeStatus:
+		old := b.sessions[s.Key].Status
+		return "~ " + dimStyle.Render("(was "+strings.ToLower(statusLabel(old))+") ")
+	default:
+		return ""
+	}
+}
+
+// renderDigest summarizes changes among current since the baseline and
+// lists the sessions that disappeared.
+func renderDigest(b baseline, current []ClaudeSession) string {
+	var added, changed int
+	for _, s := range current {
+		switch b.classify(s) {
+		case changeNew:
+			added++
+		case changeStatus:
+			changed++
+		}
+	}
+	gone := b.gone(current)
+	var sb strings.Builder
+	sb.WriteString(dimStyle.Render(fmt.Sprintf(asciiOnly("  Since %s: %d new · %d changed · %d gone (b to reset)"),
+		b.at.Format("15:04:05"), added, changed, len(gone))))
+	sb.WriteString("\n")
+	for _, s := range gone {
+		fmt.Fprintf(&sb, "  %s %s  %s\n", dimStyle.Render(asciiOnly("−")), s.PaneID, dimStyle.Render(s.SessionName+"  "+s.Title))
+	}
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBaselineClassify(t *testing.T) {
+	b := newBaseline([]ClaudeSession{testSession("a", StatusWorking), testSession("b", StatusIdle)}, testTime)
+	moved := testSession("b", StatusIdle)
+	moved.Title = "another task" // only the status counts as a change
+	tests := []struct {
+		s      ClaudeSession
+		want   change
+		marker string
+	}{
+		{testSession("a", StatusWaiting), changeStatus, "~ (was working) "},
+		{moved, changeNone, ""},
+		{testSession("c", StatusIdle), changeNew, "+ "},
+	}
+	for _, tt := range tests {
+		if got := b.classify(tt.s); got != tt.want {
+			t.Errorf("classify(%s) = %d, want %d", tt.s.SessionName, got, tt.want)
+		}
+		if got := b.changeMarker(tt.s); got != tt.marker {
+			t.Errorf("changeMarker(%s) = %q, want %q", tt.s.SessionName, got, tt.marker)
+		}
+	}
+}
+
+func TestBaselineGone(t *testing.T) {
+	b := newBaseline([]ClaudeSession{testSession("c", StatusIdle), testSession("a", StatusIdle),
+		testSession("b", StatusIdle)}, testTime)
+	if got := names(b.gone([]ClaudeSession{testSession("b", StatusWaiting)})); got != "a c" {
+		t.Errorf("gone = %q, want a c in pane order", got)
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	b := newBaseline([]ClaudeSession{testSession("a", StatusWorking), testSession("gone", StatusIdle)}, testTime)
+	got := renderDigest(b, []ClaudeSession{testSession("a", StatusWaiting), testSession("new", StatusIdle)})
+	want := "  Since 15:04:05: 1 new · 1 changed · 1 gone (b to reset)\n" +
+		"  − gone:0.0  gone  gone task\n"
+	if got != want {
+		t.Errorf("renderDigest:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestBaselineReset(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusWorking)))
+	if m.baseline.classify(testSession("a", StatusWorking)) != changeNone {
+		t.Fatal("the first scan is not the baseline")
+	}
+	m = update(m, scannedAt(testTime.Add(time.Minute), testSession("a", StatusWaiting)), press("D"))
+	if !strings.Contains(m.View(), "1 changed") {
+		t.Errorf("the digest does not count the change:\n%s", m.View())
+	}
+	m = update(m, press("b"))
+	if got := m.baseline.classify(m.all[0]); got != changeNone || !m.baseline.at.Equal(testTime.Add(time.Minute)) {
+		t.Errorf("after b: %d at %v, want no change since the last scan", got, m.baseline.at)
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Event log (--record, --replay-events)
+//
+// --record <file> logs the messages that drive the model, one JSON object
+// per line: keys, terminal sizes, scan results, ticks, refreshes, pane
+// checks and the outcomes of actions. Every message carries its own time (a key, when it
+// was pressed; a scan, when it ran), so a replay sees the same clock. The
+// first line holds the config and saved state the picker started with;
+// the last, the model's final state.
+//
+// --replay-events <file> rebuilds that starting model and feeds the
+// messages back through Update in order, without tmux: commands Update
+// returns are dropped, so nothing is scanned, switched or killed. It
+// prints the last frame drawn and checks that the model ends in the
+// recorded final state, so a reported glitch can be reproduced and its
+// fix confirmed. Classification details for the e panel are not logged.
+
+// event is one line of the log.
+type event struct {
+	At       time.Time         `json:"at"`
+	Kind     string            `json:"kind"` // start, key, size, sessions, tick, refresh, action, pane, select or final
+	Config   *Config           `json:"config,omitempty"`
+	Flags    *cliFlags         `json:"flags,omitempty"`
+	State    *State            `json:"state,omitempty"`
+	Key      *tea.Key          `json:"key,omitempty"`
+	Width    int               `json:"width,omitempty"`
+	Height   int               `json:"height,omitempty"`
+	Sessions []recordedSession `json:"sessions,omitempty"`
+	Stats    *recordedStats    `json:"stats,omitempty"`
+	Current  string            `json:"current,omitempty"`
+	Time     time.Time         `json:"time,omitzero"` // scan or tick time
+	Notice   string            `json:"notice,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Pane     string            `json:"pane,omitempty"`
+	Session  *recordedSession  `json:"session,omitempty"`
+	Exists   bool              `json:"exists,omitempty"`
+	Zoom     bool              `json:"zoom,omitempty"`   // the pane check's follow-up
+	Answer   *string           `json:"answer,omitempty"` // likewise
+	Final    *finalState       `json:"final,omitempty"`
+}
+
+// cliFlags are the settings only command-line flags make, which the
+// logged Config does not carry.
+type cliFlags struct {
+	Sequential   bool `json:"sequential,omitempty"`
+	DeepDetect   bool `json:"deep_detect,omitempty"`
+	MotionDetect bool `json:"motion_detect,omitempty"`
+	AllUsers     bool `json:"all_users,omitempty"`
+	ASCII        bool `json:"ascii,omitempty"`
+}
+
+// flagsOf returns the command-line settings in c.
+func flagsOf(c Config) cliFlags {
+	return cliFlags{Sequential: c.sequential, DeepDetect: c.deepDetect, MotionDetect: c.motionDetect,
+		AllUsers: c.allUsers, ASCII: c.ascii}
+}
+
+// apply turns on in c each setting f has on.
+func (f cliFlags) apply(c *Config) {
+	c.sequential = c.sequential || f.Sequential
+	c.deepDetect = c.deepDetect || f.DeepDetect
+	c.motionDetect = c.motionDetect || f.MotionDetect
+	c.allUsers = c.allUsers || f.AllUsers
+	c.ascii = c.ascii || f.ASCII
+}
+
+// recordedSession is a ClaudeSession with its progress, whose fields are
+// unexported, spelled out.
+type recordedSession struct {
+	ClaudeSession
+	ProgressLabel string  `json:"progress_label,omitempty"`
+	ProgressFrac  float64 `json:"progress_frac,omitempty"`
+}
+
+// recordedStats mirrors scanStats.
+type recordedStats struct {
+	Listed   bool `json:"listed"`
+	Panes    int  `json:"panes"`
+	Titled   int  `json:"titled"`
+	Exited   int  `json:"exited"`
+	Sessions int  `json:"sessions"`
+	Throttle int  `json:"throttle"`
+	Unread   int  `json:"unread"`
+}
+
+// finalState is what a replay must reproduce.
+type finalState struct {
+	Cursor   int      `json:"cursor"`
+	Mode     int      `json:"mode"`
+	Filter   string   `json:"filter"`
+	Rows     []string `json:"rows"` // PaneIDs in display order
+	Selected string   `json:"selected"`
+	Notice   string   `json:"notice"`
+}
+
+func (m model) finalState() finalState {
+	rows := make([]string, len(m.sessions))
+	for i, s := range m.sessions {
+		rows[i] = s.PaneID
+	}
+	return finalState{Cursor: m.cursor, Mode: m.mode, Filter: m.filter, Rows: rows, Selected: m.selectedID, Notice: m.notice}
+}
+
+func (f finalState) equal(g finalState) bool {
+	return f.Cursor == g.Cursor && f.Mode == g.Mode && f.Filter == g.Filter &&
+		slices.Equal(f.Rows, g.Rows) && f.Selected == g.Selected && f.Notice == g.Notice
+}
+
+// eventFor returns the log line for msg, if it is a kind that is logged.
+func eventFor(msg tea.Msg) (event, bool) {
+	switch msg := msg.(type) {
+	case keyAtMsg:
+		k := tea.Key(msg.KeyMsg)
+		return event{At: msg.at, Kind: "key", Key: &k}, true
+	case tea.WindowSizeMsg:
+		return event{Kind: "size", Width: msg.Width, Height: msg.Height}, true
+	case sessionsMsg:
+		sessions := make([]recordedSession, len(msg.sessions))
+		for i, s := range msg.sessions {
+			sessions[i] = recordSession(s)
+		}
+		st := msg.stats
+		return event{Kind: "sessions", Sessions: sessions, Current: msg.current, Time: msg.at, Stats: &recordedStats{
+			Listed: st.listed, Panes: st.panes, Titled: st.titled, Exited: st.exited,
+			Sessions: st.sessions, Throttle: st.throttle, Unread: st.unread,
+		}}, true
+	case tickMsg:
+		return event{Kind: "tick", Time: time.Time(msg)}, true
+	case refreshMsg:
+		return event{Kind: "refresh"}, true
+	case actionMsg:
+		e := event{Kind: "action", Notice: msg.notice}
+		if msg.err != nil {
+			e.Error = msg.err.Error()
+		}
+		return e, true
+	case paneMsg:
+		s := recordSession(msg.session)
+		e := event{Kind: "pane", Session: &s, Exists: msg.exists, Zoom: msg.then.zoom}
+		if msg.then.answer {
+			e.Answer = &msg.then.text
+		}
+		return e, true
+	case selectMsg:
+		return event{Kind: "select", Pane: msg.pane}, true
+	}
+	return event{}, false
+}
+
+func recordSession(s ClaudeSession) recordedSession {
+	return recordedSession{ClaudeSession: s, ProgressLabel: s.Progress.label, ProgressFrac: s.Progress.frac}
+}
+
+func (s recordedSession) session() ClaudeSession {
+	s.Progress = progress{label: s.ProgressLabel, frac: s.ProgressFrac}
+	return s.ClaudeSession
+}
+
+// msgFor turns a logged event back into its message.
+func msgFor(e event) (tea.Msg, bool) {
+	switch e.Kind {
+	case "key":
+		if e.Key == nil {
+			return nil, false
+		}
+		return keyAtMsg{KeyMsg: tea.KeyMsg(*e.Key), at: e.At}, true
+	case "size":
+		return tea.WindowSizeMsg{Width: e.Width, Height: e.Height}, true
+	case "sessions":
+		msg := sessionsMsg{current: e.Current, at: e.Time}
+		for _, s := range e.Sessions {
+			msg.sessions = append(msg.sessions, s.session())
+		}
+		if st := e.Stats; st != nil {
+			msg.stats = scanStats{listed: st.Listed, panes: st.Panes, titled: st.Titled, exited: st.Exited,
+				sessions: st.Sessions, throttle: st.Throttle, unread: st.Unread}
+		}
+		return msg, true
+	case "tick":
+		return tickMsg(e.Time), true
+	case "refresh":
+		return refreshMsg{}, true
+	case "action":
+		msg := actionMsg{notice: e.Notice}
+		if e.Error != "" {
+			msg.err = errors.New(e.Error)
+		}
+		return msg, true
+	case "pane":
+		if e.Session == nil {
+			return nil, false
+		}
+		msg := paneMsg{session: e.Session.session(), then: followUp{zoom: e.Zoom}, exists: e.Exists}
+		if e.Answer != nil {
+			msg.then.answer, msg.then.text = true, *e.Answer
+		}
+		return msg, true
+	case "select":
+		return selectMsg{pane: e.Pane}, true
+	}
+	return nil, false
+}
+
+// recorder writes the event log.
+type recorder struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// openRecorder creates the log at path and writes its start line.
+func openRecorder(path string, start State) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &recorder{f: f, enc: json.NewEncoder(f)}
+	c, flags := cfg, flagsOf(cfg)
+	r.write(event{Kind: "start", Config: &c, Flags: &flags, State: &start})
+	return r, nil
+}
+
+// write logs e, stamped now unless it carries its own time.
+func (r *recorder) write(e event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	if err := r.enc.Encode(e); err != nil {
+		debugLog.Printf("record: %v", err)
+	}
+}
+
+// filter logs msg on its way to Update; see tea.WithFilter.
+func (r *recorder) filter(_ tea.Model, msg tea.Msg) tea.Msg {
+	if e, ok := eventFor(msg); ok {
+		r.write(e)
+	}
+	return msg
+}
+
+// close writes the final state of m and closes the log.
+func (r *recorder) close(m model) error {
+	f := m.finalState()
+	r.write(event{Kind: "final", Final: &f})
+	return r.f.Close()
+}
+
+// readEvents reads an event log.
+func readEvents(path string) ([]event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var events []event
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for n := 1; sc.Scan(); n++ {
+		var e event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		events = append(events, e)
+	}
+	return events, sc.Err()
+}
+
+// eventRunner stands in for tmux during a replay. Update runs no commands
+// itself, and those it returns are dropped, so every call fails.
+type eventRunner struct{}
+
+func (eventRunner) Output(string, ...string) ([]byte, error) {
+	return nil, errors.New("not available while replaying events")
+}
+
+// replayEvents replays the log at path, prints the last frame and reports
+// whether the model ended as recorded. It returns the exit code.
+func replayEvents(path string) int {
+	events, err := readEvents(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(events) == 0 || events[0].Kind != "start" || events[0].Config == nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: no start line\n", path)
+		return 1
+	}
+	c := *events[0].Config
+	if err := c.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: config: %v\n", path, err)
+		return 1
+	}
+	// Flags given to the recorded run and to this one both apply, so a
+	// log can be looked at with --ascii, say.
+	flagsOf(cfg).apply(&c)
+	if f := events[0].Flags; f != nil {
+		f.apply(&c)
+	}
+	cfg = c
+	applyTheme(cfg.Theme, cfg.Colors)
+	if cfg.ascii {
+		statusGlyphs = asciiGlyphs
+	}
+	sysRunner = eventRunner{}
+
+	m := newModel()
+	if st := events[0].State; st != nil {
+		m.applyState(*st)
+	}
+	var frame string
+	var want *finalState
+	for _, e := range events[1:] {
+		if e.Kind == "final" {
+			want = e.Final
+			continue
+		}
+		msg, ok := msgFor(e)
+		if !ok {
+			continue
+		}
+		next, _ := m.Update(msg)
+		m = next.(model)
+		if !m.quitting {
+			frame = m.View()
+		}
+	}
+
+	fmt.Println(frame)
+	switch got := m.finalState(); {
+	case want == nil:
+		fmt.Fprintln(os.Stderr, "csm: the log has no final state to compare with")
+	case !got.equal(*want):
+		fmt.Fprintf(os.Stderr, "csm: replay ended differently:\n  recorded %+v\n  replayed %+v\n", *want, got)
+		return 1
+	default:
+		fmt.Fprintln(os.Stderr, "csm: replay matches the recorded final state")
+	}
+	return 0
+}
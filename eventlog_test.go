@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEventRoundTrip(t *testing.T) {
+	s := testSession("a", StatusWaiting)
+	s.Progress = progress{label: "3/7", frac: 3.0 / 7}
+	tests := []tea.Msg{
+		keyAtMsg{KeyMsg: press("j"), at: testTime},
+		keyAtMsg{KeyMsg: press("enter"), at: testTime},
+		tea.WindowSizeMsg{Width: 100, Height: 30},
+		sessionsMsg{sessions: []ClaudeSession{s}, current: "a:0.0", at: testTime,
+			stats: scanStats{listed: true, panes: 4, titled: 2, exited: 1, sessions: 1, throttle: 2, unread: 1}},
+		tickMsg(testTime),
+		refreshMsg{},
+		actionMsg{notice: "Copied prompt of a:0.0"},
+		actionMsg{err: errors.New("kill-pane: no such pane")},
+		paneMsg{session: s, exists: true, then: followUp{answer: true, text: "1"}},
+		paneMsg{session: s, then: followUp{zoom: true}},
+		selectMsg{pane: "a:0.0"},
+	}
+	for _, msg := range tests {
+		e, ok := eventFor(msg)
+		if !ok {
+			t.Errorf("%T is not logged", msg)
+			continue
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back event
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+		got, ok := msgFor(back)
+		if !ok || !reflect.DeepEqual(got, msg) {
+			t.Errorf("%T round trip:\n got %#v\nwant %#v", msg, got, msg)
+		}
+	}
+	if _, ok := eventFor(flashMsg(testTime)); ok {
+		t.Error("flash frames are logged")
+	}
+}
+
+// recordRun drives a model through a recorder writing to path, as the
+// picker's filter does, and returns the last frame drawn.
+func recordRun(t *testing.T, path string, msgs []tea.Msg) string {
+	t.Helper()
+	m := newModel()
+	rec, err := openRecorder(path, m.state())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frame string
+	for _, msg := range msgs {
+		next, _ := m.Update(rec.filter(m, msg))
+		m = next.(model)
+		if !m.quitting {
+			frame = m.View()
+		}
+	}
+	if err := rec.close(m); err != nil {
+		t.Fatal(err)
+	}
+	return frame
+}
+
+func TestReplayEventsReproducesRun(t *testing.T) {
+	setConfig(t, func(c *Config) { c.ShowAge = true })
+	useRunner(t, &fakeRunner{})
+	saved := statusGlyphs
+	t.Cleanup(func() { statusGlyphs = saved; applyTheme("dark", nil) })
+
+	key := func(k string, d time.Duration) keyAtMsg { return keyAtMsg{KeyMsg: press(k), at: testTime.Add(d)} }
+	api, web, docs := testSession("api", StatusWaiting), testSession("web", StatusWorking), testSession("docs", StatusIdle)
+	msgs := []tea.Msg{
+		tea.WindowSizeMsg{Width: 90, Height: 20},
+		scannedAt(testTime, api, web, docs),
+		key("j", time.Second),
+		tickMsg(testTime.Add(2 * time.Second)),
+		key("/", 3*time.Second), key("w", 3*time.Second), key("enter", 4*time.Second),
+		actionMsg{notice: "Copied switch command"},
+		scannedAt(testTime.Add(5*time.Second), api, web, testSession("docs", StatusWaiting)),
+		key("esc", 6*time.Second),
+		key("G", 7*time.Second), key("d", 7*time.Second),
+		paneMsg{session: docs, exists: true, then: followUp{zoom: true}},
+	}
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	frame := recordRun(t, path, msgs)
+
+	stderr := filepath.Join(t.TempDir(), "stderr")
+	replay := func() (int, string, string) {
+		f, err := os.Create(stderr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		savedErr := os.Stderr
+		os.Stderr = f
+		defer func() { os.Stderr = savedErr }()
+		var code int
+		out := stdoutOf(t, func() { code = replayEvents(path) })
+		data, _ := os.ReadFile(stderr)
+		return code, out, string(data)
+	}
+	code, out, msg := replay()
+	if code != 0 || !strings.Contains(msg, "replay matches") {
+		t.Fatalf("replay exited %d: %s", code, msg)
+	}
+	if out != frame+"\n" {
+		t.Errorf("replayed last frame:\n%s\nrecorded:\n%s", out, frame)
+	}
+
+	// A final state that does not match fails the replay.
+	events, err := readEvents(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := events[len(events)-1]
+	if last.Kind != "final" || last.Final.Selected != "docs:0.0" {
+		t.Fatalf("last event %+v, want the final state choosing docs", last)
+	}
+	last.Final.Selected = "api:0.0"
+	data, _ := json.Marshal(last)
+	lines := strings.Split(strings.TrimSpace(readFile(t, path)), "\n")
+	lines[len(lines)-1] = string(data)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, msg := replay(); code != 1 || !strings.Contains(msg, "replay ended differently") {
+		t.Errorf("a tampered final state: exit %d, %s", code, msg)
+	}
+
+	// A log needs its start line.
+	if err := os.WriteFile(path, []byte(strings.Join(lines[1:], "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, msg := replay(); code != 1 || !strings.Contains(msg, "no start line") {
+		t.Errorf("no start line: exit %d, %s", code, msg)
+	}
+}
+
+func TestReplayKeepsCommandLineFlags(t *testing.T) {
+	tests := []struct {
+		recorded, now, want cliFlags
+	}{
+		{cliFlags{ASCII: true}, cliFlags{}, cliFlags{ASCII: true}},
+		{cliFlags{}, cliFlags{ASCII: true}, cliFlags{ASCII: true}}, // looking at a log with --ascii
+		{cliFlags{DeepDetect: true, AllUsers: true}, cliFlags{Sequential: true},
+			cliFlags{DeepDetect: true, AllUsers: true, Sequential: true}},
+	}
+	for _, tt := range tests {
+		c := defaultConfig()
+		tt.now.apply(&c)
+		tt.recorded.apply(&c)
+		if got := flagsOf(c); got != tt.want {
+			t.Errorf("recorded %+v, now %+v: %+v, want %+v", tt.recorded, tt.now, got, tt.want)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
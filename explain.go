@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Classification explanation (e)
+
+// explanation records why detection classified a pane as it did.
+type explanation struct {
+	source   string // where the Claude marker was found: pane title, window name or process tree
+	rawTitle string // the title before cleanTitle
+	command  string // pane_current_command
+	captured bool   // capture-pane ran
+	moved    bool   // --motion-detect saw the content change
+	verdict  verdict
+}
+
+// explainLines describes how s was classified, one step per line.
+func explainLines(s ClaudeSession) [][2]string {
+	w := s.Why
+	var lines [][2]string
+	add := func(step, text string) { lines = append(lines, [2]string{step, text}) }
+
+	switch r, _ := firstRune(w.rawTitle); {
+	case w.source == "process tree":
+		add("marker", fmt.Sprintf("none; Claude found in the process tree (--deep-detect), title %q", w.rawTitle))
+	case isBraillePrefix(w.rawTitle):
+		add("marker", fmt.Sprintf("spinner %q in %s %q", string(r), w.source, w.rawTitle))
+	default:
+		add("marker", fmt.Sprintf("%q in %s %q", string(r), w.source, w.rawTitle))
+	}
+	if s.Status == StatusExited {
+		add("command", fmt.Sprintf("%q is a shell, so Claude has exited; not captured", w.command))
+		add("status", statusLabel(s.Status))
+		return lines
+	}
+	add("command", fmt.Sprintf("%q", w.command))
+
+	if isBraillePrefix(w.rawTitle) {
+		if w.captured {
+			add("capture", "ran for deep_status; content does not affect the status")
+		} else {
+			add("capture", "skipped: the spinner already means working")
+		}
+		add("status", statusLabel(s.Status)+", from the spinner")
+		return lines
+	}
+
+	v := w.verdict
+	add("capture", fmt.Sprintf("ran (last %d lines)", cfg.CaptureLines))
+	if !v.prompt {
+		add("prompt", asciiOnly("no ❯ line with text after it, so not waiting"))
+	} else {
+		add("prompt", asciiOnly("found a ❯ line with text after it"))
+		if v.marker != "" {
+			add("waiting", fmt.Sprintf("marker %q after the prompt", v.marker))
+		} else {
+			add("waiting", "no waiting marker after the prompt")
+		}
+	}
+	if v.status == StatusWaiting {
+		if v.pattern.Pattern != "" {
+			add("reason", fmt.Sprintf("%s, from pattern %q", v.pattern.Reason, v.pattern.Pattern))
+		} else {
+			add("reason", "other; no wait pattern matched")
+		}
+	}
+	if s.Prompt != "" {
+		add("question", s.Prompt)
+	}
+	if v.status == StatusIdle {
+		if v.idle == IdleReplied {
+			add("reply", "a reply marker above the prompt, so replied until idle_stale_after")
+		} else {
+			add("reply", "no reply marker since the previous prompt, so stale")
+		}
+	}
+	if w.moved {
+		add("motion", "content changed between two captures (--motion-detect)")
+	}
+	add("status", statusLabel(s.Status))
+	return lines
+}
+
+// renderExplain renders explainLines as a panel.
+func renderExplain(s ClaudeSession) string {
+	var b strings.Builder
+	b.WriteString(dimStyle.Render("  Why " + s.PaneID + " is " + statusLabel(s.Status)))
+	b.WriteString("\n")
+	for _, l := range explainLines(s) {
+		fmt.Fprintf(&b, "  %s %s\n", dimStyle.Render(fmt.Sprintf("%-8s", l[0])), l[1])
+	}
+	return b.String()
+}
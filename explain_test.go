@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainLines(t *testing.T) {
+	bash := "❯ run the tests\n\n Bash command\n   go test ./...\n Do you want to proceed?\n ❯ 1. Yes\n   2. No\n Esc to cancel\n"
+	tests := []struct {
+		name  string
+		line  string // list-panes line
+		pane  string // capture
+		steps string // step names, in order
+		want  []string
+	}{
+		{"waiting", paneLine("a:0.0", "✳ task"), bash, "marker command capture prompt waiting reason question status",
+			[]string{`"✳" in pane title "✳ task"`, `marker "esc to cancel" after the prompt`, "bash, from pattern", "Waiting"}},
+		{"idle", paneLine("a:0.0", "✳ task"), "❯ fix it\n\n⏺ Fixed.\n\n❯ \n", "marker command capture prompt waiting reply status",
+			[]string{"no waiting marker", "a reply marker above the prompt", "Idle"}},
+		{"working", paneLine("a:0.0", "⠂ task"), "", "marker command capture status",
+			[]string{`spinner "⠂"`, "skipped: the spinner already means working", "Working, from the spinner"}},
+		{"exited", withCommand(paneLine("a:0.0", "✳ task"), "zsh"), "$ \n", "marker command status",
+			[]string{`"zsh" is a shell`, "Exited"}},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.ShowExited = true })
+		sessions, _ := detect(&fakeRunner{panes: tt.line, captures: map[string]string{"a:0.0": tt.pane}})
+		if len(sessions) != 1 {
+			t.Fatalf("%s: detected %d sessions", tt.name, len(sessions))
+		}
+		lines := explainLines(sessions[0])
+		var steps, text []string
+		for _, l := range lines {
+			steps = append(steps, l[0])
+			text = append(text, l[1])
+		}
+		if got := strings.Join(steps, " "); got != tt.steps {
+			t.Errorf("%s: steps %q, want %q", tt.name, got, tt.steps)
+		}
+		all := strings.Join(text, "\n")
+		for _, w := range tt.want {
+			if !strings.Contains(all, w) {
+				t.Errorf("%s: no %q in\n%s", tt.name, w, all)
+			}
+		}
+	}
+}
+
+func TestRenderExplain(t *testing.T) {
+	setConfig(t, nil)
+	sessions, _ := detect(&fakeRunner{panes: paneLine("a:0.0", "✳ task"), captures: map[string]string{"a:0.0": "❯ \n"}})
+	got := renderExplain(sessions[0])
+	if first := strings.SplitN(got, "\n", 2)[0]; first != "  Why a:0.0 is Idle" {
+		t.Errorf("heading %q", first)
+	}
+	if !strings.Contains(got, "  marker   \"✳\" in pane title") || !strings.HasSuffix(got, "  status   Idle\n") {
+		t.Errorf("renderExplain:\n%s", got)
+	}
+}
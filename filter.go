@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Visibility filters
+
+// attention ranks statuses by how much they need the user: idle < working < waiting.
+func attention(status int) int {
+	switch status {
+	case StatusWaiting:
+		return 2
+	case StatusWorking:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// minStatusNames maps --min-status values to statuses.
+var minStatusNames = map[string]int{
+	"idle":    StatusIdle,
+	"working": StatusWorking,
+	"waiting": StatusWaiting,
+}
+
+func parseMinStatus(name string) (int, error) {
+	s, ok := minStatusNames[name]
+	if !ok {
+		return 0, fmt.Errorf("min status: want idle, working or waiting, got %q", name)
+	}
+	return s, nil
+}
+
+func minStatusName(status int) string {
+	for name, s := range minStatusNames {
+		if s == status {
+			return name
+		}
+	}
+	return "idle"
+}
+
+// nextMinStatus cycles the threshold idle → working → waiting → idle.
+func nextMinStatus(status int) int {
+	switch status {
+	case StatusIdle:
+		return StatusWorking
+	case StatusWorking:
+		return StatusWaiting
+	default:
+		return StatusIdle
+	}
+}
+
+// filterMinStatus keeps sessions needing at least as much attention as min.
+func filterMinStatus(sessions []ClaudeSession, min int) []ClaudeSession {
+	var out []ClaudeSession
+	for _, s := range sessions {
+		if attention(s.Status) >= attention(min) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// statusToggleKeys maps the keys of status filter mode (f) to the
+// statuses they show or hide.
+var statusToggleKeys = map[string]int{"w": StatusWorking, "a": StatusWaiting, "i": StatusIdle}
+
+// filterHidden drops sessions whose status is hidden.
+func filterHidden(sessions []ClaudeSession, hidden map[int]bool) []ClaudeSession {
+	var out []ClaudeSession
+	for _, s := range sessions {
+		if !hidden[s.Status] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// updateStatusFilter handles keys in status filter mode: w, a and i toggle
+// working, waiting and idle sessions; f, Enter or Esc leave the mode.
+func (m model) updateStatusFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "w", "a", "i":
+		st := statusToggleKeys[key]
+		if m.hidden[st] {
+			delete(m.hidden, st)
+		} else {
+			m.hidden[st] = true
+		}
+		m.refilter()
+	case "f", "enter", "esc":
+		m.mode = modeNormal
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// statusCounts tallies sessions per status.
+func statusCounts(sessions []ClaudeSession) map[int]int {
+	counts := map[int]int{}
+	for _, s := range sessions {
+		counts[s.Status]++
+	}
+	return counts
+}
+
+// searchFields are the session fields the / filter can search.
+var searchFields = map[string]func(ClaudeSession) string{
+	"name":   func(s ClaudeSession) string { return s.SessionName },
+	"title":  func(s ClaudeSession) string { return s.Title },
+	"path":   func(s ClaudeSession) string { return s.Path },
+	"branch": func(s ClaudeSession) string { return s.GitBranch },
+}
+
+var defaultSearchFields = []string{"name", "title"}
+
+// compileFilter builds a scorer for the / filter query over the named
+// fields. Plain queries match a field when their characters appear in it in
+// order, case-insensitively, and score by fuzzyMatch; a leading ~ makes the
+// rest of the query a Go regular expression, and every match scores the same.
+func compileFilter(query string, fields []string) (func(ClaudeSession) (int, bool), error) {
+	var get []func(ClaudeSession) string
+	for _, f := range fields {
+		get = append(get, searchFields[f])
+	}
+	if rest, ok := strings.CutPrefix(query, "~"); ok {
+		re, err := regexp.Compile(rest)
+		if err != nil {
+			return nil, err
+		}
+		return func(s ClaudeSession) (int, bool) {
+			for _, g := range get {
+				if re.MatchString(g(s)) {
+					return 0, true
+				}
+			}
+			return 0, false
+		}, nil
+	}
+	return func(s ClaudeSession) (int, bool) {
+		best, found := 0, false
+		for _, g := range get {
+			if score, _, ok := fuzzyMatch(query, g(s)); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		return best, found
+	}, nil
+}
+
+// rankQuery keeps sessions accepted by score, best score first. The sort is
+// stable, so equal scores keep their order from the active sort. Scores are
+// kept by Key, as panes on other servers can share a PaneID.
+func rankQuery(sessions []ClaudeSession, score func(ClaudeSession) (int, bool)) []ClaudeSession {
+	var out []ClaudeSession
+	scores := map[string]int{}
+	for _, s := range sessions {
+		if n, ok := score(s); ok {
+			out = append(out, s)
+			scores[s.Key] = n
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return scores[out[i].Key] > scores[out[j].Key]
+	})
+	return out
+}
+
+// Fuzzy match scoring: every matched character scores 1, plus a bonus when
+// it follows the previous match directly or starts a word, and minus one
+// per character skipped between the first and last match. A contiguous
+// match at the start of text therefore beats one scattered through it.
+const (
+	fuzzyConsecutive = 5
+	fuzzyWordStart   = 3
+	fuzzyTextStart   = 2
+)
+
+// fuzzyMatch reports whether the runes of query appear in text in order,
+// ignoring case, with the best score over all starting points and the rune
+// indexes of text that matched.
+func fuzzyMatch(query, text string) (score int, positions []int, ok bool) {
+	q, t := lowerRunes(query), lowerRunes(text)
+	if len(q) == 0 {
+		return 0, nil, true
+	}
+	for start := range t {
+		if t[start] != q[0] {
+			continue
+		}
+		n, pos, matched := fuzzyFrom(q, t, start)
+		if matched && (!ok || n > score) {
+			score, positions, ok = n, pos, true
+		}
+	}
+	return score, positions, ok
+}
+
+// lowerRunes lowercases s rune by rune. Unlike strings.ToLower, which
+// turns İ into two runes, it keeps one rune per rune of s, so match
+// positions index the text as displayed.
+func lowerRunes(s string) []rune {
+	r := []rune(s)
+	for i, c := range r {
+		r[i] = unicode.ToLower(c)
+	}
+	return r
+}
+
+// fuzzyFrom greedily matches q in t from index start, which matches q[0].
+func fuzzyFrom(q, t []rune, start int) (int, []int, bool) {
+	positions := make([]int, 0, len(q))
+	score := 0
+	j := 0
+	for i := start; i < len(t) && j < len(q); i++ {
+		if t[i] != q[j] {
+			continue
+		}
+		score++
+		switch {
+		case i == 0:
+			score += fuzzyWordStart + fuzzyTextStart
+		case strings.ContainsRune(" -_/.:", t[i-1]):
+			score += fuzzyWordStart
+		}
+		if len(positions) > 0 && positions[len(positions)-1] == i-1 {
+			score += fuzzyConsecutive
+		}
+		positions = append(positions, i)
+		j++
+	}
+	if j < len(q) {
+		return 0, nil, false
+	}
+	gaps := positions[len(positions)-1] - positions[0] + 1 - len(positions)
+	return score - gaps, positions, true
+}
+
+// nextWaiting returns the index of the next Waiting session after cursor in
+// direction dir (1 or -1), wrapping within the waiting subset. The session
+// with PaneID skip is passed over. If no other session waits, cursor is
+// returned unchanged.
+func nextWaiting(sessions []ClaudeSession, cursor, dir int, skip string) int {
+	n := len(sessions)
+	for step := 1; step <= n; step++ {
+		i := ((cursor+dir*step)%n + n) % n
+		if s := sessions[i]; s.Status == StatusWaiting && s.PaneID != skip {
+			return i
+		}
+	}
+	return cursor
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFilterMinStatus(t *testing.T) {
+	sessions := []ClaudeSession{
+		testSession("a", StatusIdle),
+		testSession("b", StatusWorking),
+		testSession("c", StatusWaiting),
+		testSession("d", StatusExited),
+	}
+	tests := []struct {
+		min  int
+		want string
+	}{
+		{StatusIdle, "a b c d"},
+		{StatusWorking, "b c"},
+		{StatusWaiting, "c"},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, s := range filterMinStatus(sessions, tt.min) {
+			names = append(names, s.SessionName)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("filterMinStatus(%s) = %q, want %q", minStatusName(tt.min), got, tt.want)
+		}
+	}
+}
+
+func TestMinStatusNames(t *testing.T) {
+	status := StatusIdle
+	for _, want := range []string{"working", "waiting", "idle"} {
+		status = nextMinStatus(status)
+		if got := minStatusName(status); got != want {
+			t.Errorf("nextMinStatus cycled to %q, want %q", got, want)
+		}
+		if parsed, err := parseMinStatus(want); err != nil || parsed != status {
+			t.Errorf("parseMinStatus(%q) = %d, %v", want, parsed, err)
+		}
+	}
+	if _, err := parseMinStatus("exited"); err == nil {
+		t.Error("parseMinStatus accepted exited")
+	}
+}
+
+func TestMinStatusToggleKeepsTotals(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusWaiting)), press("v"))
+	if len(m.sessions) != 1 || m.sessions[0].SessionName != "b" {
+		t.Fatalf("after v, listed %v", m.sessions)
+	}
+	if h := m.header(); !strings.Contains(h, "1 idle") || !strings.Contains(h, "(1 hidden)") {
+		t.Errorf("header = %q, want the hidden idle session counted", h)
+	}
+}
+
+func TestCompileFilter(t *testing.T) {
+	api := testSession("api", StatusIdle)
+	api.Title = "fix the login bug"
+	web := testSession("web", StatusIdle)
+	web.Title = "write docs"
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "api web"},
+		{"api", "api"},
+		{"LOGIN", "api"}, // plain queries ignore case
+		{"fxlgn", "api"}, // and match characters in order
+		{"a.i", ""},      // plain: . is a literal
+		{"~^w", "web"},   // ~ makes a regexp
+		{"~(api|web)", "api web"},
+		{"~bug$", "api"},
+		{"~LOGIN", ""}, // regexps are case-sensitive unless (?i)
+		{"~(?i)LOGIN", "api"},
+	}
+	for _, tt := range tests {
+		score, err := compileFilter(tt.query, defaultSearchFields)
+		if err != nil {
+			t.Errorf("compileFilter(%q): %v", tt.query, err)
+			continue
+		}
+		var names []string
+		for _, s := range rankQuery([]ClaudeSession{api, web}, score) {
+			names = append(names, s.SessionName)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("filter %q kept %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidRegexpFilter(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusIdle)), press("/"))
+	for _, r := range "~(^a" {
+		m = update(m, press(string(r)))
+	}
+	if m.filterErr == "" {
+		t.Error("no error shown for ~(^a")
+	}
+	if len(m.sessions) != 2 {
+		t.Errorf("an invalid regexp filtered the list to %d sessions, want all 2", len(m.sessions))
+	}
+	m = update(m, press(")"))
+	if m.filterErr != "" || len(m.sessions) != 1 {
+		t.Errorf("~(^a): error %q, %d sessions; want none and 1", m.filterErr, len(m.sessions))
+	}
+}
+
+func TestNextWaiting(t *testing.T) {
+	// idle, waiting, idle, waiting, working
+	sessions := []ClaudeSession{
+		testSession("a", StatusIdle),
+		testSession("b", StatusWaiting),
+		testSession("c", StatusIdle),
+		testSession("d", StatusWaiting),
+		testSession("e", StatusWorking),
+	}
+	tests := []struct {
+		cursor, dir int
+		skip        string
+		want        int
+	}{
+		{0, 1, "", 1},
+		{1, 1, "", 3},
+		{3, 1, "", 1}, // wraps within the waiting sessions
+		{0, -1, "", 3},
+		{1, -1, "", 3},
+		{4, 1, "", 1},
+		{0, 1, "b:0.0", 3}, // the current pane is passed over
+		{3, 1, "b:0.0", 3}, // and with no other waiting, the cursor stays
+	}
+	for _, tt := range tests {
+		if got := nextWaiting(sessions, tt.cursor, tt.dir, tt.skip); got != tt.want {
+			t.Errorf("nextWaiting(%d, %d, %q) = %d, want %d", tt.cursor, tt.dir, tt.skip, got, tt.want)
+		}
+	}
+	if got := nextWaiting(sessions[:1], 0, 1, ""); got != 0 {
+		t.Errorf("nextWaiting with none waiting = %d, want 0", got)
+	}
+}
+
+func TestTabCyclesWaiting(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusWaiting),
+		testSession("c", StatusWorking), testSession("d", StatusWaiting)))
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		m = update(m, press("tab"))
+		s := m.sessions[m.cursor]
+		if s.Status != StatusWaiting {
+			t.Fatalf("tab landed on %s, which is not waiting", s.SessionName)
+		}
+		seen[s.SessionName]++
+	}
+	if seen["b"] != 2 || seen["d"] != 2 {
+		t.Errorf("four tabs visited %v, want b and d twice each", seen)
+	}
+
+	// The cursor keeps its session when the waiting set changes.
+	on := m.sessions[m.cursor].SessionName
+	m = update(m, scanned(testSession("a", StatusIdle), testSession("b", StatusWaiting),
+		testSession("c", StatusWaiting), testSession("d", StatusWaiting)))
+	if got := m.sessions[m.cursor].SessionName; got != on {
+		t.Errorf("after a rescan the cursor moved from %s to %s", on, got)
+	}
+	m = update(m, press("shift+tab"), press("tab"))
+	if got := m.sessions[m.cursor].SessionName; got != on {
+		t.Errorf("shift+tab then tab went from %s to %s", on, got)
+	}
+}
+
+func TestSearchFields(t *testing.T) {
+	login := testSession("api", StatusIdle)
+	login.GitBranch, login.Path = "feature/login", "~/work/api"
+	docs := testSession("web", StatusIdle)
+	docs.GitBranch, docs.Path = "main", "~/work/web"
+	tests := []struct {
+		query  string
+		fields []string
+		want   string
+	}{
+		{"feature/login", defaultSearchFields, ""}, // branches are not searched by default
+		{"feature/login", []string{"name", "title", "branch"}, "api"},
+		{"~^main$", []string{"branch"}, "web"},
+		{"work/web", []string{"path"}, "web"},
+		{"api", []string{"branch"}, ""}, // only the listed fields count
+	}
+	for _, tt := range tests {
+		score, err := compileFilter(tt.query, tt.fields)
+		if err != nil {
+			t.Fatalf("compileFilter(%q): %v", tt.query, err)
+		}
+		if got := names(rankQuery([]ClaudeSession{login, docs}, score)); got != tt.want {
+			t.Errorf("filter %q over %q kept %q, want %q", tt.query, tt.fields, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSearchFields(t *testing.T) {
+	for _, fields := range [][]string{nil, {"name", "owner"}} {
+		c := defaultConfig()
+		c.SearchFields = fields
+		if err := c.validate(); err == nil {
+			t.Errorf("validate accepted search_fields %q", fields)
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, text string
+		positions   []int
+		ok          bool
+	}{
+		{"api", "api-server", []int{0, 1, 2}, true},
+		{"api", "web-api", []int{4, 5, 6}, true},
+		{"API", "web-api", []int{4, 5, 6}, true},
+		{"wa", "web-api", []int{0, 4}, true},
+		{"ab", "İab", []int{1, 2}, true}, // İ lowercases to two runes with strings.ToLower
+		{"i", "İab", []int{0}, true},
+		{"aa", "ab", nil, false},
+		{"", "anything", nil, true},
+	}
+	for _, tt := range tests {
+		_, pos, ok := fuzzyMatch(tt.query, tt.text)
+		if ok != tt.ok || !slices.Equal(pos, tt.positions) {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, %v; want %v, %v", tt.query, tt.text, pos, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyRanking(t *testing.T) {
+	tests := []struct {
+		query    string
+		sessions string // in the active sort's order
+		want     string
+	}{
+		// a match at the start beats one at a word start, which beats a
+		// match inside a word, which beats a scattered one
+		{"api", "a-p-i rapid web-api api-server", "api-server web-api rapid a-p-i"},
+		{"log", "catalog login", "login catalog"},
+		// ties keep the active sort
+		{"web", "web-b web-a", "web-b web-a"},
+		{"zzz", "web api", ""},
+	}
+	for _, tt := range tests {
+		var sessions []ClaudeSession
+		for _, name := range strings.Fields(tt.sessions) {
+			s := testSession(name, StatusIdle)
+			s.Title = ""
+			sessions = append(sessions, s)
+		}
+		score, err := compileFilter(tt.query, []string{"name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(rankQuery(sessions, score)); got != tt.want {
+			t.Errorf("%q over %q ranked %q, want %q", tt.query, tt.sessions, got, tt.want)
+		}
+	}
+}
+
+func TestFilterSelectsBestMatch(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("web-api", StatusIdle), testSession("rapid", StatusIdle),
+		testSession("api-server", StatusIdle)), press("j"), press("/"), press("a"), press("p"), press("i"))
+	if got := m.sessions[m.cursor].SessionName; got != "api-server" {
+		t.Errorf("filtering for api selected %s, want the best match api-server", got)
+	}
+}
+
+func TestRankQueryKeysScores(t *testing.T) {
+	// Two servers' panes with the same PaneID keep their own scores.
+	a, b := testSession("same", StatusIdle), testSession("same", StatusIdle)
+	a.Key, a.Title = "one/%1", "unrelated"
+	b.Key, b.Title = "two/%1", "same"
+	score := func(s ClaudeSession) (int, bool) {
+		return len(s.Title), true
+	}
+	if got := rankQuery([]ClaudeSession{b, a}, score); got[0].Key != "one/%1" {
+		t.Errorf("ranked %s first, want one/%%1 with the higher score", got[0].Key)
+	}
+}
+
+func TestStatusToggles(t *testing.T) {
+	all := []ClaudeSession{
+		testSession("a", StatusWorking), testSession("b", StatusWaiting), testSession("c", StatusIdle),
+	}
+	tests := []struct {
+		keys  []string // pressed after f
+		shown string
+		saved []string
+	}{
+		{nil, "a b c", nil},
+		{[]string{"i"}, "a b", []string{"idle"}},
+		{[]string{"w", "i"}, "b", []string{"working", "idle"}},
+		{[]string{"w", "w"}, "a b c", nil}, // a second press shows it again
+		{[]string{"w", "a", "i"}, "", []string{"working", "waiting", "idle"}},
+	}
+	for _, tt := range tests {
+		setConfig(t, nil)
+		m := update(newModel(), scanned(all...), press("f"))
+		for _, k := range tt.keys {
+			m = update(m, press(k))
+		}
+		if m.mode != modeStatus {
+			t.Errorf("%q: left status mode", tt.keys)
+		}
+		if got := names(m.sessions); got != tt.shown {
+			t.Errorf("%q: shown %q, want %q", tt.keys, got, tt.shown)
+		}
+		if got := m.state().Hidden; !slices.Equal(got, tt.saved) {
+			t.Errorf("%q: saved %q, want %q", tt.keys, got, tt.saved)
+		}
+		if got := names(filterHidden(all, m.hidden)); got != tt.shown {
+			t.Errorf("%q: filterHidden = %q, want %q", tt.keys, got, tt.shown)
+		}
+	}
+}
+
+func TestHiddenStatusesCountedAndRestored(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusWaiting), testSession("b", StatusIdle)), press("f"), press("i"), press("enter"))
+	if m.mode != modeNormal {
+		t.Fatalf("enter left mode %d", m.mode)
+	}
+	if h := m.header(); !strings.Contains(h, "1 idle (off)") || !strings.Contains(h, "1 waiting") {
+		t.Errorf("header = %q, want the hidden idle session counted", h)
+	}
+
+	restarted := newModel()
+	restarted.applyState(m.state())
+	restarted = update(restarted, scanned(testSession("b", StatusIdle)))
+	if len(restarted.sessions) != 0 {
+		t.Errorf("after a restart, listed %q with idle hidden", names(restarted.sessions))
+	}
+	if view := restarted.View(); !strings.Contains(view, "No sessions with the statuses shown") {
+		t.Errorf("no explanation for the empty list in\n%s", view)
+	}
+
+	// Unknown names in a hand-edited state file are ignored.
+	restarted = newModel()
+	restarted.applyState(State{Hidden: []string{"bogus", "working"}})
+	if len(restarted.hidden) != 1 || !restarted.hidden[StatusWorking] {
+		t.Errorf("hidden %v from bogus and working", restarted.hidden)
+	}
+}
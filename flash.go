@@ -0,0 +1,59 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Row flash (flash_duration)
+//
+// A session that starts waiting has its row flashed for flash_duration,
+// alternating the flash background every flashPeriod, then settles.
+
+const flashPeriod = 250 * time.Millisecond
+
+// flashMsg advances the flash animation.
+type flashMsg time.Time
+
+func flashTick() tea.Cmd {
+	return tea.Tick(flashPeriod, func(t time.Time) tea.Msg {
+		return flashMsg(t)
+	})
+}
+
+// flashOn reports whether a row whose flash started at start shows the
+// flash background at now: during the first d, on for one flashPeriod
+// and off for the next.
+func flashOn(start, now time.Time, d time.Duration) bool {
+	elapsed := now.Sub(start)
+	if start.IsZero() || elapsed < 0 || elapsed >= d {
+		return false
+	}
+	return (elapsed/flashPeriod)%2 == 0
+}
+
+// startFlashes records when each transition into Waiting should start
+// flashing, skipping muted paths.
+func startFlashes(flashes map[string]time.Time, changed []transition, sessions []ClaudeSession, muted map[string]bool) {
+	byID := make(map[string]ClaudeSession, len(sessions))
+	for _, s := range sessions {
+		byID[s.Key] = s
+	}
+	for _, t := range changed {
+		if s, ok := byID[t.key]; ok && t.to == StatusWaiting && !muted[s.Path] {
+			flashes[t.key] = t.at
+		}
+	}
+}
+
+// expireFlashes forgets flashes that ended by now and reports whether any
+// remain.
+func expireFlashes(flashes map[string]time.Time, now time.Time, d time.Duration) bool {
+	for id, start := range flashes {
+		if now.Sub(start) >= d {
+			delete(flashes, id)
+		}
+	}
+	return len(flashes) > 0
+}
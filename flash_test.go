@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlashOn(t *testing.T) {
+	start := testTime
+	tests := []struct {
+		at   time.Duration
+		want bool
+	}{
+		{0, true},
+		{flashPeriod - time.Millisecond, true},
+		{flashPeriod, false}, // alternates every period
+		{2 * flashPeriod, true},
+		{time.Second, false}, // over after the duration
+		{-time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		if got := flashOn(start, start.Add(tt.at), time.Second); got != tt.want {
+			t.Errorf("flashOn at %v = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+	if flashOn(time.Time{}, start, time.Second) {
+		t.Error("a row that never flashed is flashing")
+	}
+}
+
+func TestStartFlashes(t *testing.T) {
+	a, b, c := testSession("a", StatusWaiting), testSession("b", StatusWaiting), testSession("c", StatusIdle)
+	changed := []transition{
+		{at: testTime, key: a.Key, from: StatusWorking, to: StatusWaiting},
+		{at: testTime, key: b.Key, from: StatusIdle, to: StatusWaiting},
+		{at: testTime, key: c.Key, from: StatusWaiting, to: StatusIdle},
+	}
+	flashes := map[string]time.Time{}
+	startFlashes(flashes, changed, []ClaudeSession{a, b, c}, map[string]bool{b.Path: true})
+	if len(flashes) != 1 || !flashes[a.Key].Equal(testTime) {
+		t.Errorf("flashes %v, want only %s: b is muted and c stopped waiting", flashes, a.Key)
+	}
+}
+
+func TestExpireFlashes(t *testing.T) {
+	flashes := map[string]time.Time{"old": testTime, "new": testTime.Add(900 * time.Millisecond)}
+	if !expireFlashes(flashes, testTime.Add(time.Second), time.Second) {
+		t.Error("no flashes left, want new")
+	}
+	if _, ok := flashes["old"]; ok {
+		t.Error("an ended flash was kept")
+	}
+	if expireFlashes(flashes, testTime.Add(2*time.Second), time.Second) {
+		t.Error("flashes left after every one ended")
+	}
+}
+
+func TestFlashFollowsScans(t *testing.T) {
+	setConfig(t, func(c *Config) { c.FlashDuration = "1s" })
+	m := update(newModel(), scanned(testSession("a", StatusWorking)),
+		scannedAt(testTime.Add(time.Second), testSession("a", StatusWaiting)))
+	if !m.flashing || m.flashes["a/%a"].IsZero() {
+		t.Fatalf("flashing %v, flashes %v; want a flashing", m.flashing, m.flashes)
+	}
+	m = update(m, flashMsg(testTime.Add(1500*time.Millisecond)))
+	if !m.flashing {
+		t.Error("the flash stopped early")
+	}
+	m = update(m, flashMsg(testTime.Add(2*time.Second)))
+	if m.flashing || len(m.flashes) != 0 {
+		t.Errorf("after the duration: flashing %v, flashes %v", m.flashing, m.flashes)
+	}
+
+	setConfig(t, func(c *Config) { c.FlashDuration = "0" })
+	m = update(newModel(), scanned(testSession("a", StatusWorking)),
+		scannedAt(testTime.Add(time.Second), testSession("a", StatusWaiting)))
+	if m.flashing {
+		t.Error("flashing with flash_duration 0")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Git branch
+
+// gitBranch returns the branch checked out in the repository containing dir,
+// a short commit hash when HEAD is detached, or "" outside a repository.
+// It reads .git/HEAD directly rather than running git for every pane.
+func gitBranch(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if head, ok := readGitHead(filepath.Join(d, ".git")); ok {
+			return head
+		}
+		if parent := filepath.Dir(d); parent == d {
+			return ""
+		}
+	}
+}
+
+// readGitHead reads HEAD from a .git directory, or from the directory a
+// .git file points to (worktrees and submodules).
+func readGitHead(gitPath string) (string, bool) {
+	info, err := os.Stat(gitPath)
+	if err != nil {
+		return "", false
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(gitPath)
+		if err != nil {
+			return "", false
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return "", false
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(gitPath), target)
+		}
+		gitPath = target
+	}
+	data, err := os.ReadFile(filepath.Join(gitPath, "HEAD"))
+	if err != nil {
+		return "", false
+	}
+	head := strings.TrimSpace(string(data))
+	if ref, ok := strings.CutPrefix(head, "ref: "); ok {
+		return strings.TrimPrefix(ref, "refs/heads/"), true
+	}
+	if len(head) > 7 {
+		head = head[:7]
+	}
+	return head, true
+}
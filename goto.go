@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Type-ahead goto (G)
+//
+// In goto mode, typing part of a session name moves the cursor to the
+// first listed session whose name starts with it, without hiding any rows.
+// A pause longer than gotoReset starts a new name.
+
+// gotoReset is the pause after which the next key starts a new prefix.
+const gotoReset = time.Second
+
+// typeAhead returns the prefix after typing key at now, when the previous
+// key came at last: key appended, or key alone after a pause.
+func typeAhead(prefix, key string, last, now time.Time) string {
+	if now.Sub(last) > gotoReset {
+		return key
+	}
+	return prefix + key
+}
+
+// gotoMatch returns the index of the first session whose name starts with
+// prefix, ignoring case, or -1.
+func gotoMatch(sessions []ClaudeSession, prefix string) int {
+	if prefix == "" {
+		return -1
+	}
+	for i, s := range sessions {
+		if strings.HasPrefix(strings.ToLower(s.SessionName), strings.ToLower(prefix)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateGoto handles keys in goto mode. Enter switches to the session
+// under the cursor; Esc leaves the mode.
+func (m model) updateGoto(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.mode, m.input = modeNormal, ""
+		return m, nil
+	case tea.KeyEnter:
+		m.mode, m.input = modeNormal, ""
+		if m.cursor < len(m.sessions) {
+			return m.choose(m.sessions[m.cursor], followUp{})
+		}
+		return m, nil
+	case tea.KeyBackspace:
+		if _, size := utf8.DecodeLastRuneInString(m.input); size > 0 {
+			m.input = m.input[:len(m.input)-size]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input = typeAhead(m.input, string(msg.Runes), m.gotoAt, m.keyAt)
+		m.gotoAt = m.keyAt
+	default:
+		return m, nil
+	}
+	if i := gotoMatch(m.sessions, m.input); i >= 0 {
+		m.cursor = i
+	}
+	return m, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTypeAhead(t *testing.T) {
+	tests := []struct {
+		prefix string
+		pause  time.Duration
+		want   string
+	}{
+		{"ap", 200 * time.Millisecond, "api"},
+		{"ap", gotoReset, "api"},                  // exactly the reset pause still continues
+		{"ap", gotoReset + time.Millisecond, "i"}, // a longer pause starts over
+		{"", 0, "i"},
+	}
+	for _, tt := range tests {
+		if got := typeAhead(tt.prefix, "i", testTime, testTime.Add(tt.pause)); got != tt.want {
+			t.Errorf("typeAhead(%q, i) after %v = %q, want %q", tt.prefix, tt.pause, got, tt.want)
+		}
+	}
+}
+
+func TestGotoMatch(t *testing.T) {
+	sessions := []ClaudeSession{testSession("web", 0), testSession("api-v2", 0), testSession("API", 0), testSession("apps", 0)}
+	tests := []struct {
+		prefix string
+		want   int
+	}{
+		{"a", 1}, // the first match, in list order
+		{"API", 1},
+		{"app", 3},
+		{"w", 0},
+		{"x", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := gotoMatch(sessions, tt.prefix); got != tt.want {
+			t.Errorf("gotoMatch(%q) = %d, want %d", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestGotoMode(t *testing.T) {
+	setConfig(t, nil)
+	at := func(key string, d time.Duration) keyAtMsg {
+		return keyAtMsg{KeyMsg: press(key), at: testTime.Add(d)}
+	}
+	tests := []struct {
+		keys   []keyAtMsg
+		input  string
+		cursor string
+	}{
+		{[]keyAtMsg{at("w", 0), at("i", 100*time.Millisecond)}, "wi", "wiki"},
+		{[]keyAtMsg{at("w", 0), at("e", 100*time.Millisecond)}, "we", "web"},
+		{[]keyAtMsg{at("w", 0), at("a", 2*time.Second)}, "a", "api"},                                        // reset after a pause
+		{[]keyAtMsg{at("w", 0), at("i", 10*time.Millisecond), at("x", 20*time.Millisecond)}, "wix", "wiki"}, // no match: stay put
+		{[]keyAtMsg{at("w", 0), at("e", 10*time.Millisecond), at("backspace", 20*time.Millisecond)}, "w", "web"},
+	}
+	for _, tt := range tests {
+		m := update(newModel(), scanned(testSession("api", 0), testSession("web", 0), testSession("wiki", 0)), press("G"))
+		for _, k := range tt.keys {
+			m = update(m, k)
+		}
+		if m.mode != modeGoto || m.input != tt.input || len(m.sessions) != 3 {
+			t.Errorf("typed %q: mode %d, %d rows", m.input, m.mode, len(m.sessions))
+		}
+		if got := m.sessions[m.cursor].SessionName; got != tt.cursor {
+			t.Errorf("typed %q: cursor on %s, want %s", tt.input, got, tt.cursor)
+		}
+	}
+
+	m := update(newModel(), scanned(testSession("api", 0), testSession("web", 0)), press("G"), at("w", 0), press("esc"))
+	if m.mode != modeNormal || m.input != "" || m.sessions[m.cursor].SessionName != "web" {
+		t.Errorf("esc: mode %d, input %q, cursor %d", m.mode, m.input, m.cursor)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status tracking across scans
+
+// historySize bounds the transition log.
+const historySize = 20
+
+// transition is one observed status change of a pane.
+type transition struct {
+	at       time.Time
+	paneID   string // shown in the log
+	key      string // the session's Key, which tracking is by
+	from, to int
+}
+
+// history is a fixed-size ring buffer of transitions, oldest overwritten first.
+type history struct {
+	buf  [historySize]transition
+	next int
+	n    int
+}
+
+func (h *history) add(t transition) {
+	h.buf[h.next] = t
+	h.next = (h.next + 1) % historySize
+	h.n = min(h.n+1, historySize)
+}
+
+// recent returns the logged transitions, newest first.
+func (h *history) recent() []transition {
+	out := make([]transition, h.n)
+	for i := range out {
+		out[i] = h.buf[(h.next-1-i+historySize)%historySize]
+	}
+	return out
+}
+
+// tracker remembers each pane's last status and when it entered it, by
+// Key, so a pane keeps its status-since when its window is renumbered.
+type tracker struct {
+	status map[string]int
+	since  map[string]time.Time
+	log    *history
+}
+
+func newTracker() tracker {
+	return tracker{
+		status: map[string]int{},
+		since:  map[string]time.Time{},
+		log:    &history{},
+	}
+}
+
+// observe reconciles a scan taken at time at with the previous one, logging
+// status changes and forgetting panes that disappeared. It returns the
+// transitions seen in this scan.
+func (t tracker) observe(sessions []ClaudeSession, at time.Time) []transition {
+	var changed []transition
+	seen := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		seen[s.Key] = true
+		prev, ok := t.status[s.Key]
+		switch {
+		case !ok:
+			t.since[s.Key] = at
+		case prev != s.Status:
+			tr := transition{at: at, paneID: s.PaneID, key: s.Key, from: prev, to: s.Status}
+			t.log.add(tr)
+			changed = append(changed, tr)
+			t.since[s.Key] = at
+		}
+		t.status[s.Key] = s.Status
+	}
+	for id := range t.status {
+		if !seen[id] {
+			delete(t.status, id)
+			delete(t.since, id)
+		}
+	}
+	return changed
+}
+
+// renderHistory renders the transition panel, newest first.
+func renderHistory(h *history) string {
+	var b strings.Builder
+	b.WriteString(dimStyle.Render("  Recent transitions"))
+	b.WriteString("\n")
+	events := h.recent()
+	if len(events) == 0 {
+		b.WriteString(dimStyle.Render("  (none yet)"))
+		b.WriteString("\n")
+	}
+	for _, e := range events {
+		from, to := statusStyles[e.from], statusStyles[e.to]
+		fmt.Fprintf(&b, asciiOnly("  %s  %s  %s → %s\n"),
+			dimStyle.Render(e.at.Format("15:04:05")),
+			e.paneID,
+			from.Render(statusSymbol(e.from)+" "+statusLabel(e.from)),
+			to.Render(statusSymbol(e.to)+" "+statusLabel(e.to)))
+	}
+	return b.String()
+}
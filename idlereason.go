@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// Idle sub-states
+//
+// An idle session is either freshly replied, with Claude's answer above
+// the prompt waiting to be read, or stale: nothing answered since the
+// screen was cleared or the session started, or the reply has sat unread
+// longer than idle_stale_after. The first is worth a look; the second is
+// safe to leave or close.
+
+// IdleReason classifies an idle session.
+type IdleReason int
+
+const (
+	IdleStale   IdleReason = iota // no recent reply above the prompt
+	IdleReplied                   // Claude replied and awaits the next prompt
+)
+
+// replyMarkers are built-in line prefixes that mark Claude's messages.
+var replyMarkers = []string{"⏺"}
+
+// repliedAbovePrompt reports whether a reply marker, built-in or extra,
+// starts a line between the last prompt line and the prompt before it,
+// that is, whether Claude answered in the latest turn. Like afterLastLine
+// it walks the lines backwards, so a deep capture is not split up.
+func repliedAbovePrompt(content string, extra []string) bool {
+	prompt := false
+	end := len(content)
+	for {
+		start := strings.LastIndexByte(content[:end], '\n') + 1
+		line := content[start:end]
+		switch {
+		case isPromptLine(line):
+			if prompt {
+				return false
+			}
+			prompt = true
+		case prompt && isReplyLine(line, extra):
+			return true
+		}
+		if start == 0 {
+			return false
+		}
+		end = start - 1
+	}
+}
+
+// isReplyLine reports whether line starts with a reply marker.
+func isReplyLine(line string, extra []string) bool {
+	line = strings.TrimLeft(line, " \t│")
+	for _, list := range [][]string{replyMarkers, extra} {
+		for _, m := range list {
+			if strings.HasPrefix(line, m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// idleReason returns how s, idle since since, reads at now: a reply
+// goes stale once it has been idle longer than stale (0 never).
+func idleReason(s ClaudeSession, since, now time.Time, stale time.Duration) IdleReason {
+	if s.Idle != IdleReplied {
+		return IdleStale
+	}
+	if stale > 0 && !since.IsZero() && now.Sub(since) > stale {
+		return IdleStale
+	}
+	return IdleReplied
+}
+
+// idleReason is idleReason for s as of the last scan.
+func (m model) idleReason(s ClaudeSession) IdleReason {
+	return idleReason(s, m.track.since[s.Key], m.now, cfg.idleStale)
+}
+
+// idleIcon marks a replied session next to its Idle label.
+func idleIcon(r IdleReason) string {
+	if r == IdleReplied {
+		return asciiOnly("✓")
+	}
+	return " "
+}
+
+func idleReasonName(r IdleReason) string {
+	if r == IdleReplied {
+		return "replied"
+	}
+	return "stale"
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// splitRepliedAbovePrompt is repliedAbovePrompt as it was first written,
+// splitting the whole capture into lines: the reference it must agree with.
+func splitRepliedAbovePrompt(content string, extra []string) bool {
+	lines := strings.Split(content, "\n")
+	i := len(lines) - 1
+	for i >= 0 && !isPromptLine(lines[i]) {
+		i--
+	}
+	for i--; i >= 0; i-- {
+		if isPromptLine(lines[i]) {
+			return false
+		}
+		if isReplyLine(lines[i], extra) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRepliedAbovePrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		extra   []string
+		want    bool
+	}{
+		{"fresh reply", "❯ fix the test\n\n⏺ Fixed it: the fixture was stale.\n\n╭────╮\n│ ❯  │\n╰────╯\n  ? for shortcuts\n", nil, true},
+		{"reply in an earlier turn", "⏺ Done.\n❯ /clear\n\n❯ \n", nil, false},
+		{"cleared screen", "\n\n❯ \n  ? for shortcuts\n", nil, false},
+		{"new session", "╭───╮\n│ ✻ Welcome to Claude Code! │\n╰───╯\n\n❯ \n", nil, false},
+		{"no prompt", "⏺ Working on it\n", nil, false},
+		{"boxed marker", "❯ go\n│ ⏺ inside a box\n❯ \n", nil, true},
+		{"extra marker", "❯ go\n● Answer from a newer release\n❯ \n", []string{"●"}, true},
+		{"extra marker unset", "❯ go\n● Answer from a newer release\n❯ \n", nil, false},
+		{"marker below the prompt", "❯ go\n\n❯ \n⏺ typed after\n", nil, false},
+		{"no trailing newline", "❯ go\n⏺ ok\n❯ ", nil, true},
+		{"empty", "", nil, false},
+	}
+	for _, tt := range tests {
+		got := repliedAbovePrompt(tt.content, tt.extra)
+		if got != tt.want {
+			t.Errorf("%s: repliedAbovePrompt = %v, want %v", tt.name, got, tt.want)
+		}
+		if ref := splitRepliedAbovePrompt(tt.content, tt.extra); got != ref {
+			t.Errorf("%s: repliedAbovePrompt = %v, the split reference %v", tt.name, got, ref)
+		}
+	}
+	if !repliedAbovePrompt(deepCapture, nil) {
+		t.Error("a deep capture ending in a reply reads as stale")
+	}
+}
+
+func TestIdleReason(t *testing.T) {
+	replied := testSession("a", StatusIdle)
+	replied.Idle = IdleReplied
+	tests := []struct {
+		s     ClaudeSession
+		since time.Time
+		stale time.Duration
+		want  IdleReason
+	}{
+		{replied, testTime.Add(-time.Minute), 10 * time.Minute, IdleReplied},
+		{replied, testTime.Add(-11 * time.Minute), 10 * time.Minute, IdleStale}, // unread too long
+		{replied, testTime.Add(-11 * time.Minute), 0, IdleReplied},              // idle_stale_after 0
+		{replied, time.Time{}, 10 * time.Minute, IdleReplied},                   // since unknown
+		{testSession("b", StatusIdle), testTime, 10 * time.Minute, IdleStale},
+	}
+	for _, tt := range tests {
+		if got := idleReason(tt.s, tt.since, testTime, tt.stale); got != tt.want {
+			t.Errorf("%s idle since %v, stale after %v: %s, want %s", tt.s.SessionName, tt.since, tt.stale,
+				idleReasonName(got), idleReasonName(tt.want))
+		}
+	}
+}
+
+func TestRepliedMarkGoesStale(t *testing.T) {
+	setConfig(t, nil)
+	s := testSession("a", StatusIdle)
+	s.Idle = IdleReplied
+	row := func(m model) string {
+		for _, line := range strings.Split(m.View(), "\n") {
+			if strings.Contains(line, "a task") {
+				return line
+			}
+		}
+		t.Fatalf("no row for a in\n%s", m.View())
+		return ""
+	}
+	m := update(newModel(), scannedAt(testTime, s))
+	if !strings.Contains(row(m), "✓") {
+		t.Errorf("a fresh reply is not marked: %q", row(m))
+	}
+	m = update(m, scannedAt(testTime.Add(11*time.Minute), s))
+	if strings.Contains(row(m), "✓") {
+		t.Errorf("a reply unread past idle_stale_after is still marked: %q", row(m))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+)
+
+// Last output line (the output column)
+
+// outputWidth caps the output column, so one long line does not push
+// the columns after it off screen.
+const outputWidth = 60
+
+// spinnerGlyphs lead Claude's status line while it works, e.g.
+// "✻ Thinking… (esc to interrupt)"; Braille spinners are checked apart.
+const spinnerGlyphs = "✳✻✶✢✽·*"
+
+// chromeMarkers mark lines of Claude's interface rather than its output:
+// key hints and mode lines under the input box.
+var chromeMarkers = []string{"? for shortcuts", "esc to interrupt", "⏵"}
+
+// lastOutputLine returns the most recent line of Claude's output in
+// content: the last line that is not blank, the input prompt, a spinner
+// status line or interface chrome. The ⏺ that marks a message is dropped.
+func lastOutputLine(content string) string {
+	lines := strings.Split(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if isPromptLine(lines[i]) {
+			continue
+		}
+		line := strings.Trim(lines[i], " \t│╭╮╰╯─")
+		if line == "" || isChrome(line) {
+			continue
+		}
+		if r, ok := firstRune(line); ok && (isBraillePrefix(line) || strings.ContainsRune(spinnerGlyphs, r)) {
+			continue
+		}
+		return strings.TrimSpace(strings.TrimPrefix(line, "⏺"))
+	}
+	return ""
+}
+
+func isChrome(line string) bool {
+	for _, m := range chromeMarkers {
+		if strings.Contains(line, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLastOutputLine(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"idle at the prompt", strings.Join([]string{
+			"⏺ Fixed the failing test in parser_test.go.",
+			"",
+			"╭──────────────────────────────╮",
+			"│ ❯                             │",
+			"╰──────────────────────────────╯",
+			"  ? for shortcuts",
+		}, "\n"), "Fixed the failing test in parser_test.go."},
+		{"working", strings.Join([]string{
+			"⏺ Reading main.go",
+			"  ⎿  Read 120 lines",
+			"",
+			"✻ Thinking… (esc to interrupt)",
+			"",
+			"❯ ",
+		}, "\n"), "⎿  Read 120 lines"},
+		{"braille spinner", "⏺ Running the tests\n⠋ Running…\n", "Running the tests"},
+		{"plan mode chrome", "⏺ Here is the plan.\n❯ \n  ⏵⏵ accept edits on (shift+tab to cycle)\n", "Here is the plan."},
+		{"nothing yet", "\n\n❯ \n", ""},
+	}
+	for _, tt := range tests {
+		if got := lastOutputLine(tt.content); got != tt.want {
+			t.Errorf("%s: lastOutputLine = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOutputColumnToggle(t *testing.T) {
+	s := testSession("a", StatusIdle)
+	s.Output = "All 42 tests pass."
+	tests := []struct {
+		columns []string
+		keys    []string
+		shown   bool
+	}{
+		{defaultColumns, nil, false},
+		{defaultColumns, []string{"O"}, true},
+		{append([]string{"output"}, defaultColumns...), nil, true},
+		{append([]string{"output"}, defaultColumns...), []string{"O"}, false},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.Columns = tt.columns })
+		m := update(newModel(), scanned(s))
+		for _, k := range tt.keys {
+			m = update(m, press(k))
+		}
+		if shown := strings.Contains(m.View(), "All 42 tests pass."); shown != tt.shown {
+			t.Errorf("columns %q, keys %q: output shown %v, want %v", tt.columns, tt.keys, shown, tt.shown)
+		}
+	}
+
+	long := strings.Repeat("x", 2*outputWidth)
+	s.Output = long
+	setConfig(t, func(c *Config) { c.Columns = append([]string{"output"}, defaultColumns...) })
+	view := update(newModel(), scanned(s)).View()
+	if strings.Contains(view, long[:outputWidth]) || !strings.Contains(view, long[:outputWidth-1]+"…") {
+		t.Errorf("a long output line is not clipped to %d cells:\n%s", outputWidth, view)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Multi-column layout
+
+const (
+	// wideLayoutWidth is the terminal width above which sessions are laid
+	// out in several columns instead of one.
+	wideLayoutWidth = 160
+	// columnGap is the blank space between adjacent columns.
+	columnGap = 4
+	// minimalLayoutWidth is the terminal width below which each session is
+	// reduced to its status symbol and name.
+	minimalLayoutWidth = 40
+)
+
+// gridSize returns the column and row count for n rows of at most rowWidth
+// cells in a terminal of the given width. Columns are filled top to bottom
+// and kept balanced, so every column but the last has exactly rows entries.
+func gridSize(n, rowWidth, width int) (cols, rows int) {
+	if n == 0 {
+		return 1, 0
+	}
+	cols = 1
+	if width > wideLayoutWidth && rowWidth > 0 {
+		cols = (width + columnGap) / (rowWidth + columnGap)
+	}
+	cols = max(1, min(cols, n))
+	rows = (n + cols - 1) / cols
+	// Re-derive columns from rows so no column is left empty.
+	cols = (n + rows - 1) / rows
+	return cols, rows
+}
+
+// cellOf maps a session index to its column and row in the grid.
+func cellOf(i, rows int) (col, row int) {
+	if rows == 0 {
+		return 0, 0
+	}
+	return i / rows, i % rows
+}
+
+// indexAt maps a grid cell back to a session index, or -1 if the cell is empty.
+func indexAt(col, row, rows, n int) int {
+	if row < 0 || row >= rows || col < 0 {
+		return -1
+	}
+	i := col*rows + row
+	if i >= n {
+		return -1
+	}
+	return i
+}
+
+// moveColumn returns the cursor after moving dc columns left or right.
+// Moving into a shorter last column lands on its final entry.
+func moveColumn(cursor, dc, rows, n int) int {
+	col, row := cellOf(cursor, rows)
+	lastCol, _ := cellOf(n-1, rows)
+	target := col + dc
+	if target < 0 || target > lastCol {
+		return cursor
+	}
+	if i := indexAt(target, row, rows, n); i >= 0 {
+		return i
+	}
+	return n - 1
+}
+
+// scrollWindow returns the half-open range of total rows to show in height
+// rows, scrolling to keep the cursor's row centered once the list overflows.
+// A height of zero or less means unlimited.
+func scrollWindow(total, cursor, height int) (start, end int) {
+	if height <= 0 || total <= height {
+		return 0, total
+	}
+	start = min(max(0, cursor-height/2), total-height)
+	return start, start + height
+}
+
+// spaceRows returns lines with a blank line between each pair.
+func spaceRows(lines []string) []string {
+	out := make([]string, 0, 2*len(lines))
+	for i, l := range lines {
+		if i > 0 {
+			out = append(out, "")
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// joinColumns lays out rendered rows column-major, padding each cell to width.
+func joinColumns(lines []string, rows, width int) []string {
+	out := make([]string, rows)
+	for r := 0; r < rows; r++ {
+		var b strings.Builder
+		for c := 0; ; c++ {
+			i := indexAt(c, r, rows, len(lines))
+			if i < 0 {
+				break
+			}
+			if c > 0 {
+				b.WriteString(strings.Repeat(" ", columnGap))
+			}
+			cell := lines[i]
+			if next := indexAt(c+1, r, rows, len(lines)); next >= 0 {
+				cell += strings.Repeat(" ", max(0, width-lipgloss.Width(cell)))
+			}
+			b.WriteString(cell)
+		}
+		out[r] = b.String()
+	}
+	return out
+}
+
+// frame draws a rounded border around lines with caption embedded in the top
+// edge. The frame spans width columns (or hugs the content when width is
+// unknown) and, if height is positive, is padded to height rows. Lines wider
+// than the frame are truncated.
+func frame(lines []string, caption string, width, height int) string {
+	inner := max(maxWidth(lines), lipgloss.Width(caption)+4)
+	if width > 2 {
+		inner = width - 2
+	}
+	clip := lipgloss.NewStyle().MaxWidth(inner)
+	pad := func(s string) string {
+		s = clip.Render(s)
+		return s + strings.Repeat(" ", max(0, inner-lipgloss.Width(s)))
+	}
+	edge := func(s string) string { return dimStyle.Render(asciiOnly(s)) }
+
+	caption = lipgloss.NewStyle().MaxWidth(max(0, inner-4)).Render(caption)
+	rule := max(0, inner-3-lipgloss.Width(caption))
+	var b strings.Builder
+	b.WriteString(edge("╭─ ") + caption + edge(" "+strings.Repeat("─", rule)+"╮") + "\n")
+	rows := max(len(lines), height-2)
+	for i := 0; i < rows; i++ {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+		b.WriteString(edge("│") + pad(line) + edge("│") + "\n")
+	}
+	b.WriteString(edge("╰" + strings.Repeat("─", inner) + "╯"))
+	return b.String()
+}
+
+// Title and path budget (< and >)
+
+const (
+	// titleWidthStep is how many cells one < or > moves between the title
+	// and path columns.
+	titleWidthStep = 4
+	// minTitleWidth and minPathWidth keep either column from collapsing.
+	minTitleWidth = 12
+	minPathWidth  = 8
+)
+
+// adjustTitleWidth returns the title budget after moving delta cells
+// towards the title (positive) or the path (negative). A budget of 0 is
+// automatic: both columns show in full and the terminal edge clips the
+// row; the first press starts from widest, the longest title listed. The
+// budget stays within [minTitleWidth, widest].
+func adjustTitleWidth(budget, widest, delta int) int {
+	if budget == 0 {
+		budget = widest
+		if delta > 0 {
+			delta = 0 // a full-width title is as wide as it gets
+		}
+	}
+	return max(minTitleWidth, min(widest, budget+delta))
+}
+
+// pathBudget returns the width left for the path column in a row of width
+// cells once fixed, the other columns and gaps, and the title budget are
+// taken, but no less than minPathWidth.
+func pathBudget(width, fixed, title int) int {
+	return max(minPathWidth, width-fixed-title)
+}
+
+// clipRight shortens s to w cells, ending it with "…".
+func clipRight(s string, w int) string {
+	if lipgloss.Width(s) <= w {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > w {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + asciiOnly("…")
+}
+
+// clipLeft shortens s to w cells, starting it with "…", so the end of a
+// path stays visible.
+func clipLeft(s string, w int) string {
+	if lipgloss.Width(s) <= w {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > w {
+		runes = runes[1:]
+	}
+	return asciiOnly("…") + string(runes)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestGridSize(t *testing.T) {
+	tests := []struct {
+		n, rowWidth, width int
+		cols, rows         int
+	}{
+		{0, 50, 200, 1, 0},
+		{5, 50, 120, 1, 5}, // narrow terminal: one column
+		{5, 50, 200, 3, 2},
+		{4, 50, 200, 2, 2},  // three would fit, but two rows need only two
+		{5, 300, 200, 1, 5}, // rows wider than the terminal
+		{1, 50, 200, 1, 1},
+	}
+	for _, tt := range tests {
+		cols, rows := gridSize(tt.n, tt.rowWidth, tt.width)
+		if cols != tt.cols || rows != tt.rows {
+			t.Errorf("gridSize(%d, %d, %d) = %d, %d; want %d, %d", tt.n, tt.rowWidth, tt.width, cols, rows, tt.cols, tt.rows)
+		}
+	}
+}
+
+func TestCellIndexRoundTrip(t *testing.T) {
+	const n, rows = 7, 3
+	for i := 0; i < n; i++ {
+		col, row := cellOf(i, rows)
+		if got := indexAt(col, row, rows, n); got != i {
+			t.Errorf("indexAt(cellOf(%d)) = %d", i, got)
+		}
+	}
+	for _, c := range [][2]int{{2, 1}, {3, 0}, {0, 3}, {-1, 0}, {0, -1}} {
+		if got := indexAt(c[0], c[1], rows, n); got != -1 {
+			t.Errorf("indexAt(%d, %d) = %d, want -1 (empty cell)", c[0], c[1], got)
+		}
+	}
+}
+
+func TestMoveColumn(t *testing.T) {
+	// Five sessions in two rows: columns [0 1] [2 3] [4].
+	tests := []struct {
+		cursor, dc, want int
+	}{
+		{1, 1, 3},
+		{3, 1, 4}, // the last column is shorter: land on its final entry
+		{0, -1, 0},
+		{4, 1, 4},
+		{4, -1, 2},
+	}
+	for _, tt := range tests {
+		if got := moveColumn(tt.cursor, tt.dc, 2, 5); got != tt.want {
+			t.Errorf("moveColumn(%d, %d) = %d, want %d", tt.cursor, tt.dc, got, tt.want)
+		}
+	}
+}
+
+func TestJoinColumns(t *testing.T) {
+	got := joinColumns([]string{"a", "b", "c"}, 2, 3)
+	want := []string{"a" + strings.Repeat(" ", 2+columnGap) + "c", "b"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("joinColumns = %q, want %q", got, want)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	got := frame([]string{"a", "a long line clipped"}, "Cap", 10, 5)
+	want := strings.Join([]string{
+		"╭─ Cap ──╮",
+		"│a       │",
+		"│a long l│",
+		"│        │",
+		"╰────────╯",
+	}, "\n")
+	if got != want {
+		t.Errorf("frame:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBorderedViewFitsAfterResize(t *testing.T) {
+	setConfig(t, func(c *Config) { c.Border = true })
+	m := update(newModel(), scanned(testSession("alpha", StatusWaiting), testSession("beta", StatusIdle)))
+	for _, size := range []tea.WindowSizeMsg{{Width: 80, Height: 20}, {Width: 40, Height: 12}, {Width: 100, Height: 20}} {
+		m = update(m, size)
+		lines := strings.Split(m.View(), "\n")
+		if !strings.HasPrefix(lines[0], "╭─ Claude Sessions") {
+			t.Fatalf("%dx%d: first line %q", size.Width, size.Height, lines[0])
+		}
+		bottom := -1
+		for i, line := range lines {
+			if strings.HasPrefix(line, "╰") {
+				bottom = i
+				break
+			}
+		}
+		if bottom < 0 {
+			t.Fatalf("%dx%d: no bottom edge in\n%s", size.Width, size.Height, m.View())
+		}
+		for _, line := range lines[:bottom+1] {
+			if w := lipgloss.Width(line); w != size.Width {
+				t.Errorf("%dx%d: frame line %q is %d wide", size.Width, size.Height, line, w)
+			}
+		}
+		for _, line := range lines[1:bottom] {
+			if !strings.HasPrefix(line, "│") || !strings.HasSuffix(line, "│") {
+				t.Errorf("%dx%d: row %q is not inside the border", size.Width, size.Height, line)
+			}
+		}
+	}
+}
+
+func TestAdjustTitleWidth(t *testing.T) {
+	tests := []struct {
+		budget, widest, delta, want int
+	}{
+		{0, 40, -4, 36}, // the first press starts from the widest title
+		{0, 40, 4, 40},  // automatic is already as wide as it gets
+		{36, 40, 4, 40},
+		{40, 40, 4, 40},
+		{14, 40, -4, minTitleWidth},
+		{minTitleWidth, 40, -4, minTitleWidth},
+	}
+	for _, tt := range tests {
+		if got := adjustTitleWidth(tt.budget, tt.widest, tt.delta); got != tt.want {
+			t.Errorf("adjustTitleWidth(%d, %d, %d) = %d, want %d", tt.budget, tt.widest, tt.delta, got, tt.want)
+		}
+	}
+}
+
+func TestPathBudget(t *testing.T) {
+	tests := []struct{ width, fixed, title, want int }{
+		{100, 40, 30, 30},
+		{60, 40, 30, minPathWidth}, // never below the minimum
+	}
+	for _, tt := range tests {
+		if got := pathBudget(tt.width, tt.fixed, tt.title); got != tt.want {
+			t.Errorf("pathBudget(%d, %d, %d) = %d, want %d", tt.width, tt.fixed, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestClip(t *testing.T) {
+	tests := []struct {
+		s           string
+		w           int
+		right, left string
+	}{
+		{"short", 10, "short", "short"},
+		{"~/work/api-server", 8, "~/work/…", "…-server"},
+		{"日本語のタイトル", 7, "日本語…", "…イトル"}, // wide runes count two cells
+	}
+	for _, tt := range tests {
+		if got := clipRight(tt.s, tt.w); got != tt.right {
+			t.Errorf("clipRight(%q, %d) = %q, want %q", tt.s, tt.w, got, tt.right)
+		}
+		if got := clipLeft(tt.s, tt.w); got != tt.left {
+			t.Errorf("clipLeft(%q, %d) = %q, want %q", tt.s, tt.w, got, tt.left)
+		}
+	}
+}
+
+func TestTitleWidthPersists(t *testing.T) {
+	setConfig(t, nil)
+	s := testSession("a", StatusIdle)
+	s.Title = strings.Repeat("t", 30)
+	m := update(newModel(), scanned(s), press("<"), press("<"))
+	if m.titleWidth != 22 {
+		t.Fatalf("title width %d after two <, want 22", m.titleWidth)
+	}
+
+	restarted := newModel()
+	restarted.applyState(m.state())
+	if restarted.titleWidth != 22 {
+		t.Errorf("title width %d after a restart, want 22", restarted.titleWidth)
+	}
+	restarted = update(restarted, press("="))
+	if restarted.titleWidth != 0 {
+		t.Errorf("title width %d after =, want automatic", restarted.titleWidth)
+	}
+
+	// A hand-edited state file below the minimum is ignored.
+	restarted.applyState(State{TitleWidth: 3})
+	if restarted.titleWidth != 0 {
+		t.Errorf("title width %d from a too-small saved budget", restarted.titleWidth)
+	}
+}
+
+func TestSpaceRows(t *testing.T) {
+	tests := []struct{ in, want []string }{
+		{nil, []string{}},
+		{[]string{"a"}, []string{"a"}},
+		{[]string{"a", "b", "c"}, []string{"a", "", "b", "", "c"}},
+	}
+	for _, tt := range tests {
+		if got := spaceRows(tt.in); strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("spaceRows(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDensity(t *testing.T) {
+	var sessions []ClaudeSession
+	for _, name := range []string{"s0", "s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9"} {
+		sessions = append(sessions, testSession(name, StatusIdle))
+	}
+	tests := []struct {
+		density string
+		scroll  string
+		spaced  bool
+	}{
+		{"compact", "rows 2–10 of 10", false},
+		{"comfortable", "rows 5–9 of 10", true}, // half as many rows fit
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.Density = tt.density })
+		m := update(newModel(), tea.WindowSizeMsg{Width: 100, Height: 14}, scanned(sessions...))
+		for range 6 {
+			m = update(m, press("j"))
+		}
+		view := m.View()
+		lines := strings.Split(view, "\n")
+		if len(lines) > 14 {
+			t.Errorf("%s: %d lines in a 14-line terminal", tt.density, len(lines))
+		}
+		if !strings.Contains(view, tt.scroll) {
+			t.Errorf("%s: no %q in\n%s", tt.density, tt.scroll, view)
+		}
+		cursor := slices.IndexFunc(lines, func(l string) bool { return strings.Contains(l, "▸") })
+		if cursor < 0 || !strings.Contains(lines[cursor], "s6 task") {
+			t.Fatalf("%s: the cursor row s6 is not shown in\n%s", tt.density, view)
+		}
+		if spaced := strings.TrimSpace(lines[cursor+1]) == ""; spaced != tt.spaced {
+			t.Errorf("%s: blank line under the cursor row %v, want %v", tt.density, spaced, tt.spaced)
+		}
+	}
+
+	c := defaultConfig()
+	c.Density = "roomy"
+	if err := c.validate(); err == nil {
+		t.Error("validate accepted density roomy")
+	}
+}
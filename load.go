@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Load throttling (load_threshold)
+//
+// On a busy machine csm backs off: while the 1-minute load average is
+// above load_threshold, scans run throttleFactor times less often, up to
+// maxThrottle, and with that many times fewer concurrent captures.
+
+// maxThrottle caps the backoff: at most one scan every maxThrottle ticks.
+const maxThrottle = 8
+
+// throttleFactor returns how far to back off at load: 1 at or below
+// threshold (or with threshold 0, disabled), else load/threshold rounded
+// up, capped at maxThrottle.
+func throttleFactor(load, threshold float64) int {
+	if threshold <= 0 || load <= threshold {
+		return 1
+	}
+	return min(maxThrottle, int(math.Ceil(load/threshold)))
+}
+
+// throttledInterval is the tick interval under a throttle factor.
+func throttledInterval(factor int) time.Duration {
+	return time.Duration(max(1, factor)) * time.Second
+}
+
+// throttledConcurrency is capture_concurrency shared out under a
+// throttle factor, keeping at least one capture.
+func throttledConcurrency(concurrency, factor int) int {
+	return max(1, concurrency/max(1, factor))
+}
+
+// systemLoad returns the 1-minute load average, from /proc/loadavg or, on
+// macOS, sysctl. It fails where neither is available, which disables
+// throttling.
+func systemLoad() (float64, bool) {
+	if runtime.GOOS == "darwin" {
+		out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output()
+		if err != nil {
+			return 0, false
+		}
+		return parseLoad(strings.Trim(strings.TrimSpace(string(out)), "{ }"))
+	}
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, false
+	}
+	return parseLoad(string(data))
+}
+
+// parseLoad reads the first field of a load average line such as
+// "0.52 0.58 0.59 1/389 12345".
+func parseLoad(s string) (float64, bool) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || load < 0 {
+		return 0, false
+	}
+	return load, true
+}
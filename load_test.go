@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	tests := []struct {
+		load, threshold float64
+		factor          int
+		interval        time.Duration
+		concurrency     int // of 8
+	}{
+		{3, 0, 1, time.Second, 8}, // load_threshold 0: disabled
+		{3, 4, 1, time.Second, 8},
+		{4, 4, 1, time.Second, 8}, // at the threshold is not above it
+		{4.1, 4, 2, 2 * time.Second, 4},
+		{10, 4, 3, 3 * time.Second, 2},
+		{100, 4, maxThrottle, maxThrottle * time.Second, 1}, // capped
+	}
+	for _, tt := range tests {
+		factor := throttleFactor(tt.load, tt.threshold)
+		if factor != tt.factor {
+			t.Errorf("throttleFactor(%v, %v) = %d, want %d", tt.load, tt.threshold, factor, tt.factor)
+			continue
+		}
+		if got := throttledInterval(factor); got != tt.interval {
+			t.Errorf("load %v: interval %v, want %v", tt.load, got, tt.interval)
+		}
+		if got := throttledConcurrency(8, factor); got != tt.concurrency {
+			t.Errorf("load %v: concurrency %d, want %d", tt.load, got, tt.concurrency)
+		}
+	}
+	if got := throttledConcurrency(1, maxThrottle); got != 1 {
+		t.Errorf("throttledConcurrency(1, %d) = %d, want at least one capture", maxThrottle, got)
+	}
+}
+
+func TestParseLoad(t *testing.T) {
+	tests := []struct {
+		s    string
+		load float64
+		ok   bool
+	}{
+		{"0.52 0.58 0.59 1/389 12345\n", 0.52, true}, // /proc/loadavg
+		{"1.91 2.03 2.10", 1.91, true},               // sysctl vm.loadavg, braces trimmed
+		{"", 0, false},
+		{"high 1 1", 0, false},
+		{"-1 0 0", 0, false},
+	}
+	for _, tt := range tests {
+		load, ok := parseLoad(tt.s)
+		if load != tt.load || ok != tt.ok {
+			t.Errorf("parseLoad(%q) = %v, %v; want %v, %v", tt.s, load, ok, tt.load, tt.ok)
+		}
+	}
+}
+
+func TestThrottledFooter(t *testing.T) {
+	setConfig(t, nil)
+	msg := scanned(testSession("a", StatusIdle))
+	m := update(newModel(), msg)
+	if strings.Contains(m.View(), "throttled") {
+		t.Error("an unthrottled scan shows the throttle indicator")
+	}
+	msg.stats.throttle = 3
+	if view := update(m, msg).View(); !strings.Contains(view, "throttled ×3") {
+		t.Errorf("no throttle indicator in\n%s", view)
+	}
+}
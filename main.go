@@ -4,14 +4,19 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // Status constants
@@ -19,35 +24,76 @@ const (
 	StatusIdle    = 0
 	StatusWaiting = 1
 	StatusWorking = 2
+	StatusExited  = 3 // Claude title left behind at a shell; only with show_exited
 )
 
 type ClaudeSession struct {
 	PaneID      string
+	Key         string // stable identity across scans; see paneKey
 	SessionName string
 	Title       string
 	Path        string
 	Status      int
+	WaitReason  WaitReason  // set when Status is StatusWaiting
+	Idle        IdleReason  // set when Status is StatusIdle; see idleReason
+	Prompt      string      // the question a Waiting session asks, if found
+	Output      string      // last line of Claude's output; see lastOutputLine
+	Turns       string      // turn/token indicator parsed via turn_pattern
+	Progress    progress    // latest progress_pattern match; Working sessions only
+	PathMissing bool        // Path no longer exists; skip path-dependent commands
+	Created     time.Time   // tmux #{session_created}; zero if unknown
+	GitBranch   string      // branch checked out at Path, or "" outside a repository
+	Clients     int         // tmux clients attached to the session; set with show_clients
+	Why         explanation // how detection classified the pane, for the e panel
+	Group       string      // tmux session group; the pane is listed once, under SessionName
+	Socket      string      // server socket with --all-users, "" for the current server
+	Owner       string      // who runs the server at Socket, e.g. "alice" or "alice/work"
+	Background  bool        // in a window no client is looking at; see paneBackground
 }
 
 // Messages
-type sessionsMsg []ClaudeSession
+type sessionsMsg struct {
+	sessions []ClaudeSession
+	stats    scanStats
+	current  string    // PaneID the tmux client is viewing
+	at       time.Time // when the scan ran
+}
 type tickMsg time.Time
 
+// refreshMsg requests an immediate rescan outside the tick loop.
+type refreshMsg struct{}
+
+// keyAtMsg is a key press stamped with the time it arrived, so Update
+// never reads the clock and a replayed key behaves as it did when
+// recorded. stampKeys turns every tea.KeyMsg into one.
+type keyAtMsg struct {
+	tea.KeyMsg
+	at time.Time
+}
+
+// stampKeys stamps key presses on their way to Update; see tea.WithFilter.
+func stampKeys(_ tea.Model, msg tea.Msg) tea.Msg {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		return keyAtMsg{KeyMsg: k, at: time.Now()}
+	}
+	return msg
+}
+
 // Commands
 func scan() tea.Cmd {
 	return func() tea.Msg {
-		sessions := detectSessions()
-		return sessionsMsg(sessions)
+		sessions, stats := detectConfigured(sysRunner)
+		return sessionsMsg{sessions: sessions, stats: stats, current: backend.Current(sysRunner), at: time.Now()}
 	}
 }
 
-func tick() tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+// tick schedules the next scan after d.
+func tick(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
-
 // Detection pipeline
 
 // shellCommands lists processes that indicate Claude has exited.
@@ -55,155 +101,526 @@ var shellCommands = map[string]bool{
 	"zsh": true, "bash": true, "fish": true, "sh": true, "dash": true,
 }
 
+// defaultPassthroughCommands run Claude out of tmux's sight (on another
+// host, in a container). A Claude title on such a pane is trusted as is.
+var defaultPassthroughCommands = []string{"ssh", "mosh", "mosh-client", "docker", "podman"}
+
+// claudeExited reports whether cmd in the foreground means Claude has exited.
+// Passthrough commands never do, even if also listed as shells.
+func claudeExited(cmd string, passthrough map[string]bool) bool {
+	return shellCommands[cmd] && !passthrough[cmd]
+}
+
 // isClaudeTitle returns true if the title starts with ✳ or a Braille spinner (U+2800–U+28FF).
 func isClaudeTitle(title string) bool {
-	if title == "" {
-		return false
-	}
-	r, _ := utf8.DecodeRuneInString(title)
-	return r == '✳' || (r >= 0x2800 && r <= 0x28FF)
+	r, ok := firstRune(title)
+	return ok && isMarker(r)
 }
 
 // isBraillePrefix returns true if the first rune is a Braille spinner character.
 func isBraillePrefix(title string) bool {
-	if title == "" {
-		return false
+	r, ok := firstRune(title)
+	return ok && r >= 0x2800 && r <= 0x28FF
+}
+
+// firstRune decodes the first rune of s. It fails for an empty string and
+// for invalid UTF-8, so garbled titles never match a marker.
+func firstRune(s string) (rune, bool) {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError && size <= 1 {
+		return 0, false
 	}
-	r, _ := utf8.DecodeRuneInString(title)
-	return r >= 0x2800 && r <= 0x28FF
+	return r, true
+}
+
+// isMarker returns true for ✳ and Braille spinner runes.
+func isMarker(r rune) bool {
+	return r == '✳' || (r >= 0x2800 && r <= 0x28FF)
 }
 
-// cleanTitle strips the ✳ or Braille prefix from the title for display.
+// cleanTitle strips any leading run of ✳/Braille markers and the whitespace
+// around them, so compound prefixes like "✳ ⠂ task" display as "task".
+// Invalid UTF-8 is replaced with U+FFFD so titles are always valid strings.
 func cleanTitle(title string) string {
-	r, size := utf8.DecodeRuneInString(title)
-	if r == '✳' || (r >= 0x2800 && r <= 0x28FF) {
-		return strings.TrimSpace(title[size:])
+	title = strings.ToValidUTF8(title, "\uFFFD")
+	if !isClaudeTitle(title) {
+		return title
 	}
-	return title
+	return strings.TrimSpace(strings.TrimLeftFunc(title, func(r rune) bool {
+		return isMarker(r) || unicode.IsSpace(r)
+	}))
 }
 
-func detectSessions() []ClaudeSession {
-	// Step 1: list all panes (includes pane_current_command for liveness check)
-	out, err := exec.Command("tmux", "list-panes", "-a", "-F",
-		"#{session_name}:#{window_index}.#{pane_index}\t#{pane_current_path}\t#{pane_title}\t#{pane_current_command}").Output()
-	if err != nil {
-		return nil
+// scanStats counts what a scan saw, to explain an empty result.
+type scanStats struct {
+	listed   bool // list-panes succeeded
+	panes    int  // panes reported by tmux
+	titled   int  // panes with a Claude title marker
+	exited   int  // titled panes dropped because a shell is in the foreground
+	sessions int  // panes kept as sessions
+	unread   int  // candidate panes dropped because capture-pane failed
+	throttle int  // load backoff factor for this scan; 1 is none, see load.go
+}
+
+// paneClaudeTitle picks the title that marks a pane as Claude: the pane title,
+// or with useWindow the window name when the pane title carries no marker
+// (titles set via OSC escapes sometimes only reach the window name).
+func paneClaudeTitle(paneTitle, windowName string, useWindow bool) (string, bool) {
+	if isClaudeTitle(paneTitle) {
+		return paneTitle, true
+	}
+	if useWindow && isClaudeTitle(windowName) {
+		return windowName, true
+	}
+	return "", false
+}
+
+// listPanesFormat is the list-panes -F format detection parses; one
+// tab-separated line per pane.
+const listPanesFormat = "#{session_name}:#{window_index}.#{pane_index}\t#{pane_current_path}\t#{pane_title}\t#{pane_current_command}\t#{window_name}\t#{session_created}\t#{pane_pid}\t#{pane_id}\t#{window_active}\t#{session_attached}"
+
+// detectSessions lists Claude sessions across all tmux panes, running tmux through r.
+func detectSessions(r CommandRunner) []ClaudeSession {
+	sessions, _ := detectConfigured(r)
+	return sessions
+}
+
+// detectConfigured is the backend's detection, extended to other tmux
+// servers with --all-users.
+func detectConfigured(r CommandRunner) ([]ClaudeSession, scanStats) {
+	return backend.Detect(r)
+}
+
+// paneInfo is a pane that passed the title and command checks, before
+// its content is inspected.
+type paneInfo struct {
+	id      string
+	key     string // paneKey
+	sess    string
+	path    string
+	title   string
+	working bool // title has Braille spinner prefix
+	exited  bool // a shell is back in the foreground
+	created time.Time
+	why     explanation
+	group   string // tmux session group, if the session is in one
+	bg      bool   // out of sight; see paneBackground
+}
+
+// newScanStats starts the counts of a scan, with its load backoff.
+func newScanStats() scanStats {
+	stats := scanStats{throttle: 1}
+	if cfg.LoadThreshold > 0 {
+		if load, ok := systemLoad(); ok {
+			stats.throttle = throttleFactor(load, cfg.LoadThreshold)
+		}
 	}
+	return stats
+}
+
+// detect is detectSessions on one tmux server, plus the counts behind its
+// result.
+func detect(r CommandRunner) ([]ClaudeSession, scanStats) {
+	stats := newScanStats()
 
-	type paneInfo struct {
-		id      string
-		sess    string
-		path    string
-		title   string
-		working bool // title has Braille spinner prefix
+	// Step 1: list all panes (includes pane_current_command for liveness check)
+	out, err := tolerantOutput(r, "tmux", "list-panes", "-a", "-F", listPanesFormat)
+	if err != nil {
+		return nil, stats
 	}
+	stats.listed = true
 
 	var candidates []paneInfo
+	var procs *procTree // loaded on first use by --deep-detect
 	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
 		if line == "" {
 			continue
 		}
-		parts := strings.SplitN(line, "\t", 4)
-		if len(parts) < 4 {
+		// #{pane_id}, #{window_active} and #{session_attached} came
+		// later; lines without them (older replay fixtures) still parse,
+		// keyed by PaneID and counted as visible.
+		parts := strings.SplitN(line, "\t", 10)
+		if len(parts) < 7 {
 			continue
 		}
-		title := parts[2]
+		stats.panes++
 		cmd := parts[3]
 
 		// Check A: title must start with ✳ or Braille spinner
-		if !isClaudeTitle(title) {
-			continue
+		title, ok := paneClaudeTitle(parts[2], parts[4], cfg.MatchWindowName)
+		why := explanation{source: "pane title", rawTitle: title, command: cmd}
+		if ok && title != parts[2] {
+			why.source = "window name"
 		}
-		// Check B: command must not be a shell (indicates Claude has exited)
-		if shellCommands[cmd] {
+		exited := false
+		if ok {
+			stats.titled++
+			// Check B: command must not be a shell (indicates Claude has exited)
+			if claudeExited(cmd, cfg.passthrough) {
+				stats.exited++
+				if !cfg.ShowExited {
+					continue
+				}
+				exited = true
+			}
+		} else {
+			// With --deep-detect, an untitled pane still counts if Claude
+			// runs in its foreground process tree.
+			if !cfg.deepDetect || shellCommands[cmd] {
+				continue
+			}
+			if procs == nil {
+				t, err := loadProcTree(r)
+				if err != nil {
+					debugLog.Printf("deep-detect: ps: %v", err)
+					t = parseProcTree("")
+				}
+				procs = &t
+			}
+			pid, err := strconv.Atoi(parts[6])
+			if err != nil || !procs.hasClaude(pid) {
+				continue
+			}
+			title = parts[2]
+			why.source, why.rawTitle = "process tree", title
+		}
+
+		if !pathInScope(parts[1], cfg.includeRoots, cfg.excludeRoots) {
 			continue
 		}
 
 		paneID := parts[0]
 		sessName := strings.SplitN(paneID, ":", 2)[0]
+		uid, background := "", false
+		if len(parts) > 7 {
+			uid = parts[7]
+		}
+		if len(parts) > 9 {
+			background = paneBackground(parts[8], parts[9])
+		}
 		candidates = append(candidates, paneInfo{
 			id:      paneID,
+			key:     paneKey(sessName, paneID, uid),
 			sess:    sessName,
 			path:    parts[1],
 			title:   cleanTitle(title),
-			working: isBraillePrefix(title),
+			working: isBraillePrefix(title) && !exited,
+			exited:  exited,
+			created: parseTmuxTime(parts[5]),
+			why:     why,
+			bg:      background,
 		})
 	}
 
 	if len(candidates) == 0 {
-		return nil
+		return nil, stats
+	}
+
+	// Grouped sessions share windows, so list-panes reports each shared
+	// pane once per member; keep one.
+	infos, err := listSessions(r)
+	if err != nil {
+		debugLog.Printf("list-sessions: %v", err)
 	}
+	candidates = dedupGroups(candidates, infos)
 
+	sessions := inspectPanes(candidates, func(pane string) (string, error) {
+		return capturePane(r, pane)
+	}, &stats)
+	if cfg.ShowClients {
+		joinClients(sessions, infos)
+	}
+	stats.sessions = len(sessions)
+	return sessions, stats
+}
+
+// inspectPanes determines the status of each candidate, reading pane
+// content through capture, and returns the sessions sorted by PaneID.
+// Candidates that could not be captured are counted in stats.unread.
+func inspectPanes(candidates []paneInfo, capture func(pane string) (string, error), stats *scanStats) []ClaudeSession {
 	// Step 2: determine status in parallel
-	// Working sessions (Braille prefix) need no capture-pane call.
+	// Working sessions (Braille prefix) need no capture-pane call
+	// unless deep_status is on.
 	// Idle/Waiting sessions (✳ prefix) capture content to distinguish.
+	// At most cfg.CaptureConcurrency capture-pane processes run at once,
+	// fewer under load_threshold.
 	results := make([]ClaudeSession, len(candidates))
 	valid := make([]bool, len(candidates))
-	var wg sync.WaitGroup
-
-	for i, c := range candidates {
-		wg.Add(1)
-		go func(idx int, p paneInfo) {
-			defer wg.Done()
-
-			status := StatusIdle
-			if p.working {
-				status = StatusWorking
-			} else {
-				// ✳ prefix — capture pane to distinguish Waiting vs Idle
-				out, err := exec.Command("tmux", "capture-pane", "-t", p.id, "-p", "-S", "-50").Output()
-				if err != nil {
-					return
-				}
-				content := string(out)
-				status = determineStatus(content)
+	sem := make(chan struct{}, throttledConcurrency(cfg.CaptureConcurrency, stats.throttle))
+
+	inspect := func(idx int, p paneInfo) {
+		// ✳ prefix — capture pane to distinguish Waiting vs Idle.
+		// With deep_status, working panes are captured too so their
+		// content can be parsed; a failed capture then isn't fatal.
+		// Exited panes show a shell; there is nothing to capture.
+		var content string
+		why := p.why
+		if !p.exited && (!p.working || cfg.DeepStatus) {
+			sem <- struct{}{}
+			out, err := capture(p.id)
+			<-sem
+			if err != nil && !p.working {
+				debugLog.Printf("capture-pane %s: %v", p.id, err)
+				return
 			}
+			content = out
+			why.captured = true
+		}
 
-			results[idx] = ClaudeSession{
-				PaneID:      p.id,
-				SessionName: p.sess,
-				Title:       p.title,
-				Path:        shortenPath(p.path),
-				Status:      status,
+		v := verdict{status: StatusWorking}
+		switch {
+		case p.exited:
+			v.status = StatusExited
+		case !p.working:
+			v = determineStatus(content)
+		}
+		if v.status == StatusIdle && cfg.motionDetect {
+			time.Sleep(motionInterval)
+			sem <- struct{}{}
+			after, err := capture(p.id)
+			<-sem
+			if contentMoved(content, after, err) {
+				v.status, v.idle, content = StatusWorking, IdleStale, after
+				why.moved = true
 			}
-			valid[idx] = true
-		}(i, c)
+		}
+		status, reason := v.status, v.reason
+		why.verdict = v
+		var question string
+		if status == StatusWaiting {
+			question = waitQuestion(content)
+		}
+		var prog progress
+		if status == StatusWorking {
+			prog = parseProgress(content, cfg.progressRe)
+		}
+		// A deleted directory has no branch; looking anyway would walk up
+		// to a parent repository and report its branch.
+		missing, branch := !pathExists(p.path), ""
+		if !missing {
+			branch = gitBranch(p.path)
+		}
+
+		results[idx] = ClaudeSession{
+			PaneID:      p.id,
+			Key:         p.key,
+			SessionName: p.sess,
+			Title:       p.title,
+			Path:        shortenPath(p.path),
+			Status:      status,
+			WaitReason:  reason,
+			Idle:        v.idle,
+			Prompt:      question,
+			Output:      lastOutputLine(content),
+			Turns:       extractIndicator(content, cfg.turnRe),
+			Progress:    prog,
+			PathMissing: missing,
+			GitBranch:   branch,
+			Created:     p.created,
+			Why:         why,
+			Group:       p.group,
+			Background:  p.bg,
+		}
+		valid[idx] = true
+	}
+
+	// --sequential inspects panes one at a time, in list-panes order,
+	// for tracing; results are identical either way.
+	if cfg.sequential {
+		for i, c := range candidates {
+			inspect(i, c)
+		}
+	} else {
+		var wg sync.WaitGroup
+		for i, c := range candidates {
+			wg.Add(1)
+			go func(idx int, p paneInfo) {
+				defer wg.Done()
+				inspect(idx, p)
+			}(i, c)
+		}
+		wg.Wait()
 	}
-	wg.Wait()
 
+	// Panes that could not be captured are left out, but counted, so the
+	// footer can say the list is incomplete.
 	var sessions []ClaudeSession
 	for i, v := range valid {
 		if v {
 			sessions = append(sessions, results[i])
+		} else {
+			stats.unread++
 		}
 	}
 
 	sort.Slice(sessions, func(i, j int) bool {
 		return sessions[i].PaneID < sessions[j].PaneID
 	})
-
 	return sessions
 }
 
-func determineStatus(content string) int {
+// paneKey identifies a pane across scans by its session and tmux's
+// #{pane_id}, such as "work/%3", which survive window renumbering. If
+// tmux did not report a pane_id it falls back to paneID.
+func paneKey(sess, paneID, uid string) string {
+	if uid = strings.TrimSpace(uid); uid != "" {
+		return sess + "/" + uid
+	}
+	return paneID
+}
+
+// paneBackground reports whether a pane is out of sight, from tmux's
+// #{window_active} and #{session_attached}: its window is not the one its
+// session shows, or no client is attached to the session. Other panes of
+// the window on screen are visible, so #{pane_active} does not matter.
+func paneBackground(windowActive, attached string) bool {
+	return strings.TrimSpace(windowActive) != "1" || strings.TrimSpace(attached) == "0"
+}
+
+// extractIndicator returns the last match of re in content: its first
+// capture group if it has one, else the whole match. A nil re disables it.
+func extractIndicator(content string, re *regexp.Regexp) string {
+	if re == nil || content == "" {
+		return ""
+	}
+	matches := re.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	last := matches[len(matches)-1]
+	if len(last) > 1 {
+		return strings.TrimSpace(last[1])
+	}
+	return strings.TrimSpace(last[0])
+}
+
+// currentPane returns the PaneID the tmux client is viewing, or "" if unknown.
+func currentPane(r CommandRunner) string {
+	out, err := tolerantOutput(r, "tmux", "display-message", "-p", "#{session_name}:#{window_index}.#{pane_index}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// verdict is determineStatus's result with the evidence behind it, for
+// the e explain panel.
+type verdict struct {
+	status  int
+	reason  WaitReason
+	prompt  bool        // a ❯ prompt line with text after it was found
+	marker  string      // waiting marker found after the prompt, if any
+	pattern WaitPattern // wait pattern that set reason; zero if none matched
+	idle    IdleReason  // whether Claude replied above the prompt; idle only
+}
+
+func determineStatus(content string) verdict {
 	// Only called for ✳-prefixed (non-working) sessions.
-	// Distinguish Waiting (user input requested) vs Idle.
+	// Distinguish Waiting (user input requested) vs Idle, and classify
+	// what a Waiting session is asking for.
 	// Only check content AFTER the last prompt to avoid stale matches.
-	lines := strings.Split(content, "\n")
-	lastPrompt := -1
-	for i, line := range lines {
-		if strings.Contains(line, "❯") {
-			lastPrompt = i
+	// An Idle session is told apart as replied or stale by what is above
+	// the prompt.
+	v := verdict{status: StatusIdle}
+	if afterPrompt, ok := afterLastPrompt(content); ok {
+		v.prompt = true
+		if marker, ok := matchWaitingMarker(afterPrompt, cfg.WaitingMarkers); ok {
+			v.status, v.marker = StatusWaiting, marker
+			// A dialog's question sits above its ❯ 1. Yes selector, which
+			// is itself a prompt line; look past it to the input prompt.
+			dialog, ok := afterLastLine(content, isInputLine)
+			if !ok {
+				dialog = content
+			}
+			if p, ok := matchWaitPattern(dialog, cfg.WaitPatterns); ok {
+				v.reason, v.pattern = waitReasonNames[p.Reason], p
+			}
 		}
 	}
+	if v.status == StatusIdle && repliedAbovePrompt(content, cfg.ReplyMarkers) {
+		v.idle = IdleReplied
+	}
+	return v
+}
+
+// waitingMarkers are built-in phrases that mean Claude is waiting for input:
+// its confirmation dialogs and pager-style continue prompts.
+var waitingMarkers = []string{
+	"esc to cancel",
+	"press enter to continue",
+	"press any key to continue",
+	"continue? (y/n)",
+}
 
-	if lastPrompt >= 0 && lastPrompt < len(lines)-1 {
-		afterPrompt := strings.Join(lines[lastPrompt+1:], "\n")
-		if strings.Contains(afterPrompt, "Esc to cancel") {
-			return StatusWaiting
+// matchWaitingMarker returns the first built-in or extra waiting marker
+// found in text, ignoring case.
+func matchWaitingMarker(text string, extra []string) (string, bool) {
+	lower := strings.ToLower(text)
+	for _, list := range [][]string{waitingMarkers, extra} {
+		for _, m := range list {
+			if strings.Contains(lower, strings.ToLower(m)) {
+				return m, true
+			}
 		}
 	}
-	return StatusIdle
+	return "", false
+}
+
+// afterLastPrompt returns the text after the last prompt line. It walks
+// lines backwards from the end, so deep captures are not split into a
+// slice; ok is false when there is no prompt or nothing follows it.
+func afterLastPrompt(content string) (after string, ok bool) {
+	return afterLastLine(content, isPromptLine)
+}
+
+// afterLastLine is afterLastPrompt for the last line that match accepts.
+func afterLastLine(content string, match func(line string) bool) (after string, ok bool) {
+	end := len(content)
+	for {
+		start := strings.LastIndexByte(content[:end], '\n') + 1
+		if match(content[start:end]) {
+			if end == len(content) {
+				return "", false
+			}
+			return content[end+1:], true
+		}
+		if start == 0 {
+			return "", false
+		}
+		end = start - 1
+	}
+}
+
+// pathExists reports whether path can be stat'ed. Panes whose directory was
+// deleted or unmounted keep reporting it as pane_current_path.
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// isPromptLine reports whether line is Claude's input prompt: ❯ as the first
+// glyph, ignoring indentation and the input box's │ border. A ❯ elsewhere in
+// a line is ordinary output.
+func isPromptLine(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t│"), "❯")
+}
+
+// isOptionLine reports whether line is a dialog's selected option, such as
+// "│ ❯ 1. Yes": a prompt line whose text starts with a number and a dot.
+func isOptionLine(line string) bool {
+	rest, ok := strings.CutPrefix(strings.TrimLeft(line, " \t│"), "❯")
+	if !ok {
+		return false
+	}
+	rest = strings.TrimLeft(rest, " ")
+	digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+	return digits > 0 && strings.HasPrefix(rest[digits:], ".")
+}
+
+// isInputLine reports whether line is Claude's input prompt and not a
+// dialog option.
+func isInputLine(line string) bool {
+	return isPromptLine(line) && !isOptionLine(line)
 }
 
 func shortenPath(path string) string {
@@ -211,192 +628,828 @@ func shortenPath(path string) string {
 	if err != nil {
 		return path
 	}
-	if strings.HasPrefix(path, home) {
-		return "~" + path[len(home):]
+	return shortenPathFor(path, home)
+}
+
+// shortenPathFor replaces a leading home directory in path with ~. Only whole
+// path components match, so /home/bob2 stays as is when home is /home/bob.
+// A home of / is never shortened, since every path would become ~/...
+func shortenPathFor(path, home string) string {
+	home = strings.TrimRight(home, "/")
+	if home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+"/"); ok {
+		return "~/" + rest
 	}
 	return path
 }
 
 // Bubble Tea model
 
+// Input modes
+const (
+	modeNormal  = iota
+	modeLaunch  // typing the directory for a new session
+	modeFilter  // typing the / filter query
+	modeConfirm // confirming m.confirm
+	modeMove    // m: moving the selected session in the manual order
+	modeStatus  // f: toggling which statuses are shown
+	modeLayout  // L: picking a layout from the menu
+	modeGoto    // G: typing a session name to jump to
+)
+
 type model struct {
-	sessions   []ClaudeSession
+	all        []ClaudeSession // every detected session
+	sessions   []ClaudeSession // visible sessions, after filters
 	cursor     int
 	width      int
 	height     int
 	quitting   bool
 	selectedID string
+	then       followUp // done to the selected pane after switching to it
+
+	mode   int
+	input  string    // text typed in the current input mode
+	layout int       // 1-based layout the modeLaunch prompt opens; 0 for a plain n launch
+	notice string    // last action result, shown in the help line
+	gotoAt time.Time // when the last goto key was typed; see typeAhead
+
+	minStatus int          // hide sessions needing less attention than this
+	hidden    map[int]bool // statuses hidden with f, independent of minStatus
+	sortMode  string       // one of sortModes
+	order     []string     // paths in the manual sort order; see reorder.go
+
+	titleWidth int // < and >: title budget, the path column taking the rest; 0 is automatic
+
+	collapse      bool // fold idle sessions into one summary row
+	collapsedIdle int  // idle sessions folded into the summary row
+
+	current string // PaneID the tmux client is viewing, refreshed each scan
+
+	filter    string // / filter query; "~" prefix for a regular expression
+	filterErr string // why filter could not be compiled
+
+	track       tracker // per-pane status and status-since across scans
+	showHistory bool
+	showDetail  bool // raw fields of the selected session below the list
+	showExplain bool // e: explain how the selected row was classified
+	showOutput  bool // O: show the output column; starts on if columns lists it
+	statusAge   bool // T: the age column shows time in the current status, not session age
+
+	stats    scanStats // counts from the last scan, for the empty state
+	scanning bool      // a scan is in flight
+	rescan   bool      // scan again once the one in flight finishes
+	now      time.Time // time of the last scan, for the age column
+	tickAt   time.Time // time of the last tick, for the footer's heartbeat
+	control  bool      // --control-mode is up: scan on tmux events, ticks only poll slowly
+
+	muted  map[string]bool // paths whose sessions never alert
+	pinned map[string]bool // paths whose sessions sort above the rest
+	alerts notifier
+
+	declined map[string]bool // session Keys the user chose not to auto-kill
+
+	confirm confirmation // action awaiting y/n in modeConfirm
+
+	popup      bool   // publish popupTitle for the tmux popup border
+	popupTitle string // last published title
+
+	windows *windowMarker // nil unless waiting_window_style is set
+
+	project     project // resolved from the launch directory; root "" if unknown
+	projectOnly bool    // p: list only sessions in project
+
+	flashes  map[string]time.Time // Key → when its row started flashing
+	flashing bool                 // a flashTick is pending
+	frame    time.Time            // time of the last flash frame
+
+	keyScanAt time.Time // last scan_on_key rescan
+	keyAt     time.Time // when the key being handled was pressed; see keyAtMsg
+
+	recent []string // PaneIDs last switched to, newest first; see pushRecent
+
+	baseline baseline // snapshot the digest compares against
+	digest   bool     // D: mark changes since baseline
 }
 
+func newModel() model {
+	return model{
+		minStatus:  cfg.minStatus,
+		sortMode:   cfg.Sort,
+		track:      newTracker(),
+		muted:      map[string]bool{},
+		pinned:     map[string]bool{},
+		hidden:     map[int]bool{},
+		declined:   map[string]bool{},
+		flashes:    map[string]time.Time{},
+		showOutput: slices.Contains(cfg.Columns, "output"),
+		alerts:     newNotifier(cfg.debounce),
+		scanning:   true, // Init's scan
+	}
+}
+
+// launchDir is the directory the launch prompt starts with: the selected
+// session's, or ~.
+func (m model) launchDir() string {
+	if m.cursor < len(m.sessions) && !m.sessions[m.cursor].PathMissing {
+		return m.sessions[m.cursor].Path
+	}
+	return "~"
+}
+
+// refilter recomputes the visible sessions from m.all.
+func (m *model) refilter() {
+	oldID := ""
+	if m.cursor < len(m.sessions) {
+		oldID = m.sessions[m.cursor].Key
+	}
+	m.sessions = filterHidden(filterMinStatus(m.all, m.minStatus), m.hidden)
+	if m.projectOnly {
+		m.sessions = filterProject(m.sessions, m.project)
+	}
+	sortSessions(m.sessions, m.sortMode, m.pinned, m.order)
+	m.filterErr = ""
+	if m.filter != "" {
+		score, err := compileFilter(m.filter, cfg.SearchFields)
+		if err != nil {
+			m.filterErr = err.Error()
+		} else {
+			m.sessions = rankQuery(m.sessions, score)
+		}
+	}
+	m.collapsedIdle = 0
+	if m.collapse {
+		m.sessions, m.collapsedIdle = collapseIdle(m.sessions, m.pinned)
+	}
+	// Preserve cursor position by matching PaneID, unless the
+	// cursor is configured to stay on the same row.
+	if oldID != "" && cfg.CursorFollow != "row" {
+		for i, s := range m.sessions {
+			if s.Key == oldID {
+				m.cursor = i
+				return
+			}
+		}
+	}
+	if m.cursor >= m.rowCount() {
+		m.cursor = max(0, m.rowCount()-1)
+	}
+}
+
+// find returns the detected session with the given PaneID.
+func (m model) find(paneID string) (ClaudeSession, bool) {
+	for _, s := range m.all {
+		if s.PaneID == paneID {
+			return s, true
+		}
+	}
+	return ClaudeSession{PaneID: paneID}, false
+}
+
+// Init starts the first scan; newModel marks it in flight.
 func (m model) Init() tea.Cmd {
-	return tea.Batch(scan(), tick())
+	return tea.Batch(scan(), tick(time.Second))
+}
+
+// requestScan starts a scan unless one is still in flight, in which case a
+// single follow-up scan runs when it finishes. On a loaded system scans can
+// outlast the tick; overlapping them would only pile up capture-pane calls.
+func (m *model) requestScan() tea.Cmd {
+	if m.scanning {
+		m.rescan = true
+		return nil
+	}
+	m.scanning = true
+	return scan()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case sessionsMsg:
-		oldID := ""
-		if m.cursor < len(m.sessions) {
-			oldID = m.sessions[m.cursor].PaneID
-		}
-		m.sessions = msg
-		// Preserve cursor position by matching PaneID
-		if oldID != "" {
-			for i, s := range m.sessions {
-				if s.PaneID == oldID {
-					m.cursor = i
-					return m, nil
-				}
+		m.all = msg.sessions
+		m.stats = msg.stats
+		m.current = msg.current
+		m.now = msg.at
+		changed := m.track.observe(msg.sessions, msg.at)
+		if m.baseline.sessions == nil {
+			m.baseline = newBaseline(msg.sessions, msg.at)
+		}
+		m.refilter()
+		m.forgetDeclines()
+		due := m.alerts.due(changed, msg.sessions, m.muted, msg.at)
+		var title tea.Cmd
+		if m.popup {
+			if t := popupTitle(msg.sessions); t != m.popupTitle {
+				m.popupTitle = t
+				title = setPopupTitle(t)
 			}
 		}
-		if m.cursor >= len(m.sessions) {
-			m.cursor = max(0, len(m.sessions)-1)
+		marks := markWindows(m.windows.update(msg.sessions))
+		var flash tea.Cmd
+		if cfg.flash > 0 {
+			startFlashes(m.flashes, changed, msg.sessions, m.muted)
+			m.frame = msg.at
+			if len(m.flashes) > 0 && !m.flashing {
+				m.flashing = true
+				flash = flashTick()
+			}
 		}
+		m.scanning = false
+		var again tea.Cmd
+		if m.rescan {
+			m.rescan = false
+			again = m.requestScan()
+		}
+		return m, tea.Batch(alert(due), playSound(due), onWaiting(due), title, marks, flash, again)
+
+	case flashMsg:
+		m.frame = time.Time(msg)
+		if expireFlashes(m.flashes, m.frame, cfg.flash) {
+			return m, flashTick()
+		}
+		m.flashing = false
 		return m, nil
 
 	case tickMsg:
-		return m, tea.Batch(scan(), tick())
+		m.tickAt = time.Time(msg)
+		next := tick(throttledInterval(m.stats.throttle))
+		if m.control && m.tickAt.Sub(m.now) < controlPoll {
+			return m, next
+		}
+		return m, tea.Batch(m.requestScan(), next)
+
+	case controlMsg:
+		m.control = false
+		m.notice = "Control mode stopped (" + msg.err.Error() + "); polling every second"
+		return m, m.requestScan()
+
+	case refreshMsg:
+		// Rescan only; the tick loop keeps its own schedule.
+		return m, m.requestScan()
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, nil
 
+	case paneMsg:
+		return m.chosen(msg)
+
+	case selectMsg:
+		m.quitting = true
+		m.selectedID = msg.pane
+		m.recent = pushRecent(m.recent, msg.pane)
+		return m, tea.Quit
+
+	case actionMsg:
+		if msg.err != nil {
+			m.notice = "Error: " + msg.err.Error()
+		} else {
+			m.notice = msg.notice
+		}
+		return m, m.requestScan()
+
+	case keyAtMsg:
+		m.keyAt = msg.at
+		return m.Update(msg.KeyMsg)
+
 	case tea.KeyMsg:
+		switch m.mode {
+		case modeConfirm:
+			return m.updateConfirm(msg)
+		case modeMove:
+			return m.updateMove(msg)
+		case modeStatus:
+			return m.updateStatusFilter(msg)
+		case modeLayout:
+			return m.updateLayoutMenu(msg)
+		case modeGoto:
+			return m.updateGoto(msg)
+		}
+		if m.mode != modeNormal {
+			return m.updateInput(msg)
+		}
+		m.notice = ""
+		if m.cursor < len(m.sessions) {
+			s := m.sessions[m.cursor]
+			if actsOnPane(msg.String(), s) {
+				err := foreignGuard(s)
+				if err == nil {
+					err = tmuxOnly(msg.String())
+				}
+				if err != nil {
+					m.notice = err.Error()
+					return m, nil
+				}
+			}
+			if answer, ok := scopedAnswer(cfg.ScopedKeys, s.Path, msg.String()); ok {
+				if s.Status != StatusWaiting {
+					m.notice = msg.String() + " answers " + s.Path + " sessions only while they wait"
+					return m, nil
+				}
+				return m.choose(s, followUp{answer: true, text: answer})
+			}
+		}
 		switch msg.String() {
 		case "q", "ctrl+c", "esc":
 			m.quitting = true
 			return m, tea.Quit
 		case "j", "down":
-			if len(m.sessions) > 0 {
-				m.cursor = (m.cursor + 1) % len(m.sessions)
-			}
+			m.cursor = moveCursor(m.cursor, 1, m.rowCount(), cfg.WrapNavigation)
 		case "k", "up":
-			if len(m.sessions) > 0 {
-				m.cursor = (m.cursor - 1 + len(m.sessions)) % len(m.sessions)
+			m.cursor = moveCursor(m.cursor, -1, m.rowCount(), cfg.WrapNavigation)
+		case "h", "left":
+			if m.rowCount() > 0 {
+				_, rows := m.grid(m.renderRows())
+				m.cursor = moveColumn(m.cursor, -1, rows, m.rowCount())
 			}
+		case "l", "right":
+			if m.rowCount() > 0 {
+				_, rows := m.grid(m.renderRows())
+				m.cursor = moveColumn(m.cursor, 1, rows, m.rowCount())
+			}
+		case "c":
+			m.collapse = !m.collapse
+			m.refilter()
 		case "enter":
+			if m.onSummaryRow() {
+				m.collapse = false
+				m.refilter()
+				// Land on the first of the sessions just revealed.
+				for i, s := range m.sessions {
+					if s.Status == StatusIdle {
+						m.cursor = i
+						break
+					}
+				}
+				break
+			}
+			if m.cursor < len(m.sessions) {
+				return m.choose(m.sessions[m.cursor], followUp{})
+			}
+		case "z":
+			if cfg.EnableZoom && m.cursor < len(m.sessions) {
+				return m.choose(m.sessions[m.cursor], followUp{zoom: true})
+			}
+		case "Y":
+			if m.cursor < len(m.sessions) {
+				s := m.sessions[m.cursor]
+				if err := quickAnswerGuard(s); err != nil {
+					m.notice = err.Error()
+					break
+				}
+				return m.choose(s, followUp{answer: true, text: cfg.QuickAnswer})
+			}
+		case "x":
 			if m.cursor < len(m.sessions) {
-				m.quitting = true
-				m.selectedID = m.sessions[m.cursor].PaneID
-				return m, tea.Quit
+				s := m.sessions[m.cursor]
+				if err := cancelGuard(s); err != nil {
+					m.notice = err.Error()
+					break
+				}
+				m.ask(confirmation{
+					prompt: "Send Escape to " + s.PaneID + ", interrupting its work?",
+					run:    cancelSession(s.PaneID),
+				})
+			}
+		case "R":
+			if m.cursor < len(m.sessions) {
+				s := m.sessions[m.cursor]
+				if err := restartGuard(s); err != nil {
+					m.notice = err.Error()
+					break
+				}
+				m.ask(confirmation{
+					prompt: "Run " + cfg.LaunchCmd + " again in " + s.PaneID + "?",
+					run:    restartSession(s.PaneID, cfg.LaunchCmd),
+				})
+			}
+		case "v":
+			m.minStatus = nextMinStatus(m.minStatus)
+			m.refilter()
+		case "f":
+			m.mode = modeStatus
+		case "s":
+			m.sortMode = nextSortMode(m.sortMode)
+			m.refilter()
+			m.notice = "Sort: " + m.sortMode
+		case "m":
+			m.startMove()
+		case "<", ">":
+			if len(m.sessions) > 0 {
+				delta := titleWidthStep
+				if msg.String() == "<" {
+					delta = -delta
+				}
+				m.titleWidth = adjustTitleWidth(m.titleWidth, max(minTitleWidth, m.widestTitle()), delta)
+				m.notice = fmt.Sprintf("Title width: %d (= for automatic)", m.titleWidth)
+			}
+		case "=":
+			m.titleWidth = 0
+			m.notice = "Title width: automatic"
+		case "tab", "shift+tab":
+			dir := 1
+			if msg.String() == "shift+tab" {
+				dir = -1
+			}
+			skip := ""
+			if cfg.SkipCurrent {
+				skip = m.current
+			}
+			m.cursor = nextWaiting(m.sessions, m.cursor, dir, skip)
+		case "y":
+			if m.cursor < len(m.sessions) {
+				cmd := expandTemplate(cfg.CopyTemplate, m.sessions[m.cursor])
+				return m, copyCmd(cmd, "switch command: "+cmd)
+			}
+		case "P":
+			if m.cursor < len(m.sessions) {
+				s := m.sessions[m.cursor]
+				if err := copyPromptGuard(s); err != nil {
+					m.notice = err.Error()
+					break
+				}
+				return m, copyCmd(s.Prompt, "prompt of "+s.PaneID)
 			}
+		case "M":
+			if m.cursor < len(m.sessions) {
+				path := m.sessions[m.cursor].Path
+				if m.muted[path] {
+					delete(m.muted, path)
+					m.notice = "Unmuted " + path
+				} else {
+					m.muted[path] = true
+					m.notice = "Muted " + path
+				}
+			}
+		case "*":
+			if m.cursor < len(m.sessions) {
+				path := m.sessions[m.cursor].Path
+				if m.pinned[path] {
+					delete(m.pinned, path)
+					m.notice = "Unpinned " + path
+				} else {
+					m.pinned[path] = true
+					m.notice = "Pinned " + path
+				}
+				m.refilter()
+			}
+		case "-":
+			if s, ok := lastSession(m.recent, m.current, m.all, m.sessions); ok {
+				return m.choose(s, followUp{})
+			}
+			m.notice = "No other session to switch to"
+		case "o":
+			if m.cursor < len(m.sessions) {
+				s := m.sessions[m.cursor]
+				if err := openPathGuard(s); err != nil {
+					m.notice = err.Error()
+					break
+				}
+				return m, openPath(s.Path)
+			}
+		case "p":
+			if m.project.root == "" {
+				m.notice = "Launch directory unknown"
+				break
+			}
+			m.projectOnly = !m.projectOnly
+			m.refilter()
+		case "D":
+			m.digest = !m.digest
+		case "b":
+			if m.now.IsZero() {
+				break // no scan yet; the first one sets the baseline
+			}
+			m.baseline = newBaseline(m.all, m.now)
+			m.notice = "Baseline reset to " + m.now.Format("15:04:05")
+		case "H":
+			m.showHistory = !m.showHistory
+		case "i":
+			m.showDetail = !m.showDetail
+		case "O":
+			m.showOutput = !m.showOutput
+		case "K":
+			m.offerAutoKill()
+		case "T":
+			m.statusAge = !m.statusAge
+			m.notice = "Age column: " + ageSourceName(m.statusAge)
+			if !slices.Contains(columns(), "age") {
+				m.notice += " (add age to columns or set show_age to see it)"
+			}
+		case "e":
+			m.showExplain = !m.showExplain
+		case "G":
+			m.mode, m.input = modeGoto, ""
+		case "/":
+			m.mode = modeFilter
+			m.input = m.filter
+		case "n":
+			m.mode = modeLaunch
+			m.input = m.launchDir()
+		case "L":
+			if len(cfg.Layouts) == 0 {
+				m.notice = "No layouts configured (see layouts in the config)"
+				break
+			}
+			m.mode = modeLayout
 		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
 			idx := int(msg.String()[0]-'0') - 1
-			if idx < len(m.sessions) {
-				m.quitting = true
-				m.selectedID = m.sessions[idx].PaneID
-				return m, tea.Quit
+			if cfg.NumberShortcuts && idx < len(m.sessions) {
+				return m.choose(m.sessions[idx], followUp{})
 			}
 		}
+		return m, m.keyScan(m.keyAt)
 	}
 
 	return m, nil
 }
 
-// Styles
-var (
-	titleStyle    = lipgloss.NewStyle().Bold(true).MarginBottom(1).MarginLeft(2)
-	selectedRow   = lipgloss.NewStyle().Background(lipgloss.Color("236"))
-	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("242"))
-	dimTitleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("242")).MarginTop(1).MarginLeft(2)
+// keyScanInterval limits scan_on_key rescans, so a held key does not
+// flood tmux with capture-pane calls.
+const keyScanInterval = 500 * time.Millisecond
 
-	statusStyles = map[int]lipgloss.Style{
-		StatusWorking: lipgloss.NewStyle().Foreground(lipgloss.Color("76")),  // green
-		StatusWaiting: lipgloss.NewStyle().Foreground(lipgloss.Color("214")), // amber
-		StatusIdle:    lipgloss.NewStyle().Foreground(lipgloss.Color("242")), // gray
+// keyScan requests a scan for a key pressed at now when scan_on_key is on
+// and the last key-triggered scan is at least keyScanInterval old.
+func (m *model) keyScan(now time.Time) tea.Cmd {
+	if !cfg.ScanOnKey || now.Sub(m.keyScanAt) < keyScanInterval {
+		return nil
 	}
-)
+	m.keyScanAt = now
+	return m.requestScan()
+}
 
-func statusSymbol(s int) string {
-	switch s {
-	case StatusWorking:
-		return "●"
-	case StatusWaiting:
-		return "◐"
-	default:
-		return "○"
+// choose selects s, to do then to it after switching, and quits once
+// checkPane has found its pane; see chosen. Every key that switches to a
+// session goes through it, so finishSelection handles them all alike.
+func (m model) choose(s ClaudeSession, then followUp) (tea.Model, tea.Cmd) {
+	if err := foreignGuard(s); err != nil {
+		m.notice = err.Error()
+		return m, nil
 	}
+	return m, checkPane(s, then)
 }
 
-func statusLabel(s int) string {
-	switch s {
-	case StatusWorking:
-		return "Working"
-	case StatusWaiting:
-		return "Waiting"
-	default:
-		return "Idle"
+// chosen finishes choose. If the pane has closed since the last scan, it
+// offers to start the launch command again at the session's path.
+func (m model) chosen(msg paneMsg) (tea.Model, tea.Cmd) {
+	s := msg.session
+	if !msg.exists {
+		if s.PathMissing {
+			m.notice = s.PaneID + " is gone and " + s.Path + " no longer exists"
+			return m, m.requestScan()
+		}
+		m.ask(confirmation{
+			prompt: s.PaneID + " is gone. Start " + cfg.LaunchCmd + " in " + s.Path + "?",
+			run:    recreateSession(s.Path, cfg.LaunchCmd),
+		})
+		return m, nil
 	}
+	m.quitting = true
+	m.selectedID, m.then = s.PaneID, msg.then
+	m.recent = pushRecent(m.recent, s.PaneID)
+	return m, tea.Quit
 }
 
-func (m model) View() string {
-	if m.quitting {
-		return ""
+// moveCursor moves cursor by delta within n rows, wrapping around the ends
+// when wrap is set and stopping at them otherwise.
+func moveCursor(cursor, delta, n int, wrap bool) int {
+	if n == 0 {
+		return 0
 	}
+	if wrap {
+		return ((cursor+delta)%n + n) % n
+	}
+	return max(0, min(n-1, cursor+delta))
+}
 
-	var b strings.Builder
-
-	b.WriteString(titleStyle.Render("Claude Sessions"))
-	b.WriteString("\n")
-
-	if len(m.sessions) == 0 {
-		b.WriteString(dimStyle.Render("  No Claude sessions found"))
-		b.WriteString("\n")
-	} else {
-		// Calculate column widths
-		maxSess := 0
-		for _, s := range m.sessions {
-			if len(s.SessionName) > maxSess {
-				maxSess = len(s.SessionName)
+// updateInput handles keys while a text prompt is open.
+func (m model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyEsc:
+		if m.mode == modeFilter {
+			m.filter = ""
+			m.refilter()
+		}
+		m.mode = modeNormal
+		m.input = ""
+		m.layout = 0
+	case tea.KeyEnter:
+		mode, input, layout := m.mode, strings.TrimSpace(m.input), m.layout
+		m.mode = modeNormal
+		m.input = ""
+		m.layout = 0
+		switch {
+		case mode != modeLaunch || input == "":
+		case layout > 0:
+			return m, launchLayout(cfg.Layouts[layout-1], input)
+		default:
+			return m, launchSession(input, cfg.LaunchCmd)
+		}
+	case tea.KeyUp, tea.KeyDown:
+		// Let the list be navigated while typing a filter.
+		if m.mode == modeFilter {
+			step := 1
+			if msg.Type == tea.KeyUp {
+				step = -1
 			}
+			m.cursor = moveCursor(m.cursor, step, m.rowCount(), cfg.WrapNavigation)
 		}
+	case tea.KeyBackspace:
+		if _, size := utf8.DecodeLastRuneInString(m.input); size > 0 {
+			m.input = m.input[:len(m.input)-size]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	}
+	if m.mode == modeFilter && m.input != m.filter {
+		m.filter = m.input
+		m.refilter()
+		m.cursor = 0 // the best match
+	}
+	return m, nil
+}
 
-		for i, s := range m.sessions {
-			pointer := "  "
-			if i == m.cursor {
-				pointer = " ▸"
-			}
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-			style := statusStyles[s.Status]
-			sym := style.Render(statusSymbol(s.Status))
-			label := style.Render(fmt.Sprintf("%-7s", statusLabel(s.Status)))
+// tuiOptions carries command-line settings for the interactive picker.
+type tuiOptions struct {
+	resetState bool
+	clearMutes bool
+	minStatus  string // explicit --min-status, overrides saved state
+	replay     bool   // detection reads fixtures; print the choice instead of switching
+	control    bool   // rescan on tmux control-mode events instead of every tick
+	record     string // --record: log messages to this file
+	dryRun     bool   // print the command a choice would run instead of running it
+	printID    bool   // print the chosen pane ID instead of switching; exit 1 on none
+	autoSwitch bool   // with exactly one session, choose it without the picker
+}
 
-			num := fmt.Sprintf("%d", i+1)
-			sess := fmt.Sprintf("%-*s", maxSess, s.SessionName)
-			title := dimTitleStyle.Render(s.Title)
+// runTUI runs the interactive picker and switches to the chosen session.
+func runTUI(opts tuiOptions) int {
+	if os.Getenv("TMUX") == "" && !opts.replay && tmuxOnly("csm") == nil {
+		fmt.Println("csm must be run inside a tmux session.")
+		return 1
+	}
 
-			line := fmt.Sprintf(" %s %s  %s %s   %s  %s", pointer, num, sym, label, sess, title)
+	stateFile := statePath()
+	if opts.resetState {
+		if err := resetState(stateFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	m := newModel()
+	m.applyState(loadState(stateFile))
+	if opts.clearMutes {
+		m.muted = map[string]bool{}
+	}
+	if opts.minStatus != "" {
+		m.minStatus = cfg.minStatus
+	}
 
-			if i == m.cursor {
-				line = selectedRow.Render(line)
+	if tmuxOnly("waiting_window_style") == nil {
+		m.windows = newWindowMarker(sysRunner, cfg.WaitingWindowStyle)
+	}
+	if dir, err := os.Getwd(); err == nil {
+		m.project = resolveProject(sysRunner, dir)
+	}
+	if opts.autoSwitch {
+		if all := detectSessions(sysRunner); len(all) == 1 && foreignGuard(all[0]) == nil {
+			m.recent = pushRecent(m.recent, all[0].PaneID)
+			if err := saveState(stateFile, m.state()); err != nil {
+				fmt.Fprintf(os.Stderr, "csm: saving state: %v\n", err)
 			}
-
-			b.WriteString(line)
-			b.WriteString("\n")
+			return finishSelection(all[0], followUp{}, opts)
 		}
 	}
 
-	b.WriteString(helpStyle.Render(" ↑↓ navigate · enter switch · q quit"))
+	m.control = opts.control && !opts.replay && tmuxOnly("--control-mode") == nil
+	m.popup = cfg.PopupTitle && inPopup()
+	if m.popup {
+		defer clearPopupTitle()
+	}
 
-	return b.String()
-}
+	if opts.printID {
+		// Keep stdout for the pane ID alone.
+		tuiOutput = os.Stderr
+	}
+	filter := stampKeys
+	var rec *recorder
+	if opts.record != "" {
+		var err error
+		if rec, err = openRecorder(opts.record, m.state()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --record: %v\n", err)
+			return 1
+		}
+		filter = func(m tea.Model, msg tea.Msg) tea.Msg { return rec.filter(m, stampKeys(m, msg)) }
+	}
+	progOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithOutput(tuiOutput), tea.WithFilter(filter)}
+	p := tea.NewProgram(m, progOpts...)
 
-func main() {
-	if os.Getenv("TMUX") == "" {
-		fmt.Println("csm must be run inside a tmux session.")
-		os.Exit(1)
+	// SIGUSR1 triggers an immediate rescan, e.g. from tmux hooks.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	go func() {
+		for range sigs {
+			p.Send(refreshMsg{})
+		}
+	}()
+
+	if m.control {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			if err := runControl(func() { p.Send(refreshMsg{}) }, stop); err != nil {
+				p.Send(controlMsg{err: err})
+			}
+		}()
 	}
 
-	p := tea.NewProgram(model{}, tea.WithAltScreen())
 	result, err := p.Run()
+	signal.Stop(sigs)
+	close(sigs)
 	if err != nil {
+		if rec != nil {
+			rec.f.Close()
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+	final, ok := result.(model)
+	if !ok {
+		return 0
+	}
+	if rec != nil {
+		if err := rec.close(final); err != nil {
+			fmt.Fprintf(os.Stderr, "csm: --record: %v\n", err)
+		}
+	}
+	if err := saveState(stateFile, final.state()); err != nil {
+		fmt.Fprintf(os.Stderr, "csm: saving state: %v\n", err)
+	}
+	if final.selectedID == "" {
+		if opts.printID {
+			return 1
+		}
+		return 0
+	}
+	s, ok := final.find(final.selectedID)
+	if !ok {
+		s.PaneID = final.selectedID
 	}
-	if final, ok := result.(model); ok && final.selectedID != "" {
-		exec.Command("tmux", "switch-client", "-t", final.selectedID).Run()
+	return finishSelection(s, final.then, opts)
+}
+
+// finishSelection acts on the chosen session: prints its pane ID for
+// --replay and --print-id, or for --dry-run the command it would run;
+// otherwise runs on_select if set, or switches to it, then does then to
+// its pane. A failure is reported and exits 1.
+func finishSelection(s ClaudeSession, then followUp, opts tuiOptions) int {
+	switch {
+	case opts.replay || opts.printID:
+		fmt.Println(s.PaneID)
+		return 0
+	case opts.dryRun:
+		fmt.Println(selectionCommand(s))
+		for _, args := range followUpCmds(s.PaneID, then) {
+			bin, argv := tmuxArgv(args)
+			fmt.Println(shellJoin(append([]string{bin}, argv...)))
+		}
+		return 0
+	}
+	if cfg.OnSelect != "" {
+		cmd := exec.Command("sh", "-c", expandTemplate(cfg.OnSelect, s))
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "csm: on_select: %v\n", err)
+			return 1
+		}
+	} else if err := backend.Switch(sysRunner, s.PaneID); err != nil {
+		fmt.Fprintf(os.Stderr, "csm: %v\n", err)
+		return 1
+	}
+	if err := runFollowUp(sysRunner, s.PaneID, then); err != nil {
+		fmt.Fprintf(os.Stderr, "csm: %s: %v\n", s.PaneID, err)
+		return 1
+	}
+	return 0
+}
+
+// selectionCommand is the shell command finishSelection runs for s.
+func selectionCommand(s ClaudeSession) string {
+	if cfg.OnSelect != "" {
+		return expandTemplate(cfg.OnSelect, s)
 	}
+	return shellJoin(backend.SwitchCommand(s.PaneID))
 }
@@ -0,0 +1,685 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testTime is the clock tests run at.
+var testTime = time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// testSession returns a session in tmux session name, keyed like detect
+// keys it.
+func testSession(name string, status int) ClaudeSession {
+	id := name + ":0.0"
+	return ClaudeSession{PaneID: id, Key: name + "/%" + name, SessionName: name, Title: name + " task",
+		Path: "~/" + name, Status: status}
+}
+
+// update feeds msgs to m in order and returns the result.
+func update(m model, msgs ...tea.Msg) model {
+	for _, msg := range msgs {
+		next, _ := m.Update(msg)
+		m = next.(model)
+	}
+	return m
+}
+
+// scanned is the sessionsMsg of a scan at testTime that found sessions.
+func scanned(sessions ...ClaudeSession) sessionsMsg {
+	return scannedAt(testTime, sessions...)
+}
+
+// scannedAt is scanned for a scan at t.
+func scannedAt(t time.Time, sessions ...ClaudeSession) sessionsMsg {
+	return sessionsMsg{sessions: sessions, stats: scanStats{listed: true, sessions: len(sessions)}, at: t}
+}
+
+// press is the message for typing key, as tea names it.
+func press(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "shift+tab":
+		return tea.KeyMsg{Type: tea.KeyShiftTab}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestCursorFollowOnGrowth(t *testing.T) {
+	b, c := testSession("b", StatusIdle), testSession("c", StatusIdle)
+	a := testSession("a", StatusIdle) // sorts above b and c
+	tests := []struct {
+		follow string
+		want   string // PaneID under the cursor after a appears
+	}{
+		{"id", "c:0.0"},
+		{"row", "b:0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.follow, func(t *testing.T) {
+			setConfig(t, func(c *Config) { c.CursorFollow = tt.follow })
+			m := update(newModel(), scanned(b, c), press("j"))
+			if got := m.sessions[m.cursor].PaneID; got != "c:0.0" {
+				t.Fatalf("before: cursor on %s, want c:0.0", got)
+			}
+			m = update(m, scanned(a, b, c))
+			if got := m.sessions[m.cursor].PaneID; got != tt.want {
+				t.Errorf("cursor on %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorClampsWhenListShrinks(t *testing.T) {
+	setConfig(t, nil)
+	a, b, c := testSession("a", StatusIdle), testSession("b", StatusIdle), testSession("c", StatusIdle)
+	m := update(newModel(), scanned(a, b, c), press("j"), press("j"), scanned(a))
+	if m.cursor != 0 {
+		t.Errorf("cursor = %d, want 0", m.cursor)
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct{ title, want string }{
+		{"✳ fix the tests", "fix the tests"},
+		{"⠂ fix the tests", "fix the tests"},
+		{"⠐⠂ fix the tests", "fix the tests"},  // several spinner runes
+		{"✳ ⠂ fix the tests", "fix the tests"}, // ✳ then a spinner
+		{"⠂  ✳\tfix the tests  ", "fix the tests"},
+		{"✳ fix ✳ the tests", "fix ✳ the tests"}, // only the leading run
+		{"✳", ""},
+		{"bash", "bash"}, // not a Claude title: untouched
+		{"✳ fix \xff", "fix �"},
+	}
+	for _, tt := range tests {
+		if got := cleanTitle(tt.title); got != tt.want {
+			t.Errorf("cleanTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestClaudeTitleMarkers(t *testing.T) {
+	tests := []struct {
+		title           string
+		claude, braille bool
+	}{
+		{"✳ task", true, false},
+		{"⠂ task", true, true},
+		{"task ✳", false, false},
+		{"", false, false},
+		{"\xe2\x9c", false, false}, // a truncated ✳
+	}
+	for _, tt := range tests {
+		if got := isClaudeTitle(tt.title); got != tt.claude {
+			t.Errorf("isClaudeTitle(%q) = %v, want %v", tt.title, got, tt.claude)
+		}
+		if got := isBraillePrefix(tt.title); got != tt.braille {
+			t.Errorf("isBraillePrefix(%q) = %v, want %v", tt.title, got, tt.braille)
+		}
+	}
+}
+
+// BenchmarkDetect scans 50 panes, half idle, with captures uncapped (one
+// per idle pane at once, as before capture_concurrency) and capped at the
+// default of 8. fake captures take 1ms; exec ones run a cat process.
+func BenchmarkDetect(b *testing.B) {
+	file := filepath.Join(b.TempDir(), "capture.txt")
+	if err := os.WriteFile(file, []byte(fakePanes(1).captures["s00:0.0"]), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	for _, runner := range []string{"fake", "exec"} {
+		for _, concurrency := range []int{50, 8} {
+			b.Run(fmt.Sprintf("%s/concurrency=%d", runner, concurrency), func(b *testing.B) {
+				c := defaultConfig()
+				c.CaptureConcurrency = concurrency
+				c.LoadThreshold = 0
+				saved := cfg
+				cfg = c
+				defer func() { cfg = saved }()
+				f := fakePanes(50)
+				if runner == "exec" {
+					f.catFile = file
+				} else {
+					f.delay = time.Millisecond
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if sessions, _ := detect(f); len(sessions) != 50 {
+						b.Fatalf("detected %d sessions, want 50", len(sessions))
+					}
+				}
+				b.ReportMetric(float64(f.peak), "peak-captures")
+			})
+		}
+	}
+}
+
+func TestCaptureConcurrencyCap(t *testing.T) {
+	for _, limit := range []int{1, 3, 8} {
+		setConfig(t, func(c *Config) { c.CaptureConcurrency, c.LoadThreshold = limit, 0 })
+		f := fakePanes(20)
+		f.delay = 2 * time.Millisecond
+		sessions, _ := detect(f)
+		if f.peak > limit {
+			t.Errorf("capture_concurrency %d: %d captures ran at once", limit, f.peak)
+		}
+		if len(sessions) != 20 {
+			t.Fatalf("capture_concurrency %d: detected %d sessions, want 20", limit, len(sessions))
+		}
+		for i, s := range sessions {
+			if want := fmt.Sprintf("s%02d:0.0", i); s.PaneID != want {
+				t.Errorf("capture_concurrency %d: session %d is %s, want %s", limit, i, s.PaneID, want)
+			}
+		}
+	}
+}
+
+func TestWrapNavigation(t *testing.T) {
+	tests := []struct {
+		wrap bool
+		keys []string
+		want int
+	}{
+		{true, []string{"k"}, 2},
+		{true, []string{"j", "j", "j"}, 0},
+		{false, []string{"k"}, 0},
+		{false, []string{"j", "j", "j", "j"}, 2},
+		{false, []string{"j", "j", "j", "k"}, 1},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.WrapNavigation = tt.wrap })
+		m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusIdle), testSession("c", StatusIdle)))
+		for _, k := range tt.keys {
+			m = update(m, press(k))
+		}
+		if m.cursor != tt.want {
+			t.Errorf("wrap %v, keys %v: cursor %d, want %d", tt.wrap, tt.keys, m.cursor, tt.want)
+		}
+	}
+}
+
+func TestMoveCursorEmptyList(t *testing.T) {
+	for _, wrap := range []bool{true, false} {
+		if got := moveCursor(0, 1, 0, wrap); got != 0 {
+			t.Errorf("moveCursor on no rows, wrap %v = %d", wrap, got)
+		}
+	}
+}
+
+func TestExtractIndicator(t *testing.T) {
+	capture := "❯ fix the tests\n\n⏺ Done.\n\n  ↑ 1.2k tokens · 3 turns\n❯ \n  ↑ 4.8k tokens · 5 turns\n"
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"", ""},                             // off by default
+		{`(\d+(?:\.\d+)?k?) tokens`, "4.8k"}, // the last match, first group
+		{`\d+ turns`, "5 turns"},             // no group: the whole match
+		{`queued: (\d+)`, ""},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.TurnPattern = tt.pattern })
+		if got := extractIndicator(capture, cfg.turnRe); got != tt.want {
+			t.Errorf("turn_pattern %q: got %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestTurnsOnWorkingPanesNeedDeepStatus(t *testing.T) {
+	for _, deep := range []bool{false, true} {
+		setConfig(t, func(c *Config) { c.TurnPattern, c.DeepStatus = `(\d+) turns`, deep })
+		f := &fakeRunner{panes: paneLine("w:0.0", "⠂ task"), captures: map[string]string{"w:0.0": "✻ Working… 7 turns\n"}}
+		sessions, _ := detect(f)
+		want := ""
+		if deep {
+			want = "7"
+		}
+		if len(sessions) != 1 || sessions[0].Turns != want {
+			t.Errorf("deep_status %v: sessions %+v, want Turns %q", deep, sessions, want)
+		}
+	}
+}
+
+func TestTurnPatternValidated(t *testing.T) {
+	c := defaultConfig()
+	c.TurnPattern = "(unclosed"
+	if err := c.validate(); err == nil {
+		t.Error("validate accepted a malformed turn_pattern")
+	}
+}
+
+func TestMissingPathSkipsGit(t *testing.T) {
+	setConfig(t, nil)
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("ref: refs/heads/feature\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// gone was deleted, but its parent repository is still there.
+	gone := filepath.Join(repo, "gone")
+	f := &fakeRunner{
+		panes:    paneLineAt("a:0.0", repo, "✳ task") + paneLineAt("b:0.0", gone, "✳ task"),
+		captures: map[string]string{"a:0.0": "❯ \n", "b:0.0": "❯ \n"},
+	}
+	sessions, _ := detect(f)
+	if len(sessions) != 2 {
+		t.Fatalf("detected %d sessions, want 2", len(sessions))
+	}
+	if a := sessions[0]; a.PathMissing || a.GitBranch != "feature" {
+		t.Errorf("a: PathMissing %v, branch %q; want false, feature", a.PathMissing, a.GitBranch)
+	}
+	if b := sessions[1]; !b.PathMissing || b.GitBranch != "" {
+		t.Errorf("b: PathMissing %v, branch %q; want true and no branch", b.PathMissing, b.GitBranch)
+	}
+	if err := openPathGuard(sessions[1]); err == nil {
+		t.Error("openPathGuard allowed a missing path")
+	}
+}
+
+func TestPaneClaudeTitle(t *testing.T) {
+	tests := []struct {
+		pane, window string
+		useWindow    bool
+		want         string
+		ok           bool
+	}{
+		{"✳ task", "zsh", false, "✳ task", true},
+		{"✳ task", "⠂ other", true, "✳ task", true}, // the pane title wins
+		{"host.local", "✳ task", false, "", false},  // window names are opt-in
+		{"host.local", "✳ task", true, "✳ task", true},
+		{"", "⠂ task", true, "⠂ task", true},
+		{"host.local", "zsh", true, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := paneClaudeTitle(tt.pane, tt.window, tt.useWindow)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("paneClaudeTitle(%q, %q, %v) = %q, %v; want %q, %v", tt.pane, tt.window, tt.useWindow, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDetectByWindowName(t *testing.T) {
+	// A pane titled by the host, with Claude's title only on its window.
+	line := strings.Replace(paneLine("a:0.0", "host.local"), "\tclaude\tclaude\t", "\tclaude\t⠂ task\t", 1)
+	for _, match := range []bool{false, true} {
+		setConfig(t, func(c *Config) { c.MatchWindowName = match })
+		sessions, _ := detect(&fakeRunner{panes: line})
+		if !match {
+			if len(sessions) != 0 {
+				t.Errorf("match_window_name off: detected %d sessions", len(sessions))
+			}
+			continue
+		}
+		if len(sessions) != 1 || sessions[0].Title != "task" || sessions[0].Status != StatusWorking {
+			t.Fatalf("match_window_name on: detected %+v", sessions)
+		}
+		if got := sessions[0].Why.source; got != "window name" {
+			t.Errorf("detected by %q, want window name", got)
+		}
+	}
+}
+
+func TestPassthroughCommands(t *testing.T) {
+	tests := []struct {
+		cmd         string
+		passthrough []string // nil: the defaults
+		kept        bool
+	}{
+		{"claude", nil, true},
+		{"node", nil, true},
+		{"zsh", nil, false}, // back at a local shell: Claude exited
+		{"ssh", nil, true},  // Claude runs on the other end
+		{"docker", nil, true},
+		{"mosh-client", nil, true},
+		{"zsh", []string{"zsh"}, true}, // a shell listed as passthrough is trusted
+		{"ssh", []string{}, true},      // not a shell either way
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) {
+			if tt.passthrough != nil {
+				c.PassthroughCommands = tt.passthrough
+			}
+		})
+		line := withCommand(paneLine("a:0.0", "⠂ task"), tt.cmd)
+		sessions, stats := detect(&fakeRunner{panes: line})
+		if kept := len(sessions) == 1; kept != tt.kept {
+			t.Errorf("%s with passthrough %v: kept %v, want %v (exited %d)", tt.cmd, tt.passthrough, kept, tt.kept, stats.exited)
+		}
+	}
+}
+
+func TestSequentialMatchesParallel(t *testing.T) {
+	f := fakePanes(20)
+	f.captures["s03:0.0"] = "❯ fix it\n\n Do you want to make this edit to main.go?\n ❯ 1. Yes\n Esc to cancel\n"
+	f.captures["s05:0.0"] = "⏺ earlier\n"
+	delete(f.captures, "s07:0.0") // capture fails
+	var results [2][]ClaudeSession
+	for i, sequential := range []bool{false, true} {
+		setConfig(t, func(c *Config) { c.LoadThreshold = 0 })
+		cfg.sequential = sequential
+		results[i], _ = detect(f)
+	}
+	if len(results[0]) != 19 {
+		t.Fatalf("detected %d sessions, want 19", len(results[0]))
+	}
+	if !reflect.DeepEqual(results[0], results[1]) {
+		t.Errorf("sequential and parallel scans differ:\n%+v\n%+v", results[0], results[1])
+	}
+}
+
+func TestShortenPathFor(t *testing.T) {
+	tests := []struct {
+		path, home string
+		want       string
+	}{
+		{"/home/bob", "/home/bob", "~"},
+		{"/home/bob/src/api", "/home/bob", "~/src/api"},
+		{"/home/bob/src", "/home/bob/", "~/src"}, // trailing slash on home
+		{"/home/bob2/src", "/home/bob", "/home/bob2/src"},
+		{"/home/bobby", "/home/bob", "/home/bobby"},
+		{"/etc", "/", "/etc"}, // a home of / would make every path ~/...
+		{"/", "/", "/"},
+		{"/home/bob/src", "", "/home/bob/src"},
+		{"relative/path", "/home/bob", "relative/path"},
+		{"/Users/Bob/src", "/Users/Bob", "~/src"},
+	}
+	for _, tt := range tests {
+		if got := shortenPathFor(tt.path, tt.home); got != tt.want {
+			t.Errorf("shortenPathFor(%q, %q) = %q, want %q", tt.path, tt.home, got, tt.want)
+		}
+	}
+}
+
+func TestShortenPathUsesHome(t *testing.T) {
+	t.Setenv("HOME", "/home/carol")
+	if got := shortenPath("/home/carol/notes"); got != "~/notes" {
+		t.Errorf("shortenPath = %q, want ~/notes", got)
+	}
+}
+
+func TestScansCoalesce(t *testing.T) {
+	setConfig(t, nil)
+	m := newModel() // Init's scan is in flight
+	steps := []struct {
+		msg      tea.Msg
+		cmd      bool // a scan was started, as far as requestScan goes
+		scanning bool
+		rescan   bool
+	}{
+		{refreshMsg{}, false, true, true}, // queued behind the scan in flight
+		{refreshMsg{}, false, true, true}, // and coalesced with the first
+		{scanned(), true, true, false},    // the scan ends; the queued one starts
+		{scanned(), true, false, false},
+		{refreshMsg{}, true, true, false},
+	}
+	for i, st := range steps {
+		next, cmd := m.Update(st.msg)
+		m = next.(model)
+		if m.scanning != st.scanning || m.rescan != st.rescan {
+			t.Errorf("step %d: scanning %v, rescan %v; want %v, %v", i, m.scanning, m.rescan, st.scanning, st.rescan)
+		}
+		if _, ok := st.msg.(refreshMsg); ok && (cmd != nil) != st.cmd {
+			t.Errorf("step %d: started a scan %v, want %v", i, cmd != nil, st.cmd)
+		}
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	setConfig(t, func(c *Config) { c.CommandTimeout = "50ms" })
+	start := time.Now()
+	if _, err := (execRunner{}).Output("sleep", "5"); err == nil {
+		t.Error("a stuck command did not fail")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("a stuck command held the scan for %v", d)
+	}
+	for _, bad := range []string{"soon", "-1s"} {
+		c := defaultConfig()
+		c.CommandTimeout = bad
+		if err := c.validate(); err == nil {
+			t.Errorf("validate accepted command_timeout %q", bad)
+		}
+	}
+}
+
+func TestKeyScanRateLimit(t *testing.T) {
+	setConfig(t, func(c *Config) { c.ScanOnKey = true })
+	m := update(newModel(), scanned(testSession("a", StatusIdle)))
+	tests := []struct {
+		after time.Duration
+		scan  bool
+	}{
+		{0, true},
+		{100 * time.Millisecond, false}, // a held key
+		{499 * time.Millisecond, false},
+		{500 * time.Millisecond, true},
+		{600 * time.Millisecond, false},
+		{2 * time.Second, true},
+	}
+	for _, tt := range tests {
+		m.scanning = false // the previous scan has finished
+		if got := m.keyScan(testTime.Add(tt.after)) != nil; got != tt.scan {
+			t.Errorf("key at +%v: scanned %v, want %v", tt.after, got, tt.scan)
+		}
+	}
+}
+
+func TestKeyScanOffByDefault(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle)))
+	_, cmd := m.Update(keyAtMsg{KeyMsg: press("j"), at: testTime.Add(time.Minute)})
+	if cmd != nil {
+		t.Error("a key press scanned with scan_on_key off")
+	}
+	setConfig(t, func(c *Config) { c.ScanOnKey = true })
+	if _, cmd = m.Update(keyAtMsg{KeyMsg: press("j"), at: testTime.Add(time.Minute)}); cmd == nil {
+		t.Error("a key press did not scan with scan_on_key on")
+	}
+}
+
+func TestMotionDetect(t *testing.T) {
+	idle := "⏺ Done.\n\n❯ \n"
+	tests := []struct {
+		name    string
+		motion  bool
+		later   string // second capture; "" for unchanged
+		status  int
+		moved   bool
+		capture int // captures made
+	}{
+		{"unchanged", true, "", StatusIdle, false, 2},
+		{"changed", true, "⏺ Done.\n\n⏺ Reading main.go\n\n❯ \n", StatusWorking, true, 2},
+		{"off", false, "⏺ Reading main.go\n", StatusIdle, false, 1},
+	}
+	for _, tt := range tests {
+		setConfig(t, nil)
+		cfg.motionDetect = tt.motion
+		f := &fakeRunner{panes: paneLine("a:0.0", "✳ task"), captures: map[string]string{"a:0.0": idle}}
+		if tt.later != "" {
+			f.later = map[string]string{"a:0.0": tt.later}
+		}
+		sessions, _ := detect(f)
+		if len(sessions) != 1 {
+			t.Fatalf("%s: detected %d sessions", tt.name, len(sessions))
+		}
+		if s := sessions[0]; s.Status != tt.status || s.Why.moved != tt.moved {
+			t.Errorf("%s: status %d, moved %v; want %d, %v", tt.name, s.Status, s.Why.moved, tt.status, tt.moved)
+		}
+		if got := len(f.ran("tmux capture-pane")); got != tt.capture {
+			t.Errorf("%s: captured %d times, want %d", tt.name, got, tt.capture)
+		}
+	}
+}
+
+func TestMotionDetectSkipsWaiting(t *testing.T) {
+	setConfig(t, nil)
+	cfg.motionDetect = true
+	f := &fakeRunner{
+		panes:    paneLine("a:0.0", "✳ task") + paneLine("b:0.0", "⠂ task"),
+		captures: map[string]string{"a:0.0": "❯ go\n\n Do you want to proceed?\n ❯ 1. Yes\n Esc to cancel\n", "b:0.0": ""},
+	}
+	detect(f)
+	if got := len(f.ran("tmux capture-pane")); got != 1 {
+		t.Errorf("captured %d times; want one capture, of the waiting pane, and none again", got)
+	}
+}
+
+func TestGarbledTitles(t *testing.T) {
+	titles := []string{
+		"\xff task",
+		"\x80\x80\x80",
+		"\xef\xbf\xbd task", // a real U+FFFD is no marker either
+		"\xe2\xa0",          // a truncated spinner
+		"\xe2\x9c\xb3\xff\xfe task",
+		"✳ \xc3\x28 half a rune",
+		"⠂\xed\xa0\x80", // an encoded surrogate
+	}
+	for _, title := range titles {
+		got := cleanTitle(title)
+		if !utf8.ValidString(got) {
+			t.Errorf("cleanTitle(%q) = %q, not valid UTF-8", title, got)
+		}
+		claude := strings.HasPrefix(title, "✳") || strings.HasPrefix(title, "⠂")
+		if isClaudeTitle(title) != claude {
+			t.Errorf("isClaudeTitle(%q) = %v, want %v", title, !claude, claude)
+		}
+		if claude && (strings.HasPrefix(got, "✳") || strings.HasPrefix(got, "⠂")) {
+			t.Errorf("cleanTitle(%q) = %q kept its marker", title, got)
+		}
+	}
+
+	f := &fakeRunner{panes: paneLine("a:0.0", "\xff\xfe task") + paneLine("b:0.0", "\xe2\x9c"),
+		captures: map[string]string{"a:0.0": "❯ \n", "b:0.0": "❯ \n"}}
+	setConfig(t, nil)
+	if sessions, stats := detect(f); len(sessions) != 0 || stats.titled != 0 {
+		t.Errorf("garbled titles detected as %d sessions, %d titled", len(sessions), stats.titled)
+	}
+}
+
+func TestPaneKey(t *testing.T) {
+	tests := []struct{ sess, paneID, uid, want string }{
+		{"work", "work:2.1", "%3", "work/%3"},
+		{"work", "work:2.1", " %3\n", "work/%3"},
+		{"work", "work:2.1", "", "work:2.1"}, // no #{pane_id}: fall back to PaneID
+	}
+	for _, tt := range tests {
+		if got := paneKey(tt.sess, tt.paneID, tt.uid); got != tt.want {
+			t.Errorf("paneKey(%q, %q, %q) = %q, want %q", tt.sess, tt.paneID, tt.uid, got, tt.want)
+		}
+	}
+
+	setConfig(t, nil)
+	old := strings.Join(strings.Split(paneLine("b:0.0", "✳ task"), "\t")[:7], "\t") + "\n"
+	f := &fakeRunner{
+		panes:    paneLine("a:0.0", "✳ task") + old,
+		captures: map[string]string{"a:0.0": "❯ \n", "b:0.0": "❯ \n"},
+	}
+	sessions, _ := detect(f)
+	if len(sessions) != 2 || sessions[0].Key != "a/%a" || sessions[1].Key != "b:0.0" {
+		t.Errorf("detected keys %v", sessions)
+	}
+}
+
+func TestStatusSinceSurvivesRenumbering(t *testing.T) {
+	setConfig(t, nil)
+	pane := func(id string, status int) ClaudeSession {
+		s := testSession("work", status)
+		s.PaneID, s.Key = id, "work/%3"
+		return s
+	}
+	m := update(newModel(),
+		scannedAt(testTime, testSession("a", StatusIdle), pane("work:1.0", StatusWaiting)),
+		press("j"),
+		// The window moves from 1 to 2.
+		scannedAt(testTime.Add(30*time.Second), testSession("a", StatusIdle), pane("work:2.0", StatusWaiting)))
+	if since := m.track.since["work/%3"]; !since.Equal(testTime) {
+		t.Errorf("status since %v after renumbering, want %v", since, testTime)
+	}
+	if m.track.log.n != 0 {
+		t.Errorf("renumbering logged %d transitions", m.track.log.n)
+	}
+	if s := m.sessions[m.cursor]; s.PaneID != "work:2.0" {
+		t.Errorf("cursor on %s, want it to follow the renumbered pane", s.PaneID)
+	}
+}
+
+func TestPaneBackground(t *testing.T) {
+	tests := []struct {
+		windowActive, attached string
+		want                   bool
+	}{
+		{"1", "1", false},
+		{"1", "2", false}, // two clients on the session
+		{"0", "1", true},  // another window is current
+		{"1", "0", true},  // nobody attached
+		{"0", "0", true},
+		{"1\n", " 1", false},
+	}
+	for _, tt := range tests {
+		if got := paneBackground(tt.windowActive, tt.attached); got != tt.want {
+			t.Errorf("paneBackground(%q, %q) = %v, want %v", tt.windowActive, tt.attached, got, tt.want)
+		}
+	}
+}
+
+func TestDetectBackground(t *testing.T) {
+	setConfig(t, nil)
+	visible := paneLine("a:0.0", "✳ task")
+	lines := map[string]string{
+		"a:0.0": visible,
+		"b:1.0": replaceField(paneLine("b:1.0", "✳ task"), 8, "0"),
+		"c:0.0": replaceField(paneLine("c:0.0", "✳ task"), 9, "0\n"),
+		"d:0.0": strings.Join(strings.Split(paneLine("d:0.0", "✳ task"), "\t")[:8], "\t") + "\n", // an older fixture
+	}
+	want := map[string]bool{"a:0.0": false, "b:1.0": true, "c:0.0": true, "d:0.0": false}
+	f := &fakeRunner{captures: map[string]string{}}
+	for id, line := range lines {
+		f.panes += line
+		f.captures[id] = "❯ \n"
+	}
+	sessions, _ := detect(f)
+	if len(sessions) != len(want) {
+		t.Fatalf("detected %d sessions, want %d", len(sessions), len(want))
+	}
+	for _, s := range sessions {
+		if s.Background != want[s.PaneID] {
+			t.Errorf("%s: background %v, want %v", s.PaneID, s.Background, want[s.PaneID])
+		}
+	}
+
+	bg := testSession("b", StatusIdle)
+	bg.Background = true
+	view := update(newModel(), scanned(testSession("a", StatusIdle), bg)).View()
+	var marked []string
+	for _, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, "task") && strings.Contains(line, " bg") {
+			marked = append(marked, line)
+		}
+	}
+	if len(marked) != 1 || !strings.Contains(marked[0], "b task") {
+		t.Errorf("rows marked bg: %q, want only b's", marked)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// metrics holds the gauges exposed by watch --metrics-addr in the
+// Prometheus text exposition format.
+type metrics struct {
+	mu     sync.Mutex
+	counts map[int]int
+	total  int
+}
+
+// update replaces the gauges with the counts from one scan.
+func (m *metrics) update(sessions []ClaudeSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts = statusCounts(sessions)
+	m.total = len(sessions)
+}
+
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	gauge := func(name, help string, v int) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, v)
+	}
+	gauge("csm_sessions_total", "Detected Claude sessions.", m.total)
+	gauge("csm_sessions_waiting", "Claude sessions waiting for input.", m.counts[StatusWaiting])
+	gauge("csm_sessions_working", "Claude sessions working.", m.counts[StatusWorking])
+	gauge("csm_sessions_idle", "Claude sessions idle at the prompt.", m.counts[StatusIdle])
+}
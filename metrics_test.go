@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gauges := &metrics{}
+	gauges.update([]ClaudeSession{
+		testSession("a", StatusWaiting),
+		testSession("b", StatusWorking),
+		testSession("c", StatusWorking),
+		testSession("d", StatusIdle),
+	})
+	srv := httptest.NewServer(gauges)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE csm_sessions_total gauge\ncsm_sessions_total 4\n",
+		"# TYPE csm_sessions_waiting gauge\ncsm_sessions_waiting 1\n",
+		"# TYPE csm_sessions_working gauge\ncsm_sessions_working 2\n",
+		"# TYPE csm_sessions_idle gauge\ncsm_sessions_idle 1\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics lack %q in:\n%s", want, body)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		if !strings.HasPrefix(line, "# HELP ") && !strings.HasPrefix(line, "# TYPE ") && len(strings.Fields(line)) != 2 {
+			t.Errorf("malformed sample line %q", line)
+		}
+	}
+}
+
+func TestMetricsBeforeFirstScan(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&metrics{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "csm_sessions_total 0\n") {
+		t.Errorf("metrics before a scan:\n%s", rec.Body)
+	}
+}
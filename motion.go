@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Motion detection (--motion-detect)
+//
+// Some setups hide the title spinner. With --motion-detect, a ✳-titled pane
+// that does not look Waiting is captured a second time motionInterval
+// later; if its content changed, Claude is taken to be working.
+
+// motionInterval is the gap between the two captures.
+const motionInterval = 300 * time.Millisecond
+
+// capturePane returns the last capture_lines of pane.
+func capturePane(r CommandRunner, pane string) (string, error) {
+	out, err := tolerantOutput(r, "tmux", "capture-pane", "-t", pane, "-p", "-S", fmt.Sprintf("-%d", cfg.CaptureLines))
+	return string(out), err
+}
+
+// contentMoved reports whether a fresh capture of pane differs from
+// before. A failed capture counts as no motion.
+func contentMoved(before, after string, err error) bool {
+	return err == nil && after != before
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Notifications
+
+// notifier decides which sessions to alert about when they start waiting,
+// rate-limited per pane and skipping muted paths.
+type notifier struct {
+	last     map[string]time.Time // Key → last alert
+	debounce time.Duration
+}
+
+func newNotifier(debounce time.Duration) notifier {
+	return notifier{last: map[string]time.Time{}, debounce: debounce}
+}
+
+// due returns the sessions that have just entered Waiting and should be
+// alerted about now, recording the alert time for each.
+func (n notifier) due(changed []transition, sessions []ClaudeSession, muted map[string]bool, now time.Time) []ClaudeSession {
+	byID := make(map[string]ClaudeSession, len(sessions))
+	for _, s := range sessions {
+		byID[s.Key] = s
+	}
+	var out []ClaudeSession
+	for _, t := range changed {
+		if t.to != StatusWaiting {
+			continue
+		}
+		s, ok := byID[t.key]
+		if !ok || muted[s.Path] {
+			continue
+		}
+		if last, ok := n.last[t.key]; ok && now.Sub(last) < n.debounce {
+			continue
+		}
+		n.last[t.key] = now
+		out = append(out, s)
+	}
+	return out
+}
+
+// tuiOutput is where the picker draws, and so where the bell rings: stderr
+// under --print-id, which keeps stdout for the pane ID.
+var tuiOutput io.Writer = os.Stdout
+
+// alert rings the bell and/or posts a desktop notification per config.
+func alert(sessions []ClaudeSession) tea.Cmd {
+	if len(sessions) == 0 || (!cfg.Bell && !cfg.DesktopNotify) {
+		return nil
+	}
+	return func() tea.Msg {
+		if cfg.Bell {
+			io.WriteString(tuiOutput, "\a")
+		}
+		if cfg.DesktopNotify {
+			for _, s := range sessions {
+				desktopNotify("Claude is waiting", s.SessionName+": "+s.Title)
+			}
+		}
+		return nil
+	}
+}
+
+// onWaiting runs the on_waiting hook for each session in the background.
+// Hooks share the alert debounce; failures go to the debug log.
+func onWaiting(sessions []ClaudeSession) tea.Cmd {
+	if cfg.OnWaiting == "" {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, s := range sessions {
+		cmds = append(cmds, func() tea.Msg {
+			out, err := exec.Command("sh", "-c", expandTemplate(cfg.OnWaiting, s)).CombinedOutput()
+			if err != nil {
+				debugLog.Printf("on_waiting %s: %v: %s", s.PaneID, err, bytes.TrimSpace(out))
+			}
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// desktopNotify posts a notification with notify-send or osascript.
+// Failures are ignored; notifications are best effort.
+func desktopNotify(title, body string) {
+	switch runtime.GOOS {
+	case "darwin":
+		exec.Command("osascript", "-e",
+			"display notification "+appleQuote(body)+" with title "+appleQuote(title)).Run()
+	default:
+		exec.Command("notify-send", title, body).Run()
+	}
+}
+
+// appleQuote quotes s as an AppleScript string literal.
+func appleQuote(s string) string {
+	out := []rune{'"'}
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			out = append(out, '\\')
+		}
+		out = append(out, r)
+	}
+	return string(append(out, '"'))
+}
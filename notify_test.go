@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNotifierDue(t *testing.T) {
+	a, b := testSession("a", StatusWaiting), testSession("b", StatusWaiting)
+	sessions := []ClaudeSession{a, b}
+	started := func(s ClaudeSession) transition {
+		return transition{key: s.Key, paneID: s.PaneID, from: StatusWorking, to: StatusWaiting}
+	}
+	tests := []struct {
+		name    string
+		changed []transition
+		muted   map[string]bool
+		want    string
+	}{
+		{"both", []transition{started(a), started(b)}, nil, "a b"},
+		{"muted", []transition{started(a), started(b)}, map[string]bool{b.Path: true}, "a"},
+		{"all muted", []transition{started(a)}, map[string]bool{a.Path: true}, ""},
+		{"not to waiting", []transition{{key: a.Key, from: StatusWaiting, to: StatusIdle}}, nil, ""},
+		{"gone", []transition{{key: "x/%x", to: StatusWaiting}}, nil, ""},
+	}
+	for _, tt := range tests {
+		n := newNotifier(time.Minute)
+		var names []string
+		for _, s := range n.due(tt.changed, sessions, tt.muted, testTime) {
+			names = append(names, s.SessionName)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("%s: alerted %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNotifierDebounce(t *testing.T) {
+	a := testSession("a", StatusWaiting)
+	changed := []transition{{key: a.Key, from: StatusWorking, to: StatusWaiting}}
+	n := newNotifier(time.Minute)
+	for _, tt := range []struct {
+		after time.Duration
+		want  int
+	}{{0, 1}, {30 * time.Second, 0}, {61 * time.Second, 1}} {
+		if got := len(n.due(changed, []ClaudeSession{a}, nil, testTime.Add(tt.after))); got != tt.want {
+			t.Errorf("at +%v: %d alerts, want %d", tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestAlertRingsOnTUIOutput(t *testing.T) {
+	setConfig(t, func(c *Config) { c.Bell = true })
+	var out strings.Builder
+	saved := tuiOutput
+	tuiOutput = &out
+	t.Cleanup(func() { tuiOutput = saved })
+
+	if cmd := alert(nil); cmd != nil {
+		t.Error("alert with no sessions returned a command")
+	}
+	alert([]ClaudeSession{testSession("a", StatusWaiting)})()
+	if out.String() != "\a" {
+		t.Errorf("bell wrote %q", out.String())
+	}
+}
+
+// drain runs cmd and every command batched in it, dropping their messages.
+func drain(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		for _, c := range batch {
+			drain(c)
+		}
+	}
+}
+
+func TestOnWaitingRunsOncePerTransition(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "hook.log")
+	setConfig(t, func(c *Config) {
+		c.OnWaiting = "printf '%s\\n' {name} >> " + log
+		c.NotifyDebounce = "1m"
+	})
+	useRunner(t, &fakeRunner{})
+	working, waiting := testSession("a", StatusWorking), testSession("a", StatusWaiting)
+	m := newModel()
+	for i, s := range []ClaudeSession{working, waiting, waiting, working, waiting, working, waiting} {
+		// Scans 20s apart: waits start at 20s, 80s and 120s, and the
+		// last is within a minute of the one before.
+		next, cmd := m.Update(scannedAt(testTime.Add(time.Duration(i)*20*time.Second), s))
+		m = next.(model)
+		drain(cmd)
+	}
+	data, _ := os.ReadFile(log)
+	if got := string(data); got != "a\na\n" {
+		t.Errorf("on_waiting ran for %q, want twice for a", got)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// File manager (o)
+
+// opener returns the command that opens a directory in the file manager
+// on goos.
+func opener(goos string) string {
+	if goos == "darwin" {
+		return "open"
+	}
+	return "xdg-open"
+}
+
+// openPathGuard reports why s's path cannot be opened.
+func openPathGuard(s ClaudeSession) error {
+	if s.PathMissing {
+		return fmt.Errorf("%s no longer exists", s.Path)
+	}
+	return nil
+}
+
+// openPath opens dir in the file manager without waiting for it, so csm
+// keeps running.
+func openPath(dir string) tea.Cmd {
+	return func() tea.Msg {
+		name := opener(runtime.GOOS)
+		if _, err := exec.LookPath(name); err != nil {
+			return actionMsg{err: fmt.Errorf("%s not found; cannot open a file manager", name)}
+		}
+		cmd := exec.Command(name, expandPath(dir))
+		if err := cmd.Start(); err != nil {
+			return actionMsg{err: fmt.Errorf("%s: %w", name, err)}
+		}
+		go cmd.Wait()
+		return actionMsg{notice: "Opened " + dir}
+	}
+}
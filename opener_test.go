@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpener(t *testing.T) {
+	tests := []struct{ goos, want string }{
+		{"darwin", "open"},
+		{"linux", "xdg-open"},
+		{"freebsd", "xdg-open"},
+	}
+	for _, tt := range tests {
+		if got := opener(tt.goos); got != tt.want {
+			t.Errorf("opener(%q) = %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestOpenPath(t *testing.T) {
+	bin, opened := t.TempDir(), filepath.Join(t.TempDir(), "opened")
+	script := "#!/bin/sh\necho \"$1\" > " + opened + "\n"
+	if err := os.WriteFile(filepath.Join(bin, opener(runtime.GOOS)), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("HOME", "/home/carol")
+	msg, ok := openPath("~/api")().(actionMsg)
+	if !ok || msg.err != nil || msg.notice != "Opened ~/api" {
+		t.Fatalf("openPath = %+v", msg)
+	}
+	// The opener runs in the background; give it a moment.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(opened)
+		if err == nil && strings.HasSuffix(string(data), "\n") {
+			if got := strings.TrimSpace(string(data)); got != "/home/carol/api" {
+				t.Errorf("opened %q, want the expanded path", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the opener never ran")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOpenPathWithoutOpener(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	msg, ok := openPath("/tmp")().(actionMsg)
+	if !ok || msg.err == nil || !strings.Contains(msg.err.Error(), "not found") {
+		t.Errorf("openPath without an opener = %+v", msg)
+	}
+}
+
+func TestOpenKeyGuardsMissingPath(t *testing.T) {
+	setConfig(t, nil)
+	s := testSession("gone", StatusIdle)
+	s.PathMissing = true
+	next, cmd := update(newModel(), scanned(s)).Update(press("o"))
+	if m := next.(model); cmd != nil || m.quitting || !strings.Contains(m.notice, "no longer exists") {
+		t.Errorf("o on a missing path: cmd %v, quitting %v, notice %q", cmd != nil, m.quitting, m.notice)
+	}
+}
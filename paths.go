@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Path scoping (include_paths, exclude_paths)
+
+// underPath reports whether path is root or inside it, comparing whole path
+// components so /src/app2 is not under /src/app.
+func underPath(path, root string) bool {
+	if root == "/" {
+		return strings.HasPrefix(path, "/")
+	}
+	return path == root || strings.HasPrefix(path, root+"/")
+}
+
+// cleanRoots expands ~ and cleans each configured root.
+func cleanRoots(roots []string) []string {
+	out := make([]string, len(roots))
+	for i, r := range roots {
+		out[i] = filepath.Clean(expandPath(r))
+	}
+	return out
+}
+
+// pathInScope applies include and exclude roots to an absolute pane path.
+// With includes set, only paths under one of them are in scope; an exclude
+// always wins.
+func pathInScope(path string, include, exclude []string) bool {
+	for _, root := range exclude {
+		if underPath(path, root) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, root := range include {
+		if underPath(path, root) {
+			return true
+		}
+	}
+	return false
+}
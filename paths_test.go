@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestUnderPath(t *testing.T) {
+	tests := []struct {
+		path, root string
+		want       bool
+	}{
+		{"/src/app", "/src/app", true},
+		{"/src/app/cmd", "/src/app", true},
+		{"/src/app2", "/src/app", false}, // whole components only
+		{"/src", "/src/app", false},
+		{"/anything", "/", true},
+	}
+	for _, tt := range tests {
+		if got := underPath(tt.path, tt.root); got != tt.want {
+			t.Errorf("underPath(%q, %q) = %v, want %v", tt.path, tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestPathInScope(t *testing.T) {
+	work, vendor := []string{"/work"}, []string{"/work/vendor"}
+	tests := []struct {
+		path             string
+		include, exclude []string
+		want             bool
+	}{
+		{"/tmp/x", nil, nil, true},
+		{"/work/api", work, nil, true},
+		{"/tmp/x", work, nil, false}, // includes hide everything else
+		{"/workshop", work, nil, false},
+		{"/work/vendor/lib", work, vendor, false}, // exclude wins
+		{"/work/vendors", work, vendor, true},
+		{"/tmp/x", nil, vendor, true},
+		{"/tmp/x", []string{"/work", "/tmp"}, nil, true},
+	}
+	for _, tt := range tests {
+		if got := pathInScope(tt.path, tt.include, tt.exclude); got != tt.want {
+			t.Errorf("pathInScope(%q, %q, %q) = %v, want %v", tt.path, tt.include, tt.exclude, got, tt.want)
+		}
+	}
+}
+
+func TestCleanRoots(t *testing.T) {
+	t.Setenv("HOME", "/home/carol")
+	got := cleanRoots([]string{"~/work/", "/srv//api/.", "~"})
+	want := []string{"/home/carol/work", "/srv/api", "/home/carol"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cleanRoots[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDetectInScope(t *testing.T) {
+	setConfig(t, func(c *Config) {
+		c.IncludePaths, c.ExcludePaths = []string{"/work"}, []string{"/work/scratch"}
+	})
+	f := &fakeRunner{
+		panes: paneLineAt("a:0.0", "/work/api", "✳ task") + paneLineAt("b:0.0", "/work/scratch/x", "✳ task") +
+			paneLineAt("c:0.0", "/home/me", "✳ task"),
+		captures: map[string]string{"a:0.0": "❯ \n", "b:0.0": "❯ \n", "c:0.0": "❯ \n"},
+	}
+	sessions, _ := detect(f)
+	if len(sessions) != 1 || sessions[0].PaneID != "a:0.0" {
+		t.Errorf("detected %v, want only a:0.0", sessions)
+	}
+	if got := len(f.ran("tmux capture-pane")); got != 1 {
+		t.Errorf("captured %d panes, want out-of-scope panes skipped before capture", got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Popup border title
+//
+// With popup_title on and csm running in a tmux popup, the server option
+// @csm_title carries a live summary after each scan. Open the popup with
+// display-popup -T '#{@csm_title}' to show it in the border.
+
+// popupTitleOption is the tmux user option holding the summary.
+const popupTitleOption = "@csm_title"
+
+// inPopup reports whether csm runs in a tmux popup: tmux sets $TMUX for
+// popup commands but, having no pane for them, not $TMUX_PANE.
+func inPopup() bool {
+	return os.Getenv("TMUX") != "" && os.Getenv("TMUX_PANE") == ""
+}
+
+// popupTitle summarizes the waiting count for the border.
+func popupTitle(sessions []ClaudeSession) string {
+	waiting := statusCounts(sessions)[StatusWaiting]
+	if waiting == 0 {
+		return " Claude Sessions "
+	}
+	return fmt.Sprintf(" Claude Sessions · %d waiting ", waiting)
+}
+
+// popupTitleArgs returns the tmux invocation that publishes title.
+func popupTitleArgs(title string) []string {
+	return []string{"set-option", "-g", popupTitleOption, title}
+}
+
+// setPopupTitle publishes title in the background; failures go to the debug log.
+func setPopupTitle(title string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := sysRunner.Output("tmux", popupTitleArgs(title)...); err != nil {
+			debugLog.Printf("popup_title: %v", err)
+		}
+		return nil
+	}
+}
+
+// clearPopupTitle removes the option when csm exits; failures go to the
+// debug log.
+func clearPopupTitle() {
+	if _, err := sysRunner.Output("tmux", "set-option", "-gu", popupTitleOption); err != nil {
+		debugLog.Printf("popup_title: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInPopup(t *testing.T) {
+	tests := []struct {
+		tmux, pane string
+		want       bool
+	}{
+		{"/tmp/tmux-1000/default,1,0", "", true},
+		{"/tmp/tmux-1000/default,1,0", "%3", false}, // a pane, not a popup
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("TMUX", tt.tmux)
+		t.Setenv("TMUX_PANE", tt.pane)
+		if got := inPopup(); got != tt.want {
+			t.Errorf("TMUX %q, TMUX_PANE %q: inPopup = %v", tt.tmux, tt.pane, got)
+		}
+	}
+}
+
+func TestPopupTitle(t *testing.T) {
+	tests := []struct {
+		sessions []ClaudeSession
+		want     string
+	}{
+		{nil, " Claude Sessions "},
+		{[]ClaudeSession{testSession("a", StatusIdle), testSession("b", StatusWorking)}, " Claude Sessions "},
+		{[]ClaudeSession{testSession("a", StatusWaiting), testSession("b", StatusWaiting)}, " Claude Sessions · 2 waiting "},
+	}
+	for _, tt := range tests {
+		if got := popupTitle(tt.sessions); got != tt.want {
+			t.Errorf("popupTitle(%s) = %q, want %q", names(tt.sessions), got, tt.want)
+		}
+	}
+	want := "[set-option -g @csm_title  Claude Sessions ]"
+	if got := fmt.Sprint(popupTitleArgs(" Claude Sessions ")); got != want {
+		t.Errorf("popupTitleArgs = %s, want %s", got, want)
+	}
+}
+
+func TestPopupTitleFollowsScans(t *testing.T) {
+	setConfig(t, nil)
+	waiting := testSession("a", StatusWaiting)
+	for _, popup := range []bool{false, true} {
+		m := newModel()
+		m.popup = popup
+		m = update(m, scanned(waiting))
+		want := ""
+		if popup {
+			want = " Claude Sessions · 1 waiting "
+		}
+		if m.popupTitle != want {
+			t.Errorf("popup %v: published %q, want %q", popup, m.popupTitle, want)
+		}
+	}
+	m := newModel()
+	m.popup = true
+	m = update(m, scanned(waiting), scanned(testSession("a", StatusIdle)))
+	if m.popupTitle != " Claude Sessions " {
+		t.Errorf("after the wait ended, published %q", m.popupTitle)
+	}
+}
+
+func TestPopupTitleRuns(t *testing.T) {
+	setConfig(t, nil)
+	var logged bytes.Buffer
+	debugLog.SetOutput(&logged)
+	t.Cleanup(func() { debugLog.SetOutput(io.Discard) })
+
+	f := &fakeRunner{}
+	useRunner(t, f)
+	setPopupTitle(" Claude Sessions ")()
+	clearPopupTitle()
+	want := []string{"tmux set-option -g @csm_title  Claude Sessions ", "tmux set-option -gu @csm_title"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("ran %q, want %q", f.calls, want)
+	}
+
+	useRunner(t, &fakeRunner{fail: map[string]bool{"set-option": true}})
+	setPopupTitle(" Claude Sessions ")()
+	clearPopupTitle()
+	if got := strings.Count(logged.String(), "popup_title: fake: set-option failed"); got != 2 {
+		t.Errorf("logged %q, want both failures", logged.String())
+	}
+}
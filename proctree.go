@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Process ancestry (--deep-detect)
+
+// procTree is a snapshot of the process table: children by parent PID and
+// the command line of each PID.
+type procTree struct {
+	children map[int][]int
+	args     map[int]string
+}
+
+// loadProcTree snapshots the process table with one ps call.
+func loadProcTree(r CommandRunner) (procTree, error) {
+	out, err := r.Output("ps", "-A", "-o", "pid=,ppid=,args=")
+	if err != nil {
+		return procTree{}, err
+	}
+	return parseProcTree(string(out)), nil
+}
+
+// parseProcTree parses "pid ppid args..." lines, skipping malformed ones.
+func parseProcTree(out string) procTree {
+	t := procTree{children: map[int][]int{}, args: map[int]string{}}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		t.children[ppid] = append(t.children[ppid], pid)
+		t.args[pid] = strings.Join(fields[2:], " ")
+	}
+	return t
+}
+
+// hasClaude reports whether root or any of its descendants is Claude.
+func (t procTree) hasClaude(root int) bool {
+	seen := map[int]bool{}
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if seen[pid] {
+			continue
+		}
+		seen[pid] = true
+		if isClaudeCommand(t.args[pid]) {
+			return true
+		}
+		queue = append(queue, t.children[pid]...)
+	}
+	return false
+}
+
+// isClaudeCommand matches a claude binary, or a JavaScript runtime running
+// the claude script or the @anthropic-ai/claude-code package.
+func isClaudeCommand(args string) bool {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return false
+	}
+	switch filepath.Base(fields[0]) {
+	case "claude":
+		return true
+	case "node", "bun", "deno":
+		for _, f := range fields[1:] {
+			if filepath.Base(f) == "claude" || strings.Contains(f, "@anthropic-ai/claude-code") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+// testProcs is a process table: two panes' shells, one running Claude
+// through node two levels down, the other running vim.
+const testProcs = `
+  100     1 -zsh
+  101   100 /usr/bin/node /home/me/.npm/bin/claude --resume
+  102   101 rg TODO
+  200     1 -bash
+  201   200 vim notes.md
+  garbage
+  300 x   bad ppid
+`
+
+func TestHasClaude(t *testing.T) {
+	tree := parseProcTree(testProcs)
+	tests := []struct {
+		root int
+		want bool
+	}{
+		{100, true},
+		{101, true},
+		{102, false}, // only descendants count, not ancestors
+		{200, false},
+		{999, false},
+	}
+	for _, tt := range tests {
+		if got := tree.hasClaude(tt.root); got != tt.want {
+			t.Errorf("hasClaude(%d) = %v, want %v", tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestHasClaudeCycle(t *testing.T) {
+	// PIDs wrap and ps snapshots race; a loop must not hang.
+	tree := parseProcTree("1 2 sh\n2 1 sh\n")
+	if tree.hasClaude(1) {
+		t.Error("found Claude in a loop of shells")
+	}
+}
+
+func TestIsClaudeCommand(t *testing.T) {
+	tests := []struct {
+		args string
+		want bool
+	}{
+		{"claude", true},
+		{"/opt/homebrew/bin/claude --continue", true},
+		{"node /usr/lib/node_modules/@anthropic-ai/claude-code/cli.js", true},
+		{"bun x claude", true},
+		{"node server.js", false},
+		{"vim claude.md", false},
+		{"claudette", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isClaudeCommand(tt.args); got != tt.want {
+			t.Errorf("isClaudeCommand(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDeepDetect(t *testing.T) {
+	// Two untitled panes, their shells at PIDs 100 and 200.
+	claude := withCommand(paneLine("a:0.0", "host"), "node")
+	editor := withCommand(paneLine("b:0.0", "host"), "vim")
+	editor = replaceField(editor, 6, "200")
+	claude = replaceField(claude, 6, "100")
+	f := &fakeRunner{panes: claude + editor, ps: testProcs, captures: map[string]string{"a:0.0": "❯ \n"}}
+	for _, deep := range []bool{false, true} {
+		setConfig(t, nil)
+		cfg.deepDetect = deep
+		sessions, _ := detect(f)
+		want := 0
+		if deep {
+			want = 1
+		}
+		if len(sessions) != want {
+			t.Fatalf("deep %v: detected %d sessions, want %d", deep, len(sessions), want)
+		}
+		if deep && (sessions[0].PaneID != "a:0.0" || sessions[0].Why.source != "process tree") {
+			t.Errorf("deep-detect found %s by %q", sessions[0].PaneID, sessions[0].Why.source)
+		}
+	}
+	if got := len(f.ran("ps")); got != 1 {
+		t.Errorf("ran ps %d times, want once, for the deep scan only", got)
+	}
+}
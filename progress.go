@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Progress indicators (progress_pattern)
+//
+// With deep_status, a working pane's capture is searched for
+// progress_pattern and its last match is shown in the row as a mini bar.
+// A pattern with one capture group reads a percentage ("(\\d+)%"); one
+// with two reads a done/total fraction ("step (\\d+)/(\\d+)").
+
+// progressCells is the width of the mini progress bar.
+const progressCells = 5
+
+// progress is the latest progress indicator of a working pane. The zero
+// value means none was found.
+type progress struct {
+	label string  // "42%" or "3/10"
+	frac  float64 // completion in [0, 1]
+}
+
+// compileProgress compiles a progress_pattern, which must have one or two
+// capture groups.
+func compileProgress(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if n := re.NumSubexp(); n != 1 && n != 2 {
+		return nil, fmt.Errorf("want 1 capture group (percent) or 2 (done/total), got %d", n)
+	}
+	return re, nil
+}
+
+// parseProgress returns the last match of re in content that reads as a
+// valid percentage or fraction. A nil re disables it.
+func parseProgress(content string, re *regexp.Regexp) progress {
+	if re == nil || content == "" {
+		return progress{}
+	}
+	matches := re.FindAllStringSubmatch(content, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		if p, ok := readProgress(matches[i]); ok {
+			return p
+		}
+	}
+	return progress{}
+}
+
+func readProgress(m []string) (progress, bool) {
+	switch len(m) {
+	case 2:
+		pct, err := strconv.ParseFloat(strings.TrimSpace(m[1]), 64)
+		if err != nil || pct < 0 || pct > 100 {
+			return progress{}, false
+		}
+		return progress{label: strconv.FormatFloat(pct, 'f', -1, 64) + "%", frac: pct / 100}, true
+	case 3:
+		done, err1 := strconv.Atoi(strings.TrimSpace(m[1]))
+		total, err2 := strconv.Atoi(strings.TrimSpace(m[2]))
+		if err1 != nil || err2 != nil || total <= 0 || done < 0 || done > total {
+			return progress{}, false
+		}
+		return progress{label: fmt.Sprintf("%d/%d", done, total), frac: float64(done) / float64(total)}, true
+	}
+	return progress{}, false
+}
+
+// progressBar renders p as a mini bar followed by its label, or "" for
+// the zero progress.
+func progressBar(p progress) string {
+	if p.label == "" {
+		return ""
+	}
+	filled := int(p.frac*progressCells + 0.5)
+	return asciiOnly(strings.Repeat("▰", filled)+strings.Repeat("▱", progressCells-filled)) + " " + p.label
+}
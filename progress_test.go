@@ -0,0 +1,83 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseProgress(t *testing.T) {
+	percent := regexp.MustCompile(`(\d+(?:\.\d+)?)%`)
+	steps := regexp.MustCompile(`[Ss]tep (\d+)/(\d+)`)
+	tests := []struct {
+		name    string
+		re      *regexp.Regexp
+		content string
+		want    progress
+	}{
+		{"percent", percent, "Downloading 12%\nDownloading 48%\n", progress{"48%", 0.48}},
+		{"decimal", percent, "  ▕███    ▏ 37.5% done\n", progress{"37.5%", 0.375}},
+		{"fraction", steps, "Step 2/10: lint\nstep 3/10: test\n", progress{"3/10", 0.3}},
+		// implausible matches fall back to the last sensible one
+		{"over 100", percent, "coverage 80%\nbuild 140%\n", progress{"80%", 0.8}},
+		{"done past total", steps, "step 4/5\nstep 9/5\n", progress{"4/5", 0.8}},
+		{"zero total", steps, "step 0/0\n", progress{}},
+		{"no match", percent, "Reading main.go\n", progress{}},
+		{"off", nil, "Downloading 48%\n", progress{}},
+	}
+	for _, tt := range tests {
+		if got := parseProgress(tt.content, tt.re); got != tt.want {
+			t.Errorf("%s: parseProgress = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		p    progress
+		want string
+	}{
+		{progress{}, ""},
+		{progress{"0%", 0}, "▱▱▱▱▱ 0%"},
+		{progress{"3/10", 0.3}, "▰▰▱▱▱ 3/10"}, // rounds 1.5 cells up
+		{progress{"100%", 1}, "▰▰▰▰▰ 100%"},
+	}
+	for _, tt := range tests {
+		if got := progressBar(tt.p); got != tt.want {
+			t.Errorf("progressBar(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestCompileProgress(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{`(\d+)%`, true},
+		{`(\d+)/(\d+)`, true},
+		{`\d+%`, false},              // no group
+		{`(\d+)/(\d+)/(\d+)`, false}, // too many
+		{`(\d+`, false},
+	}
+	for _, tt := range tests {
+		if _, err := compileProgress(tt.pattern); (err == nil) != tt.ok {
+			t.Errorf("compileProgress(%q) = %v, want ok %v", tt.pattern, err, tt.ok)
+		}
+	}
+}
+
+func TestProgressOnWorkingPanes(t *testing.T) {
+	setConfig(t, func(c *Config) { c.ProgressPattern, c.DeepStatus = `(\d+)%`, true })
+	f := &fakeRunner{
+		panes:    paneLine("w:0.0", "⠂ task") + paneLine("i:0.0", "✳ task"),
+		captures: map[string]string{"w:0.0": "✻ Installing… 60%\n", "i:0.0": "⏺ Done 100%\n\n❯ \n"},
+	}
+	sessions, _ := detect(f)
+	got := map[string]progress{}
+	for _, s := range sessions {
+		got[s.SessionName] = s.Progress
+	}
+	if got["w"] != (progress{"60%", 0.6}) || got["i"] != (progress{}) {
+		t.Errorf("progress %+v; want 60%% on the working pane only", got)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Project scope (p)
+//
+// The p key narrows the list to sessions in the project csm was started
+// from: the git repository containing the launch directory, or just that
+// directory when it is not inside a repository.
+
+// project is the directory the p key scopes to.
+type project struct {
+	root string // absolute, cleaned
+	repo bool   // root is a git work tree top level
+}
+
+// resolveProject finds the repository root containing dir with git
+// rev-parse, falling back to dir itself.
+func resolveProject(r CommandRunner, dir string) project {
+	out, err := r.Output("git", "-C", dir, "rev-parse", "--show-toplevel")
+	if root := strings.TrimSpace(string(out)); err == nil && root != "" {
+		return project{root: filepath.Clean(root), repo: true}
+	}
+	return project{root: filepath.Clean(dir)}
+}
+
+// contains reports whether a session Path (possibly ~-shortened) belongs
+// to the project: anywhere in the work tree, or the exact directory
+// outside git.
+func (p project) contains(path string) bool {
+	path = filepath.Clean(expandPath(path))
+	if p.repo {
+		return underPath(path, p.root)
+	}
+	return path == p.root
+}
+
+// filterProject keeps the sessions in p.
+func filterProject(sessions []ClaudeSession, p project) []ClaudeSession {
+	var out []ClaudeSession
+	for _, s := range sessions {
+		if p.contains(s.Path) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// gitRunner answers git -C <dir> rev-parse --show-toplevel from tops, by
+// directory, as if git found no repository elsewhere.
+type gitRunner map[string]string
+
+func (tops gitRunner) Output(name string, args ...string) ([]byte, error) {
+	if name == "git" && len(args) > 1 && args[0] == "-C" {
+		if top, ok := tops[args[1]]; ok {
+			return []byte(top + "\n"), nil
+		}
+	}
+	return nil, errors.New("fatal: not a git repository")
+}
+
+func TestResolveProject(t *testing.T) {
+	r := gitRunner{"/work/api/cmd": "/work/api"}
+	tests := []struct {
+		dir  string
+		want project
+	}{
+		{"/work/api/cmd", project{root: "/work/api", repo: true}},
+		{"/tmp/scratch/", project{root: "/tmp/scratch"}}, // outside git: the directory itself
+	}
+	for _, tt := range tests {
+		if got := resolveProject(r, tt.dir); got != tt.want {
+			t.Errorf("resolveProject(%q) = %+v, want %+v", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestProjectContains(t *testing.T) {
+	t.Setenv("HOME", "/home/carol")
+	repo := project{root: "/home/carol/api", repo: true}
+	dir := project{root: "/tmp/scratch"}
+	tests := []struct {
+		p    project
+		path string
+		want bool
+	}{
+		{repo, "/home/carol/api", true},
+		{repo, "~/api/internal/db", true}, // session paths are ~-shortened
+		{repo, "~/api2", false},
+		{repo, "/home/carol", false},
+		{dir, "/tmp/scratch", true},
+		{dir, "/tmp/scratch/sub", false}, // without a repo, only the directory
+	}
+	for _, tt := range tests {
+		if got := tt.p.contains(tt.path); got != tt.want {
+			t.Errorf("%+v contains %q = %v, want %v", tt.p, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestProjectKey(t *testing.T) {
+	setConfig(t, nil)
+	t.Setenv("HOME", "/home/carol")
+	m := newModel()
+	m.project = resolveProject(gitRunner{"/home/carol/api/cmd": "/home/carol/api"}, "/home/carol/api/cmd")
+	in, out := testSession("api", StatusIdle), testSession("web", StatusIdle)
+	m = update(m, scanned(in, out), press("p"))
+	if got := names(m.sessions); got != "api" {
+		t.Errorf("p kept %q, want api", got)
+	}
+	if m = update(m, press("p")); len(m.sessions) != 2 {
+		t.Errorf("a second p kept %d sessions, want 2", len(m.sessions))
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shell prompt token (--prompt)
+//
+// csm --prompt prints the number of waiting sessions, such as "◐2", or
+// nothing when none waits, for embedding in PS1. It runs on every prompt,
+// so a count younger than promptTTL is read from a cache file instead of
+// asking tmux again; the scan itself only captures panes whose title shows
+// no spinner. Sessions at muted paths are not counted.
+
+// promptTTL is how long a cached count is reused.
+const promptTTL = 5 * time.Second
+
+// promptCachePath returns the cache file, in $XDG_CACHE_HOME/csm or the
+// platform's cache directory, or "" if there is none.
+func promptCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "csm", "prompt")
+}
+
+// readPromptCache returns the count cached at path if it was written less
+// than ttl before now.
+func readPromptCache(path string, now time.Time, ttl time.Duration) (int, bool) {
+	fi, err := os.Stat(path)
+	if err != nil || now.Sub(fi.ModTime()) >= ttl {
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// writePromptCache caches n at path. It writes a temporary file and
+// renames it, so a prompt in another shell never reads half a count.
+func writePromptCache(path string, n int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".prompt-*")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(tmp, n)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// promptCount counts the waiting sessions not at a muted path.
+func promptCount(sessions []ClaudeSession, muted []string) int {
+	n := 0
+	for _, s := range sessions {
+		if s.Status == StatusWaiting && !slices.Contains(muted, s.Path) {
+			n++
+		}
+	}
+	return n
+}
+
+// promptToken renders n waiting sessions in the waiting style, or "" for
+// none. lipgloss drops the color when stdout is not a terminal.
+func promptToken(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return statusStyles[StatusWaiting].Render(statusSymbol(StatusWaiting) + strconv.Itoa(n))
+}
+
+// runPrompt prints the prompt token, from the cache unless useCache is
+// false. It prints nothing rather than an error, so a prompt never breaks.
+func runPrompt(useCache bool) int {
+	path := ""
+	if useCache {
+		path = promptCachePath()
+	}
+	n, ok := 0, false
+	if path != "" {
+		n, ok = readPromptCache(path, time.Now(), promptTTL)
+	}
+	if !ok {
+		n = promptCount(detectSessions(sysRunner), loadState(statePath()).Muted)
+		if path != "" {
+			if err := writePromptCache(path, n); err != nil {
+				debugLog.Printf("prompt cache: %v", err)
+			}
+		}
+	}
+	fmt.Print(promptToken(n))
+	return 0
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPromptCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "csm", "prompt")
+	if err := writePromptCache(path, 3); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("the cache directory holds %d files, want the temporary file renamed away", len(entries))
+	}
+	written := time.Now()
+	if err := os.Chtimes(path, written, written); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, data string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	tests := []struct {
+		name string
+		path string
+		age  time.Duration
+		n    int
+		ok   bool
+	}{
+		{"fresh", path, time.Second, 3, true},
+		{"expired", path, promptTTL, 0, false},
+		{"missing", filepath.Join(dir, "none"), 0, 0, false},
+		{"garbage", write("garbage", "lots\n"), 0, 0, false},
+		{"negative", write("negative", "-1\n"), 0, 0, false},
+	}
+	for _, tt := range tests {
+		n, ok := readPromptCache(tt.path, written.Add(tt.age), promptTTL)
+		if n != tt.n || ok != tt.ok {
+			t.Errorf("%s: readPromptCache = %d, %v; want %d, %v", tt.name, n, ok, tt.n, tt.ok)
+		}
+	}
+}
+
+func TestPromptCountAndToken(t *testing.T) {
+	sessions := []ClaudeSession{
+		testSession("a", StatusWaiting), testSession("b", StatusWaiting),
+		testSession("c", StatusWorking), testSession("muted", StatusWaiting),
+	}
+	if n := promptCount(sessions, []string{"~/muted"}); n != 2 {
+		t.Errorf("promptCount = %d, want 2", n)
+	}
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, ""}, // nothing waits: nothing printed
+		{2, statusSymbol(StatusWaiting) + "2"},
+		{12, statusSymbol(StatusWaiting) + "12"},
+	}
+	for _, tt := range tests {
+		if got := promptToken(tt.n); got != tt.want { // no TTY: plain text
+			t.Errorf("promptToken(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRunPromptUsesCache(t *testing.T) {
+	setConfig(t, nil)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	asking := &fakeRunner{
+		panes:    paneLine("api:0.0", "✳ fix the bug"),
+		captures: map[string]string{"api:0.0": "❯ fix it\n\n Do you want to make this edit to main.go?\n ❯ 1. Yes\n Esc to cancel\n"},
+	}
+	useRunner(t, asking)
+	want := statusSymbol(StatusWaiting) + "1"
+	if out := stdoutOf(t, func() { runPrompt(true) }); out != want {
+		t.Fatalf("first prompt printed %q, want %q", out, want)
+	}
+
+	// Within promptTTL the count comes from the cache, without tmux.
+	idle := &fakeRunner{}
+	useRunner(t, idle)
+	if out := stdoutOf(t, func() { runPrompt(true) }); out != want || len(idle.calls) != 0 {
+		t.Errorf("cached prompt printed %q after %d tmux calls", out, len(idle.calls))
+	}
+	// --replay reads no cache.
+	if out := stdoutOf(t, func() { runPrompt(false) }); out != "" || len(idle.ran("tmux list-panes")) != 1 {
+		t.Errorf("uncached prompt printed %q after %q", out, idle.calls)
+	}
+}
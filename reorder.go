@@ -0,0 +1,125 @@
+package main
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Manual ordering
+//
+// The m key enters move mode, where j/k move the selected session up or
+// down and switch the list to the manual sort mode. The order is a list of
+// paths, like muted and pinned, kept in the state file so it survives
+// restarts and pane renumbering. Sessions sharing a path move together;
+// paths not in the order sort after it, in PaneID order.
+
+// manualRank maps each path in order to its position.
+func manualRank(order []string) map[string]int {
+	rank := make(map[string]int, len(order))
+	for i, p := range order {
+		if _, ok := rank[p]; !ok {
+			rank[p] = i
+		}
+	}
+	return rank
+}
+
+func lessManual(a, b ClaudeSession, rank map[string]int) bool {
+	ra, oka := rank[a.Path]
+	rb, okb := rank[b.Path]
+	switch {
+	case oka && okb && ra != rb:
+		return ra < rb
+	case oka != okb:
+		return oka
+	}
+	return a.PaneID < b.PaneID
+}
+
+// shownOrder returns the paths of listed, the sessions as shown, followed
+// by the paths of order that are not listed, and how many are listed.
+func shownOrder(order []string, listed []ClaudeSession) ([]string, int) {
+	var paths []string
+	for _, s := range listed {
+		if !slices.Contains(paths, s.Path) {
+			paths = append(paths, s.Path)
+		}
+	}
+	shown := len(paths)
+	for _, p := range order {
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths, shown
+}
+
+// moveInOrder moves the path of listed[cursor] one place up (delta -1) or
+// down (delta 1) past the neighbouring path in listed, starting from
+// shownOrder. It reports false when there is nothing to move past: the
+// ends of the list, or a neighbour on the other side of the pinned split.
+func moveInOrder(order []string, listed []ClaudeSession, pinned map[string]bool, cursor, delta int) ([]string, bool) {
+	if cursor < 0 || cursor >= len(listed) {
+		return order, false
+	}
+	paths, shown := shownOrder(order, listed)
+	from := slices.Index(paths, listed[cursor].Path)
+	to := from + delta
+	if to < 0 || to >= shown || pinned[paths[from]] != pinned[paths[to]] {
+		return order, false
+	}
+	paths[from], paths[to] = paths[to], paths[from]
+	return paths, true
+}
+
+// startMove enters move mode, switching to the manual sort mode.
+func (m *model) startMove() {
+	if m.filter != "" {
+		m.notice = "Clear the / filter to reorder"
+		return
+	}
+	if m.cursor >= len(m.sessions) {
+		return
+	}
+	if m.sortMode != sortManual {
+		// Seed the manual order from the list as it is shown now.
+		m.order, _ = shownOrder(m.order, m.sessions)
+		m.sortMode = sortManual
+		m.refilter()
+	}
+	m.mode = modeMove
+}
+
+// updateMove handles keys in move mode.
+func (m model) updateMove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	delta := 0
+	switch msg.String() {
+	case "j", "down":
+		delta = 1
+	case "k", "up":
+		delta = -1
+	case "m", "enter", "esc":
+		m.mode = modeNormal
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	}
+	if delta == 0 || m.cursor >= len(m.sessions) {
+		return m, nil
+	}
+	id := m.sessions[m.cursor].PaneID
+	order, ok := moveInOrder(m.order, m.sessions, m.pinned, m.cursor, delta)
+	if !ok {
+		return m, nil
+	}
+	m.order = order
+	m.refilter()
+	// Follow the moved session even with cursor_follow "row".
+	for i, s := range m.sessions {
+		if s.PaneID == id {
+			m.cursor = i
+		}
+	}
+	return m, nil
+}
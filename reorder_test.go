@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestManualSort(t *testing.T) {
+	sessions := []ClaudeSession{
+		testSession("a", StatusIdle), testSession("b", StatusIdle),
+		testSession("c", StatusIdle), testSession("d", StatusIdle),
+	}
+	tests := []struct {
+		order  []string
+		pinned map[string]bool
+		want   string
+	}{
+		{[]string{"~/c", "~/a"}, nil, "c a b d"},           // unknown paths follow, in pane order
+		{[]string{"~/gone", "~/d", "~/b"}, nil, "d b a c"}, // paths no longer listed are skipped
+		{[]string{"~/c", "~/a"}, map[string]bool{"~/d": true}, "d c a b"},
+		{nil, nil, "a b c d"},
+	}
+	for _, tt := range tests {
+		s := slices.Clone(sessions)
+		sortSessions(s, sortManual, tt.pinned, tt.order)
+		if got := names(s); got != tt.want {
+			t.Errorf("order %q, pinned %v: %q, want %q", tt.order, tt.pinned, got, tt.want)
+		}
+	}
+}
+
+func TestMoveInOrder(t *testing.T) {
+	listed := []ClaudeSession{testSession("a", StatusIdle), testSession("b", StatusIdle), testSession("c", StatusIdle)}
+	tests := []struct {
+		order         []string
+		pinned        map[string]bool
+		cursor, delta int
+		want          []string
+		ok            bool
+	}{
+		{nil, nil, 0, 1, []string{"~/b", "~/a", "~/c"}, true},
+		{nil, nil, 2, -1, []string{"~/a", "~/c", "~/b"}, true},
+		{nil, nil, 0, -1, nil, false}, // the top
+		{nil, nil, 2, 1, nil, false},  // the bottom
+		// hidden paths keep their place after the listed ones
+		{[]string{"~/x", "~/a"}, nil, 1, 1, []string{"~/a", "~/c", "~/b", "~/x"}, true},
+		{nil, map[string]bool{"~/a": true}, 1, -1, nil, false}, // not past the pinned split
+	}
+	for _, tt := range tests {
+		got, ok := moveInOrder(tt.order, listed, tt.pinned, tt.cursor, tt.delta)
+		if ok != tt.ok || (ok && !slices.Equal(got, tt.want)) {
+			t.Errorf("moveInOrder(%q, %d, %d) = %q, %v; want %q, %v", tt.order, tt.cursor, tt.delta, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMoveModePersists(t *testing.T) {
+	setConfig(t, nil)
+	a, b, c := testSession("a", StatusIdle), testSession("b", StatusIdle), testSession("c", StatusIdle)
+	m := update(newModel(), scanned(a, b, c), press("m"), press("j"), press("j"), press("enter"))
+	if got := names(m.sessions); got != "b c a" || m.sortMode != sortManual || m.mode != modeNormal {
+		t.Fatalf("after moving a down twice: %q, sort %s, mode %d", got, m.sortMode, m.mode)
+	}
+	if m.sessions[m.cursor].SessionName != "a" {
+		t.Errorf("cursor on %s, want it to follow a", m.sessions[m.cursor].SessionName)
+	}
+
+	file := filepath.Join(t.TempDir(), "state.json")
+	if err := saveState(file, m.state()); err != nil {
+		t.Fatal(err)
+	}
+	restarted := newModel()
+	restarted.applyState(loadState(file))
+	// A new session and renumbered panes: the order follows paths.
+	moved := testSession("c", StatusIdle)
+	moved.PaneID = "aa:0.0"
+	restarted = update(restarted, scanned(a, b, moved, testSession("new", StatusIdle)))
+	if got := names(restarted.sessions); got != "b c a new" {
+		t.Errorf("after a restart: %q, want b c a new", got)
+	}
+}
+
+func TestMoveNeedsNoFilter(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle)), press("/"), press("a"), press("enter"), press("m"))
+	if m.mode == modeMove || !strings.Contains(m.notice, "filter") {
+		t.Errorf("m under a filter: mode %d, notice %q", m.mode, m.notice)
+	}
+}
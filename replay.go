@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Replay fixtures (--replay)
+//
+// A fixture directory stands in for tmux, so a misdetected session can be
+// reproduced without the panes that produced it:
+//
+//	panes.tsv      output of tmux list-panes -a -F "<listPanesFormat>"
+//	<pane>.txt     output of tmux capture-pane -p -t <pane>, one per pane,
+//	               named by fixtureName
+//	ps.txt         optional output of ps -A -o pid=,ppid=,args=, for --deep-detect
+//	sessions.tsv   optional output of tmux list-sessions -F
+//	               "#{session_name}\t#{session_attached}\t#{session_group}",
+//	               for show_clients and session groups
+
+// unsafeFileChars are replaced in PaneIDs to form fixture file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// fixtureName returns the capture file name for pane, e.g. "work_1.0.txt"
+// for "work:1.0".
+func fixtureName(pane string) string {
+	return unsafeFileChars.ReplaceAllString(pane, "_") + ".txt"
+}
+
+// replayRunner answers detection's commands from a fixture directory.
+type replayRunner struct {
+	dir string
+}
+
+func (r replayRunner) Output(name string, args ...string) ([]byte, error) {
+	switch {
+	case name == "tmux" && len(args) > 0 && args[0] == "list-panes":
+		return os.ReadFile(filepath.Join(r.dir, "panes.tsv"))
+	case name == "tmux" && len(args) > 0 && args[0] == "list-sessions":
+		return os.ReadFile(filepath.Join(r.dir, "sessions.tsv"))
+	case name == "tmux" && len(args) > 2 && args[0] == "capture-pane" && args[1] == "-t":
+		return os.ReadFile(filepath.Join(r.dir, fixtureName(args[2])))
+	case name == "tmux" && len(args) > 3 && args[0] == "display-message" && args[2] == "-t":
+		// A pane exists if it has a capture.
+		if _, err := os.Stat(filepath.Join(r.dir, fixtureName(args[3]))); err != nil {
+			return nil, err
+		}
+		return []byte(args[3] + "\n"), nil
+	case name == "tmux" && len(args) > 0 && args[0] == "display-message":
+		return nil, fmt.Errorf("replay: no tmux client")
+	case name == "ps":
+		return os.ReadFile(filepath.Join(r.dir, "ps.txt"))
+	}
+	return nil, fmt.Errorf("replay: unsupported command %s %v", name, args)
+}
+
+// openReplay checks that dir holds a pane list and returns its runner.
+func openReplay(dir string) (CommandRunner, error) {
+	if _, err := os.Stat(filepath.Join(dir, "panes.tsv")); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	return replayRunner{dir: dir}, nil
+}
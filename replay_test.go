@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestFixtureName(t *testing.T) {
+	tests := []struct{ pane, want string }{
+		{"work:1.0", "work_1.0.txt"},
+		{"my session:0.2", "my_session_0.2.txt"},
+		{"../etc:0.0", ".._etc_0.0.txt"}, // no path separators survive
+		{"3", "3.txt"},
+	}
+	for _, tt := range tests {
+		if got := fixtureName(tt.pane); got != tt.want {
+			t.Errorf("fixtureName(%q) = %q, want %q", tt.pane, got, tt.want)
+		}
+	}
+}
+
+func TestOpenReplayNeedsPanes(t *testing.T) {
+	if _, err := openReplay(t.TempDir()); err == nil {
+		t.Error("openReplay accepted a directory without panes.tsv")
+	}
+}
+
+func TestReplayDetect(t *testing.T) {
+	setConfig(t, nil)
+	dir := fixtureDir(t, map[string]string{
+		"panes.tsv": paneLine("api:0.0", "✳ fix the bug") + paneLine("web:1.0", "⠂ write docs") +
+			paneLine("gone:0.0", "✳ no capture"),
+		"api_0.0.txt": "❯ fix the bug\n\n Do you want to make this edit to main.go?\n ❯ 1. Yes\n Esc to cancel\n",
+		"web_1.0.txt": "✻ Writing… (esc to interrupt)\n",
+	})
+	r, err := openReplay(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, stats := detect(r)
+	if !stats.listed || len(sessions) != 2 {
+		t.Fatalf("replayed %d sessions, listed %v; want 2", len(sessions), stats.listed)
+	}
+	want := map[string]int{"api:0.0": StatusWaiting, "web:1.0": StatusWorking}
+	for _, s := range sessions {
+		if s.Status != want[s.PaneID] {
+			t.Errorf("%s: status %d, want %d", s.PaneID, s.Status, want[s.PaneID])
+		}
+	}
+	if !(tmuxBackend{}).Exists(r, "api:0.0") || (tmuxBackend{}).Exists(r, "gone:0.0") {
+		t.Error("a pane should exist exactly when it has a capture")
+	}
+}
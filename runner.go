@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CommandRunner runs external commands and returns their stdout. Detection
+// takes one so canned output can stand in for tmux when measuring or
+// reproducing the scan.
+//
+// Output returns whatever stdout was produced even when err is non-nil, like
+// exec.Cmd.Output.
+type CommandRunner interface {
+	Output(name string, args ...string) ([]byte, error)
+}
+
+// execRunner runs commands with os/exec, with tmux resolved by tmuxArgv.
+// Stderr is kept apart from stdout and written to the debug log, so
+// warnings never corrupt parsed output.
+type execRunner struct{}
+
+func (execRunner) Output(name string, args ...string) ([]byte, error) {
+	// A stuck command (say, capture-pane on a wedged pane) is killed after
+	// command_timeout so it cannot hold up the whole scan.
+	ctx := context.Background()
+	if cfg.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.commandTimeout)
+		defer cancel()
+	}
+	if name == "tmux" {
+		name, args = tmuxArgv(args)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		err = fmt.Errorf("%s %s: timed out after %v", name, strings.Join(args, " "), cfg.commandTimeout)
+		debugLog.Print(err)
+	}
+	if stderr.Len() > 0 {
+		debugLog.Printf("%s %s: stderr: %s", name, strings.Join(args, " "), bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, err
+}
+
+// sysRunner is the runner used outside of tests and fixtures.
+var sysRunner CommandRunner = execRunner{}
+
+// tolerantOutput runs a command through r for detection. Some tmux setups
+// exit non-zero with a warning while still printing valid output; a failure
+// that produced stdout is logged and the output used. It fails only when
+// there is nothing to parse.
+func tolerantOutput(r CommandRunner, name string, args ...string) ([]byte, error) {
+	out, err := r.Output(name, args...)
+	if err != nil && len(bytes.TrimSpace(out)) > 0 {
+		debugLog.Printf("%s %s: %v; using its output anyway", name, strings.Join(args, " "), err)
+		return out, nil
+	}
+	return out, err
+}
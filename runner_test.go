@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRunner stands in for tmux and ps: it answers list-panes with panes,
+// capture-pane from captures and ps with ps, and records every command it
+// runs. A capture takes delay, or runs cat on catFile to cost what a real
+// capture-pane process does, so tests can see how many overlap.
+type fakeRunner struct {
+	panes    string            // list-panes output, one listPanesFormat line per pane
+	captures map[string]string // capture-pane output by pane ID; others fail
+	later    map[string]string // output of captures after a pane's first, if it changed
+	ps       string            // ps -A -o pid=,ppid=,args= output
+	windows  string            // list-windows output
+	sessions string            // list-sessions output
+	newPane  string            // pane ID new-window -P prints
+	options  map[string]string // show-options -v output by "target option"
+	delay    time.Duration
+	catFile  string
+	fail     map[string]bool // subcommands that fail, e.g. "switch-client"
+	warn     map[string]bool // subcommands that print their output, then exit 1
+
+	mu      sync.Mutex
+	calls   []string       // commands run, space-joined
+	times   map[string]int // captures so far, by pane ID
+	running int            // captures in progress
+	peak    int            // most captures in progress at once
+}
+
+func (f *fakeRunner) Output(name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, strings.Join(append([]string{name}, args...), " "))
+	f.mu.Unlock()
+	if name == "ps" {
+		return []byte(f.ps), nil
+	}
+	if name != "tmux" || len(args) == 0 {
+		return nil, fmt.Errorf("fake: unexpected command %s", name)
+	}
+	if f.fail[args[0]] {
+		return nil, fmt.Errorf("fake: %s failed", args[0])
+	}
+	out, err := f.tmux(args)
+	if err == nil && f.warn[args[0]] {
+		err = fmt.Errorf("fake: %s warned", args[0])
+	}
+	return out, err
+}
+
+// tmux answers the tmux command args.
+func (f *fakeRunner) tmux(args []string) ([]byte, error) {
+	switch args[0] {
+	case "list-panes":
+		return []byte(f.panes), nil
+	case "list-sessions":
+		return []byte(f.sessions), nil
+	case "list-windows":
+		return []byte(f.windows), nil
+	case "show-options":
+		return []byte(f.options[args[len(args)-2]+" "+args[len(args)-1]] + "\n"), nil
+	case "new-window":
+		return []byte(f.newPane + "\n"), nil
+	case "capture-pane":
+		return f.capture(args[2])
+	case "display-message":
+		if len(args) > 3 && args[2] == "-t" {
+			if _, ok := f.captures[args[3]]; !ok {
+				return nil, fmt.Errorf("fake: no pane %s", args[3])
+			}
+			return []byte(args[3] + "\n"), nil
+		}
+		return nil, fmt.Errorf("fake: no client")
+	}
+	return nil, nil
+}
+
+func (f *fakeRunner) capture(pane string) ([]byte, error) {
+	f.mu.Lock()
+	f.running++
+	f.peak = max(f.peak, f.running)
+	f.mu.Unlock()
+	time.Sleep(f.delay)
+	var err error
+	if f.catFile != "" {
+		_, err = exec.Command("cat", f.catFile).Output()
+	}
+	f.mu.Lock()
+	f.running--
+	if f.times == nil {
+		f.times = map[string]int{}
+	}
+	f.times[pane]++
+	again := f.times[pane] > 1
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	out, ok := f.captures[pane]
+	if !ok {
+		return nil, fmt.Errorf("fake: no pane %s", pane)
+	}
+	if changed, ok := f.later[pane]; ok && again {
+		out = changed
+	}
+	return []byte(out), nil
+}
+
+// ran returns the commands f ran that start with prefix.
+func (f *fakeRunner) ran(prefix string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// paneLine is a list-panes line for pane id in /tmp, with title.
+func paneLine(id, title string) string {
+	return paneLineAt(id, "/tmp", title)
+}
+
+// paneLineAt is paneLine for a pane in path.
+func paneLineAt(id, path, title string) string {
+	sess := strings.SplitN(id, ":", 2)[0]
+	return strings.Join([]string{id, path, title, "claude", "claude", "1767366245", "100", "%" + sess, "1", "1"}, "\t") + "\n"
+}
+
+// fakePanes is a runner with n Claude panes, half of them working and half
+// idle at a prompt.
+func fakePanes(n int) *fakeRunner {
+	f := &fakeRunner{captures: map[string]string{}}
+	var panes strings.Builder
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("s%02d:0.0", i)
+		if i%2 == 0 {
+			panes.WriteString(paneLine(id, "⠂ task"))
+		} else {
+			panes.WriteString(paneLine(id, "✳ task"))
+		}
+		f.captures[id] = "❯ fix the tests\n\n⏺ Done.\n\n❯ \n"
+	}
+	f.panes = panes.String()
+	return f
+}
+
+// useRunner makes r the system runner for the rest of the test.
+func useRunner(t *testing.T, r CommandRunner) {
+	t.Helper()
+	saved := sysRunner
+	sysRunner = r
+	t.Cleanup(func() { sysRunner = saved })
+}
+
+// withCommand is a list-panes line with its pane_current_command set to cmd.
+func withCommand(line, cmd string) string {
+	return replaceField(line, 3, cmd)
+}
+
+// replaceField is a list-panes line with field i, counting from 0, set to
+// value.
+func replaceField(line string, i int, value string) string {
+	fields := strings.Split(line, "\t")
+	fields[i] = value
+	return strings.Join(fields, "\t")
+}
+
+func TestTolerantOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		out    string
+		ok     bool
+	}{
+		{"clean", "echo ok", "ok\n", true},
+		{"warning", "echo ok; echo 'warning: bad option' >&2", "ok\n", true},
+		{"warning exit", "echo ok; echo 'warning: bad option' >&2; exit 1", "ok\n", true},
+		{"failure", "echo 'no server running' >&2; exit 1", "", false},
+		{"blank output", "echo; exit 1", "\n", false},
+	}
+	setConfig(t, nil)
+	for _, tt := range tests {
+		out, err := tolerantOutput(execRunner{}, "sh", "-c", tt.script)
+		if string(out) != tt.out || (err == nil) != tt.ok {
+			t.Errorf("%s: %q, %v; want %q, ok %v", tt.name, out, err, tt.out, tt.ok)
+		}
+	}
+}
+
+func TestDetectDespiteWarnings(t *testing.T) {
+	setConfig(t, nil)
+	f := &fakeRunner{
+		panes:    paneLine("a:0.0", "✳ task"),
+		captures: map[string]string{"a:0.0": "❯ \n"},
+		warn:     map[string]bool{"list-panes": true, "capture-pane": true},
+	}
+	sessions, stats := detect(f)
+	if len(sessions) != 1 || !stats.listed {
+		t.Errorf("detected %d sessions, listed %v; want the pane despite the warnings", len(sessions), stats.listed)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Socket server
+//
+// csm serve keeps scanning and answers a line protocol on a Unix socket so
+// several clients share one poller:
+//
+//	LIST          one tab-separated line per session, then OK
+//	SWITCH <id>   switch-client to the pane, then OK or ERR <reason>
+//
+// Unknown commands get ERR.
+
+// socketPath returns $XDG_RUNTIME_DIR/csm.sock, falling back to /tmp/csm-<uid>.sock.
+func socketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "csm.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("csm-%d.sock", os.Getuid()))
+}
+
+// server holds the latest scan for socket clients.
+type server struct {
+	mu       sync.RWMutex
+	sessions []ClaudeSession
+	switchTo func(paneID string) error
+}
+
+func (s *server) update(sessions []ClaudeSession) {
+	s.mu.Lock()
+	s.sessions = sessions
+	s.mu.Unlock()
+}
+
+func (s *server) snapshot() []ClaudeSession {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sessions
+}
+
+// switchGuard reports why id may not be switched to: every session listed
+// with that ID is on another user's tmux server (see foreignGuard). IDs not
+// listed are left to switchTo.
+func (s *server) switchGuard(id string) error {
+	var err error
+	for _, sess := range s.snapshot() {
+		if sess.PaneID != id {
+			continue
+		}
+		if err = foreignGuard(sess); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// handle answers one request line.
+func (s *server) handle(w io.Writer, line string) {
+	verb, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	switch strings.ToUpper(verb) {
+	case "LIST":
+		for _, sess := range s.snapshot() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sess.PaneID,
+				strings.ToLower(statusLabel(sess.Status)), sess.SessionName, sess.Path, sess.Title)
+		}
+		fmt.Fprintln(w, "OK")
+	case "SWITCH":
+		id := strings.TrimSpace(arg)
+		if id == "" {
+			fmt.Fprintln(w, "ERR usage: SWITCH <pane-id>")
+			return
+		}
+		if err := s.switchGuard(id); err != nil {
+			fmt.Fprintf(w, "ERR %v\n", err)
+			return
+		}
+		if err := s.switchTo(id); err != nil {
+			fmt.Fprintf(w, "ERR %v\n", err)
+			return
+		}
+		fmt.Fprintln(w, "OK")
+	default:
+		fmt.Fprintf(w, "ERR unknown command %q\n", verb)
+	}
+}
+
+// serveConn answers requests on conn until the client disconnects.
+func (s *server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		s.handle(w, sc.Text())
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// listenUnix listens on path, replacing a stale socket left by a crashed
+// server but refusing to steal one that is still answering.
+func listenUnix(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s: another csm serve is running", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+func serveFlags() (flags *flag.FlagSet, sock *string, interval *time.Duration) {
+	flags = flag.NewFlagSet("serve", flag.ExitOnError)
+	sock = flags.String("socket", socketPath(), "unix socket `path`")
+	interval = flags.Duration("interval", time.Second, "time between scans")
+	flags.Usage = usage(flags, "csm serve [flags]",
+		"Scan continuously and answer LIST and SWITCH <id> on a Unix socket. Stop with Ctrl+C.")
+	return flags, sock, interval
+}
+
+func runServe(args []string) int {
+	flags, sock, interval := serveFlags()
+	flags.Parse(args)
+
+	ln, err := listenUnix(*sock)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &server{switchTo: func(id string) error {
+		return backend.Switch(sysRunner, id)
+	}}
+	windows := newWindowMarker(sysRunner, cfg.WaitingWindowStyle)
+	rescan := func() {
+		sessions := detectSessions(sysRunner)
+		srv.update(sessions)
+		windows.update(sessions).run(sysRunner)
+	}
+	rescan()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(*interval):
+				rescan()
+			}
+		}
+	}()
+
+	// Closing the listener on shutdown unblocks Accept.
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var conns sync.WaitGroup
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			break
+		}
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			// Idle clients are dropped so shutdown never hangs on them.
+			go func() {
+				<-ctx.Done()
+				conn.Close()
+			}()
+			srv.serveConn(conn)
+		}()
+	}
+	wg.Wait()
+	conns.Wait()
+	os.Remove(*sock)
+	if ctx.Err() == nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// startServer serves srv on a socket in a temporary directory until the
+// test ends, and returns the socket's path.
+func startServer(t *testing.T, srv *server) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "csm.sock")
+	ln, err := listenUnix(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serveConn(conn)
+		}
+	}()
+	return sock
+}
+
+// ask sends each request on one connection to sock and returns the reply
+// lines up to and including each OK or ERR.
+func ask(t *testing.T, sock string, requests ...string) []string {
+	t.Helper()
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	var replies []string
+	for _, req := range requests {
+		fmt.Fprintln(conn, req)
+		for sc.Scan() {
+			replies = append(replies, sc.Text())
+			if sc.Text() == "OK" || strings.HasPrefix(sc.Text(), "ERR") {
+				break
+			}
+		}
+	}
+	return replies
+}
+
+func TestServeProtocol(t *testing.T) {
+	var switched []string
+	srv := &server{switchTo: func(id string) error {
+		if id != "a:0.0" {
+			return errors.New("pane no longer exists")
+		}
+		switched = append(switched, id)
+		return nil
+	}}
+	srv.update([]ClaudeSession{testSession("a", StatusWaiting), testSession("b", StatusIdle)})
+	sock := startServer(t, srv)
+
+	got := ask(t, sock, "LIST", "switch a:0.0", "SWITCH gone:0.0", "SWITCH", "\nPING") // blank lines are skipped
+	want := []string{
+		"a:0.0\twaiting\ta\t~/a\ta task",
+		"b:0.0\tidle\tb\t~/b\tb task",
+		"OK",
+		"OK",
+		"ERR pane no longer exists",
+		"ERR usage: SWITCH <pane-id>",
+		`ERR unknown command "PING"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("replies:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if fmt.Sprint(switched) != "[a:0.0]" {
+		t.Errorf("switched to %v", switched)
+	}
+}
+
+func TestServeSwitchForeign(t *testing.T) {
+	var switched []string
+	srv := &server{switchTo: func(id string) error {
+		switched = append(switched, id)
+		return nil
+	}}
+	theirs := testSession("theirs", StatusWaiting)
+	theirs.Socket, theirs.Owner = "/tmp/tmux-1001/default", "bob"
+	shared := theirs
+	shared.SessionName, shared.PaneID = "a", "a:0.0" // same ID as a local pane
+	srv.update([]ClaudeSession{theirs, shared, testSession("a", StatusIdle)})
+	sock := startServer(t, srv)
+
+	got := ask(t, sock, "SWITCH theirs:0.0", "SWITCH a:0.0")
+	want := []string{
+		"ERR theirs is on bob's tmux server; attach with: tmux -S /tmp/tmux-1001/default attach -t theirs",
+		"OK",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("replies:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if fmt.Sprint(switched) != "[a:0.0]" {
+		t.Errorf("switched to %v, want only the local pane", switched)
+	}
+}
+
+func TestServeConcurrentClients(t *testing.T) {
+	srv := &server{switchTo: func(string) error { return nil }}
+	sock := startServer(t, srv)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			srv.update([]ClaudeSession{testSession("a", StatusIdle)})
+			if got := ask(t, sock, "LIST"); len(got) == 0 || got[len(got)-1] != "OK" {
+				t.Errorf("LIST = %q", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestListenUnix(t *testing.T) {
+	sock := startServer(t, &server{})
+	if _, err := listenUnix(sock); err == nil {
+		t.Error("listenUnix took the socket of a running server")
+	}
+
+	stale := filepath.Join(t.TempDir(), "stale.sock")
+	if err := os.WriteFile(stale, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := listenUnix(stale)
+	if err != nil {
+		t.Fatalf("listenUnix over a stale socket: %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Session-level info: attached clients (show_clients) and session groups
+//
+// session_attached and session_group are session-level values, so they
+// come from one list-sessions call per scan, joined to panes by session
+// name.
+
+// sessionInfo is what list-sessions reports for one session.
+type sessionInfo struct {
+	attached int
+	group    string // "" unless the session is in a group
+}
+
+// listSessions maps session names to their info.
+func listSessions(r CommandRunner) (map[string]sessionInfo, error) {
+	out, err := tolerantOutput(r, "tmux", "list-sessions", "-F", "#{session_name}\t#{session_attached}\t#{session_group}")
+	if err != nil {
+		return nil, err
+	}
+	return parseSessions(string(out)), nil
+}
+
+// parseSessions parses list-sessions lines of name, attached count and
+// optional group, skipping malformed ones.
+func parseSessions(out string) map[string]sessionInfo {
+	infos := map[string]sessionInfo{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			continue
+		}
+		info := sessionInfo{attached: count}
+		if len(fields) > 2 {
+			info.group = fields[2]
+		}
+		infos[fields[0]] = info
+	}
+	return infos
+}
+
+// joinClients sets Clients on each session from infos by SessionName.
+func joinClients(sessions []ClaudeSession, infos map[string]sessionInfo) {
+	for i := range sessions {
+		sessions[i].Clients = infos[sessions[i].SessionName].attached
+	}
+}
+
+// dedupGroups keeps one candidate per pane shared by a session group:
+// the one under the group member whose name sorts first, so a shared pane
+// always lists and switches under the same member. Panes of ungrouped
+// sessions pass through.
+func dedupGroups(candidates []paneInfo, infos map[string]sessionInfo) []paneInfo {
+	kept := map[string]int{} // group and window.pane → index in out
+	var out []paneInfo
+	for _, c := range candidates {
+		group := infos[c.sess].group
+		if group == "" {
+			out = append(out, c)
+			continue
+		}
+		c.group = group
+		_, pane, _ := strings.Cut(c.id, ":")
+		key := group + "\x00" + pane
+		if i, ok := kept[key]; ok {
+			if c.sess < out[i].sess {
+				out[i] = c
+			}
+			continue
+		}
+		kept[key] = len(out)
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSessions(t *testing.T) {
+	out := "api\t2\t\nweb\t0\nshared-1\t1\tshared\nbroken\nbad\tmany\n"
+	want := map[string]sessionInfo{
+		"api":      {attached: 2},
+		"web":      {attached: 0},
+		"shared-1": {attached: 1, group: "shared"},
+	}
+	if got := parseSessions(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSessions = %+v, want %+v", got, want)
+	}
+	if got := parseSessions(""); len(got) != 0 {
+		t.Errorf("parseSessions of nothing = %+v", got)
+	}
+}
+
+func TestJoinClients(t *testing.T) {
+	f := &fakeRunner{
+		panes:    paneLine("api:0.0", "✳ task") + paneLine("web:0.0", "✳ task") + paneLine("new:0.0", "✳ task"),
+		captures: map[string]string{"api:0.0": "❯ \n", "web:0.0": "❯ \n", "new:0.0": "❯ \n"},
+		// new started after list-sessions ran, so it has no entry
+		sessions: "api\t2\nweb\t0\n",
+	}
+	for _, show := range []bool{false, true} {
+		setConfig(t, func(c *Config) { c.ShowClients = show })
+		sessions, _ := detect(f)
+		got := map[string]int{}
+		for _, s := range sessions {
+			got[s.SessionName] = s.Clients
+		}
+		want := map[string]int{"api": 0, "web": 0, "new": 0}
+		if show {
+			want["api"] = 2
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("show_clients %v: clients %v, want %v", show, got, want)
+		}
+	}
+}
+
+func TestClientsIndicator(t *testing.T) {
+	setConfig(t, nil)
+	watched := testSession("api", StatusIdle)
+	watched.Clients = 2
+	m := update(newModel(), scanned(watched, testSession("web", StatusIdle)))
+	if got := m.cell("session", 0, watched, 0); got != "api ◉2" {
+		t.Errorf("session cell %q, want api ◉2", got)
+	}
+	if got := m.cell("session", 1, m.sessions[1], 0); got != "web" {
+		t.Errorf("session cell %q, want no indicator", got)
+	}
+}
+
+func TestDedupGroups(t *testing.T) {
+	infos := map[string]sessionInfo{"work-2": {group: "work"}, "work-1": {group: "work"}, "solo": {}}
+	candidates := []paneInfo{
+		{id: "work-2:0.0", sess: "work-2"},
+		{id: "solo:0.0", sess: "solo"},
+		{id: "work-1:0.0", sess: "work-1"},
+		{id: "work-2:1.0", sess: "work-2"},
+		{id: "work-1:1.0", sess: "work-1"},
+		{id: "other:0.0", sess: "other"}, // not in list-sessions: ungrouped
+	}
+	var got []string
+	for _, c := range dedupGroups(candidates, infos) {
+		got = append(got, c.id+"@"+c.group)
+	}
+	// One per shared pane, under the member that sorts first.
+	want := []string{"work-1:0.0@work", "solo:0.0@", "work-1:1.0@work", "other:0.0@"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupGroups = %q, want %q", got, want)
+	}
+}
+
+func TestGroupedSessionsListOnce(t *testing.T) {
+	setConfig(t, nil)
+	f := &fakeRunner{
+		panes:    paneLine("work-2:0.0", "✳ task") + paneLine("work-1:0.0", "✳ task") + paneLine("solo:0.0", "✳ task"),
+		captures: map[string]string{"work-1:0.0": "❯ \n", "work-2:0.0": "❯ \n", "solo:0.0": "❯ \n"},
+		sessions: "work-1\t1\twork\nwork-2\t0\twork\nsolo\t0\t\n",
+	}
+	sessions, _ := detect(f)
+	var got []string
+	for _, s := range sessions {
+		got = append(got, s.PaneID+"@"+s.Group)
+	}
+	if want := []string{"solo:0.0@", "work-1:0.0@work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("detected %q, want %q", got, want)
+	}
+	if len(f.ran("tmux capture-pane")) != 2 {
+		t.Errorf("captured %q, want the shared pane once", f.ran("tmux capture-pane"))
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sort modes
+
+const (
+	sortPane   = "pane"   // by PaneID, as detected
+	sortAge    = "age"    // oldest tmux session first
+	sortManual = "manual" // the order arranged with the m key; see reorder.go
+)
+
+// sortModes lists the modes in the order the s key cycles through them.
+var sortModes = []string{sortPane, sortAge, sortManual}
+
+func validSortMode(mode string) bool {
+	for _, m := range sortModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// nextSortMode returns the mode after mode in sortModes, wrapping around.
+func nextSortMode(mode string) string {
+	for i, m := range sortModes {
+		if m == mode {
+			return sortModes[(i+1)%len(sortModes)]
+		}
+	}
+	return sortModes[0]
+}
+
+// sortSessions orders sessions in place by mode, with sessions whose Path is
+// pinned above the rest. order is the manual ordering of paths, used by
+// sortManual. Ties, and sessions whose creation time is unknown or whose
+// path is not in order, fall back to PaneID order.
+func sortSessions(sessions []ClaudeSession, mode string, pinned map[string]bool, order []string) {
+	var rank map[string]int
+	if mode == sortManual {
+		rank = manualRank(order)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if pi, pj := pinned[sessions[i].Path], pinned[sessions[j].Path]; pi != pj {
+			return pi
+		}
+		if mode == sortManual {
+			return lessManual(sessions[i], sessions[j], rank)
+		}
+		return lessByMode(sessions[i], sessions[j], mode)
+	})
+}
+
+func lessByMode(a, b ClaudeSession, mode string) bool {
+	if mode == sortAge && !a.Created.Equal(b.Created) {
+		// Unknown creation times sort last.
+		switch {
+		case a.Created.IsZero():
+			return false
+		case b.Created.IsZero():
+			return true
+		}
+		return a.Created.Before(b.Created)
+	}
+	return a.PaneID < b.PaneID
+}
+
+// parseTmuxTime parses a tmux time format field such as #{session_created}.
+// tmux prints Unix seconds; empty or malformed values (older servers, or
+// a field the server does not know) yield the zero time.
+func parseTmuxTime(field string) time.Time {
+	secs, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// formatAge renders d compactly: 45s, 12m, 3h, 2d.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
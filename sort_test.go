@@ -0,0 +1,132 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTmuxTime(t *testing.T) {
+	tests := []struct {
+		field string
+		want  time.Time
+	}{
+		{"1767366245", time.Unix(1767366245, 0)},
+		{" 1767366245\n", time.Unix(1767366245, 0)},
+		{"", time.Time{}},                   // older tmux: the field is blank
+		{"#{session_created}", time.Time{}}, // or printed verbatim
+		{"0", time.Time{}},
+		{"-5", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := parseTmuxTime(tt.field); !got.Equal(tt.want) {
+			t.Errorf("parseTmuxTime(%q) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+}
+
+// sessionsCreated returns sessions named by names, created at the given
+// Unix seconds; 0 means unknown.
+func sessionsCreated(names string, created ...int64) []ClaudeSession {
+	var out []ClaudeSession
+	for i, name := range strings.Fields(names) {
+		s := testSession(name, StatusIdle)
+		if created[i] > 0 {
+			s.Created = time.Unix(created[i], 0)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func names(sessions []ClaudeSession) string {
+	var out []string
+	for _, s := range sessions {
+		out = append(out, s.SessionName)
+	}
+	return strings.Join(out, " ")
+}
+
+func TestSortByAge(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{sortAge, "b d a c e"}, // oldest first, ties by PaneID, unknown last
+		{sortPane, "a b c d e"},
+	}
+	for _, tt := range tests {
+		sessions := sessionsCreated("c a b e d", 0, 300, 100, 0, 100)
+		sortSessions(sessions, tt.mode, nil, nil)
+		if got := names(sessions); got != tt.want {
+			t.Errorf("sort %s: %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 59*time.Second, "12m"},
+		{3 * time.Hour, "3h"},
+		{50 * time.Hour, "2d"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestNextSortMode(t *testing.T) {
+	mode := sortPane
+	for range sortModes {
+		mode = nextSortMode(mode)
+	}
+	if mode != sortPane {
+		t.Errorf("cycling every mode ended on %q", mode)
+	}
+	if got := nextSortMode("bogus"); got != sortPane {
+		t.Errorf("nextSortMode(bogus) = %q", got)
+	}
+}
+
+func TestPinnedSortFirst(t *testing.T) {
+	sessions := sessionsCreated("a b c d", 400, 300, 200, 100)
+	pinned := map[string]bool{sessions[0].Path: true, sessions[2].Path: true}
+	sortSessions(sessions, sortAge, pinned, nil)
+	if got := names(sessions); got != "c a d b" {
+		t.Errorf("pinned sort: %q, want c a d b", got)
+	}
+}
+
+func TestPinKeySurvivesRestart(t *testing.T) {
+	setConfig(t, nil)
+	a, b := testSession("a", StatusIdle), testSession("b", StatusIdle)
+	m := update(newModel(), scanned(a, b), press("j"), press("*"))
+	if got := names(m.sessions); got != "b a" {
+		t.Fatalf("after pinning b: %q, want b a", got)
+	}
+	file := filepath.Join(t.TempDir(), "state.json")
+	if err := saveState(file, m.state()); err != nil {
+		t.Fatal(err)
+	}
+	restarted := newModel()
+	restarted.applyState(loadState(file))
+	restarted = update(restarted, scanned(a, b))
+	if got := names(restarted.sessions); got != "b a" {
+		t.Errorf("after a restart: %q, want b a", got)
+	}
+	rows := restarted.renderRows()
+	if !strings.Contains(rows[0], "★ b task") || strings.Contains(rows[1], "★") {
+		t.Errorf("pin glyphs:\n%s", strings.Join(rows, "\n"))
+	}
+	restarted = update(restarted, press("*"))
+	if got := names(restarted.sessions); got != "a b" {
+		t.Errorf("after unpinning: %q, want a b", got)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Attention sound (sound_file)
+
+// soundPlayer returns the command that plays file on goos.
+func soundPlayer(goos, file string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "afplay", []string{file}
+	case "windows":
+		quoted := "'" + strings.ReplaceAll(file, "'", "''") + "'"
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command",
+			"(New-Object Media.SoundPlayer " + quoted + ").PlaySync()"}
+	default:
+		return "paplay", []string{file}
+	}
+}
+
+// playSound plays sound_file once for sessions that just started waiting,
+// without waiting for the player. It shares the alert debounce and mutes;
+// a missing file or player goes to the debug log.
+func playSound(sessions []ClaudeSession) tea.Cmd {
+	if len(sessions) == 0 || cfg.SoundFile == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		file := expandPath(cfg.SoundFile)
+		if _, err := os.Stat(file); err != nil {
+			debugLog.Printf("sound_file: %v", err)
+			return nil
+		}
+		name, args := soundPlayer(runtime.GOOS, file)
+		cmd := exec.Command(name, args...)
+		if err := cmd.Start(); err != nil {
+			debugLog.Printf("sound_file: %s: %v", name, err)
+			return nil
+		}
+		go func() {
+			if err := cmd.Wait(); err != nil {
+				debugLog.Printf("sound_file: %s: %v", name, err)
+			}
+		}()
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSoundPlayer(t *testing.T) {
+	tests := []struct {
+		goos, file string
+		name       string
+		args       []string
+	}{
+		{"linux", "/s/ding.wav", "paplay", []string{"/s/ding.wav"}},
+		{"freebsd", "/s/ding.wav", "paplay", []string{"/s/ding.wav"}},
+		{"darwin", "/s/ding.aiff", "afplay", []string{"/s/ding.aiff"}},
+		{"windows", `C:\it's.wav`, "powershell", []string{"-NoProfile", "-NonInteractive", "-Command",
+			`(New-Object Media.SoundPlayer 'C:\it''s.wav').PlaySync()`}}, // quotes doubled inside '...'
+	}
+	for _, tt := range tests {
+		name, args := soundPlayer(tt.goos, tt.file)
+		if name != tt.name || !slices.Equal(args, tt.args) {
+			t.Errorf("soundPlayer(%q, %q) = %s %q, want %s %q", tt.goos, tt.file, name, args, tt.name, tt.args)
+		}
+	}
+}
+
+func TestPlaySoundSkips(t *testing.T) {
+	waiting := []ClaudeSession{testSession("a", StatusWaiting)}
+	missing := filepath.Join(t.TempDir(), "gone.wav")
+	tests := []struct {
+		file     string
+		sessions []ClaudeSession
+		cmd      bool
+	}{
+		{"", waiting, false}, // no sound_file, or --no-sound
+		{missing, nil, false},
+		{missing, waiting, true},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.SoundFile = tt.file })
+		cmd := playSound(tt.sessions)
+		if (cmd != nil) != tt.cmd {
+			t.Errorf("sound_file %q, %d sessions: cmd %v, want %v", tt.file, len(tt.sessions), cmd != nil, tt.cmd)
+			continue
+		}
+		// A missing file is logged, not played.
+		if cmd != nil && cmd() != nil {
+			t.Errorf("sound_file %q: unexpected message", tt.file)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// State is the interactive UI state remembered between runs. The config file
+// supplies defaults; the state file remembers what was last chosen.
+type State struct {
+	MinStatus  string   `json:"min_status,omitempty"`
+	Sort       string   `json:"sort,omitempty"`
+	Muted      []string `json:"muted,omitempty"`       // paths of muted sessions
+	Pinned     []string `json:"pinned,omitempty"`      // paths of pinned sessions
+	Recent     []string `json:"recent,omitempty"`      // PaneIDs last switched to, newest first
+	Order      []string `json:"order,omitempty"`       // paths in the manual sort order
+	TitleWidth int      `json:"title_width,omitempty"` // title budget set with < and >; 0 is automatic
+	Hidden     []string `json:"hidden,omitempty"`      // statuses hidden with f
+	StatusAge  bool     `json:"status_age,omitempty"`  // T: age column shows time in status
+}
+
+// statePath returns $XDG_STATE_HOME/csm/state.json, falling back to ~/.local/state.
+func statePath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "csm", "state.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "csm", "state.json")
+}
+
+// loadState reads the state file. A missing or unreadable file yields an empty
+// state: the remembered UI state is a convenience, never a reason to fail.
+func loadState(path string) State {
+	var st State
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st
+	}
+	if json.Unmarshal(data, &st) != nil {
+		return State{}
+	}
+	return st
+}
+
+// saveState writes st to path, creating the directory if needed.
+func saveState(path string, st State) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// resetState removes the state file.
+func resetState(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// state captures the model's persistable UI state.
+func (m model) state() State {
+	return State{
+		MinStatus:  minStatusName(m.minStatus),
+		Sort:       m.sortMode,
+		Muted:      sortedKeys(m.muted),
+		Pinned:     sortedKeys(m.pinned),
+		Recent:     m.recent,
+		Order:      m.order,
+		TitleWidth: m.titleWidth,
+		Hidden:     hiddenNames(m.hidden),
+		StatusAge:  m.statusAge,
+	}
+}
+
+// applyState restores UI state saved by a previous run, ignoring invalid values.
+func (m *model) applyState(st State) {
+	if min, err := parseMinStatus(st.MinStatus); err == nil {
+		m.minStatus = min
+	}
+	if validSortMode(st.Sort) {
+		m.sortMode = st.Sort
+	}
+	for _, p := range st.Muted {
+		m.muted[p] = true
+	}
+	for _, p := range st.Pinned {
+		m.pinned[p] = true
+	}
+	m.recent = st.Recent
+	m.order = st.Order
+	if st.TitleWidth >= minTitleWidth {
+		m.titleWidth = st.TitleWidth
+	}
+	for _, name := range st.Hidden {
+		if s, err := parseMinStatus(name); err == nil {
+			m.hidden[s] = true
+		}
+	}
+	m.statusAge = st.StatusAge
+}
+
+// hiddenNames returns the names of the hidden statuses, in order.
+func hiddenNames(hidden map[int]bool) []string {
+	var names []string
+	for _, s := range []int{StatusWorking, StatusWaiting, StatusIdle} {
+		if hidden[s] {
+			names = append(names, minStatusName(s))
+		}
+	}
+	return names
+}
+
+// maxRecent is how many switched-to panes are remembered: the two that
+// the - key toggles between.
+const maxRecent = 2
+
+// pushRecent records a switch to pane, newest first.
+func pushRecent(recent []string, pane string) []string {
+	out := []string{pane}
+	for _, id := range recent {
+		if id != pane && len(out) < maxRecent {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// lastSession returns the most recently switched-to session other than
+// current, the pane being viewed. If none of them still exists it falls
+// back to the first listed session other than current.
+func lastSession(recent []string, current string, all, listed []ClaudeSession) (ClaudeSession, bool) {
+	for _, id := range recent {
+		if id == current {
+			continue
+		}
+		for _, s := range all {
+			if s.PaneID == id {
+				return s, true
+			}
+		}
+	}
+	for _, s := range listed {
+		if s.PaneID != current {
+			return s, true
+		}
+	}
+	return ClaudeSession{}, false
+}
+
+// sortedKeys returns the set members of m in order, for stable state files.
+func sortedKeys(m map[string]bool) []string {
+	var out []string
+	for k, v := range m {
+		if v {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
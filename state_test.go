@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPushRecent(t *testing.T) {
+	tests := []struct {
+		recent []string
+		pane   string
+		want   []string
+	}{
+		{nil, "a", []string{"a"}},
+		{[]string{"a"}, "b", []string{"b", "a"}},
+		{[]string{"b", "a"}, "c", []string{"c", "b"}}, // only the last two are kept
+		{[]string{"b", "a"}, "a", []string{"a", "b"}}, // toggling swaps them
+		{[]string{"a", "b"}, "a", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := pushRecent(tt.recent, tt.pane); !slices.Equal(got, tt.want) {
+			t.Errorf("pushRecent(%q, %q) = %q, want %q", tt.recent, tt.pane, got, tt.want)
+		}
+	}
+}
+
+func TestLastSession(t *testing.T) {
+	a, b, c := testSession("a", StatusIdle), testSession("b", StatusIdle), testSession("c", StatusIdle)
+	all := []ClaudeSession{a, b, c}
+	tests := []struct {
+		name    string
+		recent  []string
+		current string
+		listed  []ClaudeSession
+		want    string
+		ok      bool
+	}{
+		{"toggle", []string{"a:0.0", "b:0.0"}, "a:0.0", all, "b", true},
+		{"not viewing either", []string{"a:0.0", "b:0.0"}, "c:0.0", all, "a", true},
+		// b is hidden by a filter, but still the one to go back to
+		{"filtered out", []string{"a:0.0", "b:0.0"}, "a:0.0", []ClaudeSession{a, c}, "b", true},
+		{"closed", []string{"a:0.0", "gone:0.0"}, "a:0.0", all, "b", true}, // top of the list, skipping current
+		{"nothing else", nil, "a:0.0", []ClaudeSession{a}, "", false},
+	}
+	for _, tt := range tests {
+		s, ok := lastSession(tt.recent, tt.current, all, tt.listed)
+		if ok != tt.ok || s.SessionName != tt.want {
+			t.Errorf("%s: lastSession = %q, %v; want %q, %v", tt.name, s.SessionName, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRecentSurvivesRestart(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusIdle)),
+		selectMsg{pane: "a:0.0"})
+	m.recent = pushRecent(m.recent, "b:0.0")
+	file := filepath.Join(t.TempDir(), "state.json")
+	if err := saveState(file, m.state()); err != nil {
+		t.Fatal(err)
+	}
+	restarted := newModel()
+	restarted.applyState(loadState(file))
+	if !slices.Equal(restarted.recent, []string{"b:0.0", "a:0.0"}) {
+		t.Errorf("after a restart, recent = %q", restarted.recent)
+	}
+}
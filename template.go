@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Command templates
+
+// placeholderRe matches {name} placeholders in command templates.
+var placeholderRe = regexp.MustCompile(`\{([a-z_]*)\}`)
+
+// sessionPlaceholders are the placeholders available to session templates.
+var sessionPlaceholders = map[string]func(ClaudeSession) string{
+	"pane":   func(s ClaudeSession) string { return s.PaneID },
+	"path":   func(s ClaudeSession) string { return expandPath(s.Path) },
+	"name":   func(s ClaudeSession) string { return s.SessionName },
+	"prompt": func(s ClaudeSession) string { return s.Prompt },
+}
+
+// validateTemplate reports placeholders in tmpl that are not in known.
+func validateTemplate[T any](tmpl string, known map[string]T) error {
+	for _, m := range placeholderRe.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := known[m[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {%s}", m[1])
+		}
+	}
+	return nil
+}
+
+// expandTemplate substitutes session fields into tmpl. Values are shell
+// quoted, since the result is run with sh -c.
+func expandTemplate(tmpl string, s ClaudeSession) string {
+	return placeholderRe.ReplaceAllStringFunc(tmpl, func(p string) string {
+		if f, ok := sessionPlaceholders[p[1:len(p)-1]]; ok {
+			return shellQuote(f(s))
+		}
+		return p
+	})
+}
+
+// shellQuote wraps s in single quotes for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// plainWordRe matches words a POSIX shell reads as is.
+var plainWordRe = regexp.MustCompile(`^[A-Za-z0-9_./:%@=+,-]+$`)
+
+// shellJoin joins words into a shell command line, quoting only the
+// words that need it.
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = w
+		if !plainWordRe.MatchString(w) {
+			quoted[i] = shellQuote(w)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
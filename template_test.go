@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	s := testSession("api", StatusIdle)
+	s.Path = "~/it's here"
+	tests := []struct{ tmpl, want string }{
+		{"tmux switch-client -t {pane}", "tmux switch-client -t 'api:0.0'"},
+		{"code {path}", `code '/home/me/it'\''s here'`},
+		{"echo {name} {name}", "echo 'api' 'api'"},
+		{"echo {unknown}", "echo {unknown}"},
+		{"no placeholders", "no placeholders"},
+	}
+	for _, tt := range tests {
+		if got := expandTemplate(tt.tmpl, s); got != tt.want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplateQuotesForSh(t *testing.T) {
+	s := testSession("api", StatusIdle)
+	s.Prompt = `say "hi" $HOME; it's ` + "`x`"
+	out, err := exec.Command("sh", "-c", expandTemplate("printf %s {prompt}", s)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != s.Prompt {
+		t.Errorf("sh read %q, want %q", out, s.Prompt)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	if err := validateTemplate("open {path} {pane}", sessionPlaceholders); err != nil {
+		t.Error(err)
+	}
+	err := validateTemplate("open {dir}", sessionPlaceholders)
+	if err == nil || !strings.Contains(err.Error(), "{dir}") {
+		t.Errorf("validateTemplate({dir}) = %v, want an unknown placeholder error", err)
+	}
+	c := defaultConfig()
+	c.OnSelect = "log {title}"
+	if err := c.validate(); err == nil {
+		t.Error("validate accepted on_select with an unknown placeholder")
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	got := shellJoin([]string{"tmux", "-L", "my server", "switch-client", "-t", "%3", "it's"})
+	if want := `tmux -L 'my server' switch-client -t %3 'it'\''s'`; got != want {
+		t.Errorf("shellJoin = %q, want %q", got, want)
+	}
+}
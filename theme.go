@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Themes
+
+// palette maps style keys to lipgloss colors (ANSI numbers or #hex).
+type palette map[string]string
+
+// paletteKeys lists every key a theme must define and colors may override.
+var paletteKeys = []string{
+	"working",     // status symbol and label
+	"waiting",     // status symbol and label
+	"idle",        // status symbol and label
+	"selected_bg", // background of the cursor row
+	"flash_bg",    // background of a row flashing after it starts waiting
+	"dim",         // secondary text and hints
+	"title_text",  // session titles
+	"help",        // help line
+	"warning",     // missing paths
+}
+
+// themes are the built-in presets. "dark" is the default.
+var themes = map[string]palette{
+	"dark": {
+		"working":     "76",  // green
+		"waiting":     "214", // amber
+		"idle":        "242", // gray
+		"selected_bg": "236",
+		"flash_bg":    "94",
+		"dim":         "242",
+		"title_text":  "245",
+		"help":        "242",
+		"warning":     "167",
+	},
+	"light": {
+		"working":     "28",  // dark green
+		"waiting":     "166", // dark orange
+		"idle":        "240", // dark gray, readable on white
+		"selected_bg": "254",
+		"flash_bg":    "223",
+		"dim":         "243",
+		"title_text":  "238",
+		"help":        "243",
+		"warning":     "160",
+	},
+	"high-contrast": {
+		"working":     "46",  // bright green
+		"waiting":     "226", // bright yellow
+		"idle":        "255", // white
+		"selected_bg": "240",
+		"flash_bg":    "136",
+		"dim":         "250",
+		"title_text":  "255",
+		"help":        "250",
+		"warning":     "196",
+	},
+	// cb-safe uses the Okabe-Ito blue and orange, which stay apart under
+	// the common color vision deficiencies.
+	"cb-safe": {
+		"working":     "#0072B2", // blue
+		"waiting":     "#E69F00", // orange
+		"idle":        "245",     // gray
+		"selected_bg": "236",
+		"flash_bg":    "#5C3F00",
+		"dim":         "242",
+		"title_text":  "250",
+		"help":        "242",
+		"warning":     "#D55E00", // vermillion
+	},
+}
+
+// Status glyphs. The default set tells statuses apart by fill, which reads
+// well in color; shapeGlyphs differ in outline too, so they never depend
+// on hue.
+var (
+	fillGlyphs  = map[int]string{StatusWorking: "●", StatusWaiting: "◐", StatusIdle: "○", StatusExited: "✕"}
+	shapeGlyphs = map[int]string{StatusWorking: "▶", StatusWaiting: "◆", StatusIdle: "○", StatusExited: "✕"}
+)
+
+// shapeThemes use shapeGlyphs.
+var shapeThemes = map[string]bool{"high-contrast": true, "cb-safe": true}
+
+// statusGlyphs is the glyph set of the active theme.
+var statusGlyphs = fillGlyphs
+
+func init() {
+	applyTheme("dark", nil)
+}
+
+// themeNames returns the preset names, sorted.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTheme checks that name is a preset and overrides only use known keys.
+func validateTheme(name string, overrides map[string]string) error {
+	if _, ok := themes[name]; !ok {
+		return fmt.Errorf("theme: want one of %v, got %q", themeNames(), name)
+	}
+	for key := range overrides {
+		if !isPaletteKey(key) {
+			return fmt.Errorf("colors: unknown key %q (want one of %v)", key, paletteKeys)
+		}
+	}
+	return nil
+}
+
+func isPaletteKey(key string) bool {
+	for _, k := range paletteKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTheme sets all styles from the named preset with per-key overrides
+// on top. Callers validate first; unknown names fall back to dark.
+func applyTheme(name string, overrides map[string]string) {
+	p := palette{}
+	base, ok := themes[name]
+	if !ok {
+		base = themes["dark"]
+	}
+	for k, v := range base {
+		p[k] = v
+	}
+	for k, v := range overrides {
+		p[k] = v
+	}
+	fg := func(key string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(p[key]))
+	}
+
+	titleStyle = lipgloss.NewStyle().Bold(true).MarginBottom(1).MarginLeft(2)
+	selectedRow = lipgloss.NewStyle().Background(lipgloss.Color(p["selected_bg"]))
+	flashRow = lipgloss.NewStyle().Background(lipgloss.Color(p["flash_bg"]))
+	dimStyle = fg("dim")
+	dimTitleStyle = fg("title_text")
+	helpStyle = fg("help").MarginTop(1).MarginLeft(2)
+	missingPathStyle = fg("warning").Strikethrough(true)
+	statusGlyphs = fillGlyphs
+	if shapeThemes[name] {
+		statusGlyphs = shapeGlyphs
+	}
+	statusStyles = map[int]lipgloss.Style{
+		StatusWorking: fg("working"),
+		StatusWaiting: fg("waiting"),
+		StatusIdle:    fg("idle"),
+		StatusExited:  fg("dim"),
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestThemesDefineEveryKey(t *testing.T) {
+	for name, p := range themes {
+		for _, key := range paletteKeys {
+			if p[key] == "" {
+				t.Errorf("theme %s: no %s color", name, key)
+			}
+		}
+		for key := range p {
+			if !isPaletteKey(key) {
+				t.Errorf("theme %s: unknown key %s", name, key)
+			}
+		}
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	t.Cleanup(func() { applyTheme("dark", nil) })
+	tests := []struct {
+		theme     string
+		overrides map[string]string
+		waiting   lipgloss.Color
+		glyph     string
+	}{
+		{"dark", nil, "214", "◐"},
+		{"light", nil, "166", "◐"},
+		{"light", map[string]string{"waiting": "#ff0000"}, "#ff0000", "◐"}, // colors win over the preset
+		{"cb-safe", nil, "#E69F00", "◆"},
+		{"nope", nil, "214", "◐"}, // unknown names fall back to dark
+	}
+	for _, tt := range tests {
+		applyTheme(tt.theme, tt.overrides)
+		if got := statusStyles[StatusWaiting].GetForeground(); got != tt.waiting {
+			t.Errorf("%s %v: waiting color %v, want %v", tt.theme, tt.overrides, got, tt.waiting)
+		}
+		if got := statusGlyphs[StatusWaiting]; got != tt.glyph {
+			t.Errorf("%s: waiting glyph %q, want %q", tt.theme, got, tt.glyph)
+		}
+	}
+}
+
+func TestValidateTheme(t *testing.T) {
+	tests := []struct {
+		theme     string
+		overrides map[string]string
+		ok        bool
+	}{
+		{"dark", nil, true},
+		{"high-contrast", map[string]string{"idle": "250"}, true},
+		{"solarized", nil, false},
+		{"dark", map[string]string{"background": "0"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateTheme(tt.theme, tt.overrides); (err == nil) != tt.ok {
+			t.Errorf("validateTheme(%q, %v) = %v", tt.theme, tt.overrides, err)
+		}
+	}
+}
+
+func TestShapeThemesMarkStatusWithoutColor(t *testing.T) {
+	t.Cleanup(func() { applyTheme("dark", nil) })
+	for name := range shapeThemes {
+		setConfig(t, func(c *Config) { c.Theme = name })
+		applyTheme(name, nil)
+		m := update(newModel(), scanned(testSession("a", StatusWorking), testSession("b", StatusWaiting), testSession("c", StatusIdle)))
+		seen := map[string]int{}
+		for i, row := range m.renderRows() {
+			// Tests render without color: the glyph and label alone must
+			// set the rows apart.
+			fields := strings.Fields(strings.TrimLeft(row, " ▸•"))
+			marker := fields[1] + " " + fields[2]
+			if prev, ok := seen[marker]; ok {
+				t.Errorf("%s: rows %d and %d share the marker %q", name, prev, i, marker)
+			}
+			seen[marker] = i
+		}
+		glyphs := map[string]bool{}
+		for _, status := range []int{StatusWorking, StatusWaiting, StatusIdle} {
+			glyphs[statusSymbol(status)] = true
+		}
+		if len(glyphs) != 3 {
+			t.Errorf("%s: status glyphs %v are not all different", name, statusGlyphs)
+		}
+	}
+}
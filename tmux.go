@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// tmux invocation
+//
+// Every tmux call goes through tmuxArgv, so tmux_bin and tmux_args (such as
+// "-L work" for a separate server) apply to detection and actions alike.
+
+// tmuxArgv returns the binary and full argument list for tmux args.
+func tmuxArgv(args []string) (string, []string) {
+	bin := cfg.TmuxBin
+	if bin == "" {
+		bin = "tmux"
+	}
+	return bin, append(append([]string(nil), cfg.TmuxArgs...), args...)
+}
+
+// tmuxCommand is exec.Command for tmux with the configured binary and args.
+func tmuxCommand(args ...string) *exec.Cmd {
+	bin, argv := tmuxArgv(args)
+	return exec.Command(bin, argv...)
+}
+
+// checkTmux reports a clear error when the configured binary is missing.
+func checkTmux() error {
+	bin, _ := tmuxArgv(nil)
+	if _, err := exec.LookPath(bin); err != nil {
+		return fmt.Errorf("tmux_bin: %q not found (install tmux or set tmux_bin to its path)", bin)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTmuxArgv(t *testing.T) {
+	tests := []struct {
+		bin    string
+		args   []string
+		binOut string
+		argv   []string
+	}{
+		{"", nil, "tmux", []string{"list-panes", "-a"}},
+		{"/opt/tmux/bin/tmux", nil, "/opt/tmux/bin/tmux", []string{"list-panes", "-a"}},
+		{"", []string{"-L", "work"}, "tmux", []string{"-L", "work", "list-panes", "-a"}},
+		{"tmux-next", []string{"-S", "/tmp/sock", "-u"}, "tmux-next", []string{"-S", "/tmp/sock", "-u", "list-panes", "-a"}},
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.TmuxBin, c.TmuxArgs = tt.bin, tt.args })
+		bin, argv := tmuxArgv([]string{"list-panes", "-a"})
+		if bin != tt.binOut || !slices.Equal(argv, tt.argv) {
+			t.Errorf("bin %q, args %q: tmuxArgv = %q %q; want %q %q", tt.bin, tt.args, bin, argv, tt.binOut, tt.argv)
+		}
+	}
+}
+
+func TestTmuxArgvLeavesConfigAlone(t *testing.T) {
+	setConfig(t, func(c *Config) { c.TmuxArgs = make([]string, 2, 8) })
+	cfg.TmuxArgs[0], cfg.TmuxArgs[1] = "-L", "work"
+	_, a := tmuxArgv([]string{"a"})
+	_, b := tmuxArgv([]string{"b"})
+	if a[2] != "a" || b[2] != "b" || len(cfg.TmuxArgs) != 2 {
+		t.Errorf("calls share tmux_args' backing array: %q %q", a, b)
+	}
+}
+
+// fakeTmux installs a tmux_bin that prints its arguments, one per line.
+func fakeTmux(t *testing.T, args ...string) {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "tmux")
+	script := "#!/bin/sh\nfor a in \"$@\"; do echo \"$a\"; done\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	setConfig(t, func(c *Config) { c.TmuxBin, c.TmuxArgs = bin, args })
+}
+
+func TestTmuxSettingsReachEveryCall(t *testing.T) {
+	fakeTmux(t, "-L", "work")
+	want := "-L\nwork\nlist-panes\n-a\n"
+	if out, err := (execRunner{}).Output("tmux", "list-panes", "-a"); err != nil || string(out) != want {
+		t.Errorf("execRunner ran %q, %v; want %q", out, err, want)
+	}
+	if out, err := tmuxCommand("kill-pane", "-t", "a:0.0").Output(); err != nil || string(out) != "-L\nwork\nkill-pane\n-t\na:0.0\n" {
+		t.Errorf("tmuxCommand ran %q, %v", out, err)
+	}
+	if got := strings.Join(tmuxBackend{}.SwitchCommand("a:0.0")[1:], " "); got != "-L work switch-client -t a:0.0" {
+		t.Errorf("SwitchCommand = %q", got)
+	}
+}
+
+func TestCheckTmux(t *testing.T) {
+	fakeTmux(t)
+	if err := checkTmux(); err != nil {
+		t.Errorf("checkTmux with tmux_bin %s: %v", cfg.TmuxBin, err)
+	}
+	setConfig(t, func(c *Config) { c.TmuxBin = filepath.Join(t.TempDir(), "missing") })
+	if err := checkTmux(); err == nil || !strings.Contains(err.Error(), "tmux_bin") {
+		t.Errorf("checkTmux with a missing binary = %v", err)
+	}
+}
@@ -0,0 +1,665 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles are set from the active theme; see theme.go.
+var (
+	titleStyle       lipgloss.Style
+	selectedRow      lipgloss.Style
+	flashRow         lipgloss.Style
+	dimStyle         lipgloss.Style
+	dimTitleStyle    lipgloss.Style
+	helpStyle        lipgloss.Style
+	missingPathStyle lipgloss.Style
+	matchStyle       = lipgloss.NewStyle().Bold(true).Underline(true)
+	statusStyles     map[int]lipgloss.Style
+)
+
+func statusSymbol(s int) string {
+	if g, ok := statusGlyphs[s]; ok {
+		return g
+	}
+	return statusGlyphs[StatusIdle]
+}
+
+func statusLabel(s int) string {
+	switch s {
+	case StatusWorking:
+		return "Working"
+	case StatusWaiting:
+		return "Waiting"
+	case StatusExited:
+		return "Exited"
+	default:
+		return "Idle"
+	}
+}
+
+// rowColumns are the columns a row can show, with the spaces before each.
+var rowColumns = map[string]int{
+	"number":  1,
+	"symbol":  2,
+	"label":   1,
+	"session": 3,
+	"age":     2,
+	"path":    2,
+	"branch":  2,
+	"title":   2,
+	"output":  2,
+}
+
+var defaultColumns = []string{"number", "symbol", "label", "session", "title"}
+
+func validateColumns(cols []string) error {
+	if len(cols) == 0 {
+		return fmt.Errorf("columns: list at least one column")
+	}
+	seen := map[string]bool{}
+	for _, c := range cols {
+		if _, ok := rowColumns[c]; !ok {
+			return fmt.Errorf("columns: unknown column %q", c)
+		}
+		if seen[c] {
+			return fmt.Errorf("columns: %q listed twice", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// ageSourceName describes what the age column measures.
+func ageSourceName(statusAge bool) string {
+	if statusAge {
+		return "time in status, as csm saw it"
+	}
+	return "session age, from tmux"
+}
+
+// columns returns cfg.Columns adjusted by show_numbers and show_age.
+func columns() []string {
+	var out []string
+	hasAge := slices.Contains(cfg.Columns, "age")
+	for _, c := range cfg.Columns {
+		if c == "number" && !cfg.ShowNumbers {
+			continue
+		}
+		out = append(out, c)
+		if c == "session" && cfg.ShowAge && !hasAge {
+			out = append(out, "age")
+		}
+	}
+	return out
+}
+
+// renderRows renders one line per session, highlighting the cursor row.
+func (m model) renderRows() []string {
+	cols := columns()
+	switch {
+	case !m.showOutput:
+		cols = slices.DeleteFunc(cols, func(c string) bool { return c == "output" })
+	case !slices.Contains(cols, "output"):
+		cols = append(cols, "output")
+	}
+	cells := make([][]string, len(m.sessions))
+	for i, s := range m.sessions {
+		cells[i] = make([]string, len(cols))
+		for j, c := range cols {
+			cells[i][j] = m.cell(c, i, s, 0)
+		}
+	}
+
+	// Pad every column but the last to its widest cell.
+	widths := cellWidths(cells, len(cols))
+	// With a title budget (< and >), clip titles to it and give the path
+	// column what the rest of the row leaves.
+	if m.titleWidth > 0 {
+		limits := m.columnLimits(cols, widths)
+		for i, s := range m.sessions {
+			for j, c := range cols {
+				if limits[j] > 0 {
+					cells[i][j] = m.cell(c, i, s, limits[j])
+				}
+			}
+		}
+		widths = cellWidths(cells, len(cols))
+	}
+
+	lines := make([]string, len(m.sessions))
+	for i, s := range m.sessions {
+		pointer := "  "
+		if i == m.cursor {
+			pointer = asciiOnly(" ▸")
+		}
+		if s.PaneID == m.current {
+			// "You are here": the pane the client is already viewing.
+			pointer = asciiOnly("•") + pointer[1:]
+		}
+
+		style := statusStyles[s.Status]
+		sym := style.Render(statusSymbol(s.Status))
+		if m.minimal() {
+			lines[i] = m.paintRow(i, s, fmt.Sprintf("%s %s %s", pointer, sym, s.SessionName))
+			continue
+		}
+
+		var b strings.Builder
+		b.WriteString(" " + pointer)
+		for j, cell := range cells[i] {
+			gap := rowColumns[cols[j]]
+			if j == 0 {
+				gap = 1
+			}
+			b.WriteString(strings.Repeat(" ", gap))
+			pad := ""
+			if j < len(cols)-1 {
+				pad = strings.Repeat(" ", widths[j]-lipgloss.Width(cell))
+			}
+			if cols[j] == "age" {
+				b.WriteString(pad + cell) // right-aligned
+			} else {
+				b.WriteString(cell + pad)
+			}
+		}
+		lines[i] = m.paintRow(i, s, b.String())
+	}
+	if m.collapsedIdle > 0 {
+		pointer := "  "
+		if m.onSummaryRow() {
+			pointer = asciiOnly(" ▸")
+		}
+		line := " " + pointer + " " + m.summaryRow()
+		if m.minimal() {
+			line = fmt.Sprintf("%s %s +%d idle", pointer, statusStyles[StatusIdle].Render(statusSymbol(StatusIdle)), m.collapsedIdle)
+		}
+		if m.onSummaryRow() {
+			line = selectedRow.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func cellWidths(cells [][]string, n int) []int {
+	widths := make([]int, n)
+	for _, row := range cells {
+		for j, cell := range row {
+			widths[j] = max(widths[j], lipgloss.Width(cell))
+		}
+	}
+	return widths
+}
+
+// columnLimits returns the width each column's text is clipped to under
+// the title budget, 0 for none: the budget for the title, and for the path
+// whatever the terminal width leaves once the other columns are laid out.
+func (m model) columnLimits(cols []string, widths []int) []int {
+	limits := make([]int, len(cols))
+	fixed := 3 // the leading space and the pointer
+	path := -1
+	for j, c := range cols {
+		if j == 0 {
+			fixed++
+		} else {
+			fixed += rowColumns[c]
+		}
+		switch c {
+		case "title":
+			limits[j] = m.titleWidth
+		case "path":
+			path = j
+		default:
+			fixed += widths[j]
+		}
+	}
+	if path >= 0 && m.width > 0 {
+		limits[path] = pathBudget(m.listWidth(), fixed, m.titleWidth)
+	}
+	return limits
+}
+
+// widestTitle is the width of the longest title listed.
+func (m model) widestTitle() int {
+	w := 0
+	for _, s := range m.sessions {
+		w = max(w, lipgloss.Width(s.Title))
+	}
+	return w
+}
+
+// paintRow applies row i's background: the flash while it is on, which the
+// ▸ pointer still marks if it is the cursor row, else the selection.
+func (m model) paintRow(i int, s ClaudeSession, line string) string {
+	switch {
+	case flashOn(m.flashes[s.Key], m.frame, cfg.flash):
+		return flashRow.Render(line)
+	case i == m.cursor:
+		return selectedRow.Render(line)
+	default:
+		return line
+	}
+}
+
+// cell renders column c for session s at index i. A positive limit clips
+// the path or title text to that many cells.
+func (m model) cell(c string, i int, s ClaudeSession, limit int) string {
+	style := statusStyles[s.Status]
+	switch c {
+	case "number":
+		return fmt.Sprintf("%d", i+1)
+	case "symbol":
+		return style.Render(statusSymbol(s.Status))
+	case "label":
+		label := style.Render(fmt.Sprintf("%-7s", statusLabel(s.Status)))
+		switch s.Status {
+		case StatusWaiting:
+			return label + " " + style.Render(waitIcon(s.WaitReason))
+		case StatusIdle:
+			return label + " " + style.Render(idleIcon(m.idleReason(s)))
+		}
+		return label + "  "
+	case "session":
+		style := lipgloss.NewStyle()
+		if color, ok := tagColor(cfg.Tags, s.Path); ok {
+			style = style.Foreground(color)
+		}
+		if s.PathMissing {
+			style = missingPathStyle
+		}
+		name := m.highlight("name", s.SessionName, style)
+		if s.Group != "" {
+			name += dimStyle.Render(" (" + s.Group + ")")
+		}
+		if s.Owner != "" {
+			name += dimStyle.Render(" @" + s.Owner)
+		}
+		if s.Background {
+			name += dimStyle.Render(" bg")
+		}
+		if s.Clients > 0 {
+			// Someone is looking at this session; be careful in it.
+			name += dimStyle.Render(fmt.Sprintf(asciiOnly(" ◉%d"), s.Clients))
+		}
+		return name
+	case "age":
+		if m.statusAge {
+			// Colored like the status, to tell it from session age.
+			since := m.track.since[s.Key]
+			if since.IsZero() {
+				return style.Render("?")
+			}
+			return style.Render(formatAge(m.now.Sub(since)))
+		}
+		if s.Created.IsZero() {
+			return dimStyle.Render("?")
+		}
+		return dimStyle.Render(formatAge(m.now.Sub(s.Created)))
+	case "path":
+		if limit > 0 {
+			return dimStyle.Render(clipLeft(s.Path, limit))
+		}
+		return dimStyle.Render(s.Path)
+	case "branch":
+		return dimStyle.Render(s.GitBranch)
+	case "output":
+		return dimStyle.Render(clipRight(s.Output, outputWidth))
+	case "title":
+		text := s.Title
+		if limit > 0 {
+			text = clipRight(text, limit)
+		}
+		title := m.highlight("title", text, dimTitleStyle)
+		if s.PathMissing {
+			title = missingPathStyle.UnsetStrikethrough().Render(asciiOnly("⚠ ")+s.Path+" is gone") + " " + title
+		}
+		if m.muted[s.Path] {
+			title = dimStyle.Render(asciiOnly("⊘ ")) + title
+		}
+		if m.pinned[s.Path] {
+			title = asciiOnly("★ ") + title
+		}
+		if m.digest {
+			title = m.baseline.changeMarker(s) + title
+		}
+		if s.Turns != "" {
+			title += dimStyle.Render(" [" + s.Turns + "]")
+		}
+		if bar := progressBar(s.Progress); bar != "" {
+			title += " " + statusStyles[StatusWorking].Render(bar)
+		}
+		return title
+	}
+	return ""
+}
+
+// highlight renders text in style, with the characters matched by a plain
+// / filter on the named search field in matchStyle as well.
+func (m model) highlight(field, text string, style lipgloss.Style) string {
+	if m.filter == "" || strings.HasPrefix(m.filter, "~") || !slices.Contains(cfg.SearchFields, field) {
+		return style.Render(text)
+	}
+	_, positions, ok := fuzzyMatch(m.filter, text)
+	if !ok {
+		return style.Render(text)
+	}
+	hit := style.Inherit(matchStyle)
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		matched := slices.Contains(positions, i)
+		j := i + 1
+		for j < len(runes) && slices.Contains(positions, j) == matched {
+			j++
+		}
+		if matched {
+			b.WriteString(hit.Render(string(runes[i:j])))
+		} else {
+			b.WriteString(style.Render(string(runes[i:j])))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// minimal reports whether the terminal is too narrow for the full columns.
+// Before the first WindowSizeMsg the width is unknown and the full layout is used.
+func (m model) minimal() bool {
+	return m.width > 0 && m.listWidth() < minimalLayoutWidth
+}
+
+// grid returns the column and row count for the rendered rows at the
+// current terminal width.
+func (m model) grid(lines []string) (cols, rows int) {
+	return gridSize(len(lines), maxWidth(lines), m.listWidth())
+}
+
+// listWidth is the width available to session rows.
+func (m model) listWidth() int {
+	if cfg.Border && m.width > 2 {
+		return m.width - 2
+	}
+	return m.width
+}
+
+func maxWidth(lines []string) int {
+	w := 0
+	for _, l := range lines {
+		w = max(w, lipgloss.Width(l))
+	}
+	return w
+}
+
+// emptyHints explains why a scan found no sessions.
+func emptyHints(st scanStats) []string {
+	var hints []string
+	switch {
+	case !st.listed:
+		hints = append(hints, "· tmux list-panes failed; is the tmux server running?")
+	case st.panes == 0:
+		hints = append(hints, "· tmux reports no panes")
+	case st.titled == 0:
+		hints = append(hints, fmt.Sprintf("· none of %d panes has a Claude title (✳ or spinner)", st.panes))
+	case st.unread > 0:
+		hints = append(hints, "· "+unreadLabel(st.unread)+": capture-pane failed (details in --debug-log)")
+	case st.exited > 0:
+		hints = append(hints, fmt.Sprintf("· %d pane(s) had a Claude title but are back at a shell (Claude exited)", st.exited))
+	}
+	hints = append(hints, "· start claude in a pane, or press n to launch one")
+	for i, h := range hints {
+		hints[i] = asciiOnly(h)
+	}
+	return hints
+}
+
+// header summarizes totals across all detected sessions, including hidden ones.
+func (m model) header() string {
+	if len(m.all) == 0 {
+		return ""
+	}
+	counts := statusCounts(m.all)
+	var parts []string
+	for _, st := range []int{StatusWaiting, StatusWorking, StatusIdle, StatusExited} {
+		if counts[st] > 0 {
+			part := fmt.Sprintf("%d %s", counts[st], strings.ToLower(statusLabel(st)))
+			if m.hidden[st] {
+				// Hidden with f: still counted, but dimmed.
+				parts = append(parts, dimStyle.Render(part+" (off)"))
+				continue
+			}
+			parts = append(parts, statusStyles[st].Render(part))
+		}
+	}
+	h := "  " + strings.Join(parts, dimStyle.Render(asciiOnly(" · ")))
+	if hidden := len(m.all) - len(m.sessions) - m.collapsedIdle; hidden > 0 {
+		h += dimStyle.Render(fmt.Sprintf("  (%d hidden)", hidden))
+	}
+	if m.projectOnly {
+		h += dimStyle.Render("  in " + shortenPath(m.project.root))
+	}
+	return h
+}
+
+func (m model) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	var b strings.Builder
+
+	var panels string // history, digest, detail and explain panels, below the list
+	if m.showHistory {
+		panels = "\n" + renderHistory(m.track.log)
+	}
+	if m.digest && m.baseline.sessions != nil {
+		panels += "\n" + renderDigest(m.baseline, m.all)
+	}
+	if m.showDetail && m.cursor < len(m.sessions) {
+		s := m.sessions[m.cursor]
+		panels += "\n" + renderDetail(s, m.track.since[s.Key], m.now)
+	}
+	if m.showExplain && m.cursor < len(m.sessions) {
+		panels += "\n" + renderExplain(m.sessions[m.cursor])
+	}
+	// Rows left for the list once the title, help line (each with a
+	// margin), footer and panels are drawn; 0 if the height is unknown.
+	showFooter := cfg.Footer
+	listHeight := 0
+	if m.height > 0 {
+		listHeight = m.height - 4 - strings.Count(panels, "\n")
+		// In very short terminals the footer gives way to the last list row.
+		showFooter = showFooter && listHeight > 1
+		if showFooter {
+			listHeight--
+		}
+		listHeight = max(1, listHeight)
+	}
+
+	body, scroll := m.body(listHeight)
+	if m.mode == modeConfirm && confirmModal() {
+		body = overlayCenter(body, confirmBox(m.confirm.prompt, m.width), m.width)
+	}
+	if cfg.Border {
+		// The frame replaces the title line and its margin with its edges.
+		height := 0
+		if listHeight > 0 {
+			height = listHeight + 2
+		}
+		caption := lipgloss.NewStyle().Bold(true).Render("Claude Sessions") + m.header()
+		b.WriteString(frame(body, caption, m.width, height))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(titleStyle.Render("Claude Sessions" + m.header()))
+		b.WriteString("\n")
+		for _, line := range body {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(panels)
+	if showFooter {
+		b.WriteString(m.footer(scroll))
+		b.WriteString("\n")
+	}
+	b.WriteString(m.helpLine())
+
+	// Clip every line to the terminal so a shrinking window never wraps
+	// rows and pushes the list off screen.
+	if m.width > 0 {
+		return lipgloss.NewStyle().MaxWidth(m.width).Render(b.String())
+	}
+	return b.String()
+}
+
+// body renders the session list, or the empty state, one string per line,
+// scrolled to fit height rows. scroll describes the visible range when the
+// list overflows, and is "" otherwise.
+func (m model) body(height int) (lines []string, scroll string) {
+	switch {
+	case m.rowCount() == 0 && len(m.all) > 0 && m.filter != "":
+		return []string{dimStyle.Render("  No sessions match the filter")}, ""
+	case m.rowCount() == 0 && len(m.all) > 0 && m.projectOnly:
+		return []string{dimStyle.Render("  No sessions in " + shortenPath(m.project.root) + " (p to show all)")}, ""
+	case m.rowCount() == 0 && len(m.all) > 0 && len(m.hidden) > 0:
+		return []string{dimStyle.Render("  No sessions with the statuses shown (f to change)")}, ""
+	case m.rowCount() == 0 && len(m.all) > 0:
+		return []string{dimStyle.Render("  No sessions at or above " + strings.ToLower(statusLabel(m.minStatus)) + " (v to show more)")}, ""
+	case m.rowCount() == 0:
+		lines := []string{dimStyle.Render("  No Claude sessions found")}
+		for _, hint := range emptyHints(m.stats) {
+			lines = append(lines, dimStyle.Render("  "+hint))
+		}
+		return lines, ""
+	}
+	rendered := m.renderRows()
+	_, rows := m.grid(rendered)
+	lines = joinColumns(rendered, rows, maxWidth(rendered))
+	_, row := cellOf(m.cursor, rows)
+	// Comfortable density puts a blank line under every row but the
+	// last, so row r starts on line r*spacing.
+	spacing := 1
+	if cfg.Density == "comfortable" {
+		lines, spacing = spaceRows(lines), 2
+	}
+	start, end := scrollWindow(len(lines), row*spacing, height)
+	if start > 0 || end < len(lines) {
+		scroll = fmt.Sprintf("rows %d–%d of %d", start/spacing+1, (end+spacing-1)/spacing, rows)
+	}
+	return lines[start:end], scroll
+}
+
+// footer renders per-status totals, the scroll position and the time of
+// the last scan with its age.
+func (m model) footer(scroll string) string {
+	counts := statusCounts(m.all)
+	var parts []string
+	for _, st := range []int{StatusWorking, StatusWaiting, StatusIdle} {
+		parts = append(parts, statusStyles[st].Render(fmt.Sprintf("%d", counts[st])))
+	}
+	line := "  " + strings.Join(parts, dimStyle.Render("/")) + dimStyle.Render(" working/waiting/idle")
+	if scroll != "" {
+		line += dimStyle.Render(" · " + scroll)
+	}
+	if !m.now.IsZero() {
+		line += dimStyle.Render(" · " + lastScanLabel(m.now, m.tickAt))
+	}
+	if m.stats.throttle > 1 {
+		line += dimStyle.Render(fmt.Sprintf(" · throttled ×%d", m.stats.throttle))
+	}
+	if m.control {
+		line += dimStyle.Render(" · control mode")
+	}
+	if n := len(m.autoKillCandidates()); n > 0 {
+		line += dimStyle.Render(fmt.Sprintf(" · %d idle past auto_kill_idle (K to kill)", n))
+	}
+	if n := m.stats.unread; n > 0 {
+		line += dimStyle.Render(" · ") + missingPathStyle.UnsetStrikethrough().Render(unreadLabel(n))
+	}
+	return asciiOnly(line)
+}
+
+// unreadLabel says how many sessions the last scan could not read.
+func unreadLabel(n int) string {
+	if n == 1 {
+		return "1 session could not be read"
+	}
+	return fmt.Sprintf("%d sessions could not be read", n)
+}
+
+// lastScanLabel renders the time of the last scan and, as a heartbeat that
+// advances every tick, how long ago that was at the last tick.
+func lastScanLabel(scanned, ticked time.Time) string {
+	return scanned.Format("15:04:05") + " (" + formatAge(max(0, ticked.Sub(scanned))) + " ago)"
+}
+
+// helpLine renders the bottom line: the active prompt, a notice, or key help.
+func (m model) helpLine() string {
+	switch {
+	case m.mode == modeConfirm && confirmModal():
+		return helpStyle.Render(asciiOnly(" y confirm · n cancel"))
+	case m.mode == modeConfirm:
+		return helpStyle.Render(" " + m.confirm.prompt + " [y/n]")
+	case m.mode == modeLaunch && m.layout > 0:
+		return helpStyle.Render(" Layout " + cfg.Layouts[m.layout-1].Name + " in: " + m.input + asciiOnly("█"))
+	case m.mode == modeLaunch:
+		return helpStyle.Render(" New session in: " + m.input + asciiOnly("█"))
+	case m.mode == modeGoto:
+		return helpStyle.Render(" Go to: " + m.input + asciiOnly("█"))
+	case m.mode == modeLayout:
+		return helpStyle.Render(layoutMenu(cfg.Layouts))
+	case m.mode == modeStatus:
+		var toggles []string
+		for _, k := range []string{"w", "a", "i"} {
+			st := statusToggleKeys[k]
+			mark := "✓"
+			if m.hidden[st] {
+				mark = "✗"
+			}
+			toggles = append(toggles, k+" "+strings.ToLower(statusLabel(st))+" "+mark)
+		}
+		return helpStyle.Render(asciiOnly(" Show: " + strings.Join(toggles, " · ") + " · f/enter done"))
+	case m.mode == modeMove:
+		moving := ""
+		if m.cursor < len(m.sessions) {
+			moving = " " + m.sessions[m.cursor].PaneID
+		}
+		return helpStyle.Render(" Moving" + moving + asciiOnly(": j/k move · m/enter done"))
+	case m.notice != "" && m.mode == modeNormal:
+		return helpStyle.Render(" " + m.notice)
+	case m.mode == modeFilter || m.filter != "":
+		line := " /" + m.filter
+		if m.mode == modeFilter {
+			line += asciiOnly("█")
+		}
+		if m.filterErr != "" {
+			line += "  invalid regexp: " + m.filterErr
+		} else if m.mode != modeFilter {
+			line += asciiOnly("  (/ edit · esc in filter clears)")
+		}
+		if m.mode == modeFilter {
+			// Enter closes the filter here rather than switching.
+			return helpStyle.Render(line)
+		}
+		return helpStyle.Render(line) + m.target()
+	case m.minimal():
+		return helpStyle.Render(asciiOnly(" ↑↓ · enter · q")) + m.target()
+	default:
+		return helpStyle.Render(asciiOnly(" ↑↓ navigate · enter switch · / filter · n new · v min status · q quit")) + m.target()
+	}
+}
+
+// target names the session Enter would switch to, and its pane, or "" for
+// an empty list.
+func (m model) target() string {
+	if m.cursor >= len(m.sessions) {
+		return ""
+	}
+	s := m.sessions[m.cursor]
+	return dimStyle.Render(asciiOnly("  → ")) + s.SessionName + dimStyle.Render(" "+s.PaneID)
+}
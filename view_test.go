@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestNumberColumn(t *testing.T) {
+	rows := map[bool][]string{}
+	for _, show := range []bool{true, false} {
+		setConfig(t, func(c *Config) { c.ShowNumbers = show })
+		m := update(newModel(), scanned(testSession("a", StatusIdle), testSession("b", StatusIdle)))
+		rows[show] = m.renderRows()
+	}
+	for i, row := range rows[true] {
+		if !strings.HasPrefix(strings.TrimLeft(row, " ▸"), fmt.Sprint(i+1)+" ") {
+			t.Errorf("row %d with numbers: %q", i, row)
+		}
+	}
+	for i, row := range rows[false] {
+		if strings.HasPrefix(strings.TrimLeft(row, " ▸"), fmt.Sprint(i+1)) {
+			t.Errorf("row %d without numbers: %q", i, row)
+		}
+		if lipgloss.Width(row) >= lipgloss.Width(rows[true][i]) {
+			t.Errorf("row %d is no narrower without numbers", i)
+		}
+	}
+}
+
+func TestEmptyHints(t *testing.T) {
+	// A pane with a Claude title whose foreground command is a shell again.
+	exitedPane := withCommand(paneLine("a:0.0", "✳ task"), "zsh")
+	tests := []struct {
+		name string
+		f    *fakeRunner
+		hint string
+	}{
+		{"no server", &fakeRunner{fail: map[string]bool{"list-panes": true}}, "list-panes failed"},
+		{"no panes", &fakeRunner{}, "no panes"},
+		{"no titles", &fakeRunner{panes: paneLine("a:0.0", "vim") + paneLine("b:0.0", "bash")}, "none of 2 panes"},
+		{"exited", &fakeRunner{panes: exitedPane + paneLine("b:0.0", "bash")}, "1 pane(s) had a Claude title"},
+		{"unreadable", &fakeRunner{panes: paneLine("a:0.0", "✳ task")}, "capture-pane failed"},
+	}
+	for _, tt := range tests {
+		setConfig(t, nil)
+		sessions, stats := detect(tt.f)
+		if len(sessions) != 0 {
+			t.Errorf("%s: detected %d sessions", tt.name, len(sessions))
+		}
+		hints := strings.Join(emptyHints(stats), "\n")
+		if !strings.Contains(hints, tt.hint) {
+			t.Errorf("%s: hints %q, want %q", tt.name, hints, tt.hint)
+		}
+	}
+}
+
+func TestMinimalLayout(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("alpha", StatusWaiting), testSession("beta", StatusIdle)))
+	tests := []struct {
+		width   int
+		minimal bool
+	}{
+		{0, false}, // size not known yet
+		{39, true},
+		{40, false},
+		{120, false},
+	}
+	for _, tt := range tests {
+		m = update(m, tea.WindowSizeMsg{Width: tt.width, Height: 20})
+		if m.minimal() != tt.minimal {
+			t.Errorf("width %d: minimal %v, want %v", tt.width, m.minimal(), tt.minimal)
+		}
+		for _, row := range m.renderRows() {
+			full := strings.Contains(row, "task")
+			if full == tt.minimal {
+				t.Errorf("width %d: row %q", tt.width, row)
+			}
+			if tt.minimal && len(strings.Fields(row)) > 3 {
+				t.Errorf("width %d: row %q has more than pointer, symbol and name", tt.width, row)
+			}
+		}
+	}
+
+	// Keys act the same on the compact rows.
+	m = update(m, tea.WindowSizeMsg{Width: 30, Height: 20}, press("j"))
+	if got := m.sessions[m.cursor].SessionName; got != "beta" {
+		t.Errorf("j in the minimal layout moved to %s", got)
+	}
+	if !strings.Contains(m.helpLine(), "beta") {
+		t.Errorf("help line %q does not name the target", m.helpLine())
+	}
+}
+
+func TestHelpLineTarget(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), tea.WindowSizeMsg{Width: 120, Height: 20})
+	if strings.Contains(m.helpLine(), "→") {
+		t.Errorf("empty list: help line %q names a target", m.helpLine())
+	}
+	m = update(m, scanned(testSession("alpha", StatusIdle), testSession("beta", StatusIdle)))
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{nil, "→ alpha alpha:0.0"},
+		{[]string{"j"}, "→ beta beta:0.0"},
+		{[]string{"/", "b", "enter"}, "→ beta beta:0.0"}, // follows the filtered view
+	}
+	for _, tt := range tests {
+		next := m
+		for _, k := range tt.keys {
+			next = update(next, press(k))
+		}
+		if got := next.helpLine(); !strings.Contains(got, tt.want) {
+			t.Errorf("after %v: help line %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestFooter(t *testing.T) {
+	setConfig(t, func(c *Config) { c.Footer = true })
+	m := update(newModel(), scanned(testSession("a", StatusWorking), testSession("b", StatusWaiting),
+		testSession("c", StatusWaiting), testSession("d", StatusIdle)), tickMsg(testTime.Add(5*time.Second)))
+	if got, want := m.footer(""), "1/2/1 working/waiting/idle · 15:04:05 (5s ago)"; !strings.Contains(got, want) {
+		t.Errorf("footer %q, want %q", got, want)
+	}
+}
+
+func TestFooterFitsViewport(t *testing.T) {
+	setConfig(t, func(c *Config) { c.Footer = true })
+	var sessions []ClaudeSession
+	for i := 0; i < 30; i++ {
+		sessions = append(sessions, testSession(fmt.Sprintf("s%02d", i), StatusIdle))
+	}
+	m := update(newModel(), scanned(sessions...))
+	for _, height := range []int{5, 6, 8, 15} {
+		m = update(m, tea.WindowSizeMsg{Width: 100, Height: height})
+		lines := strings.Split(m.View(), "\n")
+		if len(lines) > height {
+			t.Errorf("height %d: view has %d lines", height, len(lines))
+		}
+		footer := strings.Contains(m.View(), "working/waiting/idle")
+		if want := height > 5; footer != want {
+			t.Errorf("height %d: footer shown %v, want %v", height, footer, want)
+		}
+	}
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		cols    []string
+		numbers bool
+		age     bool
+		want    []string
+	}{
+		{[]string{"title", "session", "number"}, true, false, []string{"title", "session", "number"}},
+		{[]string{"title", "session", "number"}, false, false, []string{"title", "session"}},
+		{[]string{"session", "title"}, true, true, []string{"session", "age", "title"}}, // show_age adds age after session
+		{[]string{"age", "session"}, true, true, []string{"age", "session"}},            // unless it is listed
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.Columns, c.ShowNumbers, c.ShowAge = tt.cols, tt.numbers, tt.age })
+		if got := columns(); !slices.Equal(got, tt.want) {
+			t.Errorf("columns %q, numbers %v, age %v: %q, want %q", tt.cols, tt.numbers, tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestColumnOrder(t *testing.T) {
+	setConfig(t, func(c *Config) { c.Columns = []string{"title", "session"} })
+	m := update(newModel(), scanned(testSession("alpha", StatusIdle)))
+	row := m.renderRows()[0]
+	title, session := strings.Index(row, "alpha task"), strings.LastIndex(row, "alpha")
+	if title < 0 || session <= title {
+		t.Errorf("row %q: want the title before the session name", row)
+	}
+}
+
+func TestViewFitsEveryResize(t *testing.T) {
+	sizes := []tea.WindowSizeMsg{
+		{Width: 120, Height: 30}, {Width: 1, Height: 1}, {Width: 2, Height: 40}, {Width: 39, Height: 3},
+		{Width: 40, Height: 5}, {Width: 200, Height: 2}, {Width: 60, Height: 12}, {Width: 120, Height: 30},
+	}
+	long := testSession("a-rather-long-session-name", StatusWaiting)
+	long.Title = strings.Repeat("a very long title ", 10)
+	for _, border := range []bool{false, true} {
+		setConfig(t, func(c *Config) { c.Border = border })
+		m := update(newModel(), scanned(long, testSession("beta", StatusWorking), testSession("gamma", StatusIdle)))
+		for _, size := range sizes {
+			m = update(m, size)
+			for _, line := range strings.Split(m.View(), "\n") {
+				if w := lipgloss.Width(line); w > size.Width {
+					t.Errorf("border %v, %dx%d: line %q is %d wide", border, size.Width, size.Height, line, w)
+				}
+			}
+		}
+	}
+}
+
+func TestLastScanLabel(t *testing.T) {
+	tests := []struct {
+		ticked time.Duration // after the scan
+		want   string
+	}{
+		{0, "15:04:05 (0s ago)"},
+		{-time.Second, "15:04:05 (0s ago)"}, // a tick that raced the scan
+		{9 * time.Second, "15:04:05 (9s ago)"},
+		{3 * time.Minute, "15:04:05 (3m ago)"},
+	}
+	for _, tt := range tests {
+		if got := lastScanLabel(testTime, testTime.Add(tt.ticked)); got != tt.want {
+			t.Errorf("lastScanLabel at +%v = %q, want %q", tt.ticked, got, tt.want)
+		}
+	}
+}
+
+func TestHeartbeatAdvancesWithTicks(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("a", StatusIdle)))
+	for _, step := range []struct {
+		msg  tea.Msg
+		want string
+	}{
+		{tickMsg(testTime.Add(2 * time.Second)), "15:04:05 (2s ago)"},
+		{tickMsg(testTime.Add(7 * time.Second)), "15:04:05 (7s ago)"},
+		// A fresh scan resets the age at the next tick.
+		{scannedAt(testTime.Add(8*time.Second), testSession("a", StatusIdle)), "15:04:13 (0s ago)"},
+	} {
+		m = update(m, step.msg)
+		if view := m.View(); !strings.Contains(view, step.want) {
+			t.Errorf("after %T: no %q in\n%s", step.msg, step.want, view)
+		}
+	}
+}
+
+func TestAgeColumnSource(t *testing.T) {
+	s := testSession("a", StatusIdle)
+	s.Created = testTime.Add(-3 * time.Hour)
+	waiting := s
+	waiting.Status = StatusWaiting
+	row := func(m model) string {
+		for _, line := range strings.Split(m.View(), "\n") {
+			if strings.Contains(line, "a task") {
+				return line
+			}
+		}
+		return ""
+	}
+	tests := []struct {
+		statusAge bool
+		want      string
+	}{
+		{false, " 3h "},
+		{true, " 2m "}, // waiting since the second scan
+	}
+	for _, tt := range tests {
+		setConfig(t, func(c *Config) { c.ShowAge = true })
+		m := update(newModel(), scannedAt(testTime.Add(-time.Minute), s), scannedAt(testTime, waiting))
+		if tt.statusAge {
+			m = update(m, press("T"))
+		}
+		m = update(m, scannedAt(testTime.Add(2*time.Minute), waiting))
+		if got := row(m); !strings.Contains(got, tt.want) {
+			t.Errorf("status_age %v: row %q, want age %q", tt.statusAge, got, strings.TrimSpace(tt.want))
+		}
+		restarted := newModel()
+		restarted.applyState(m.state())
+		if restarted.statusAge != tt.statusAge {
+			t.Errorf("status_age %v lost on a restart", tt.statusAge)
+		}
+	}
+
+	setConfig(t, nil)
+	m := update(newModel(), scanned(s), press("T"))
+	if !strings.Contains(m.notice, "time in status") || !strings.Contains(m.notice, "add age to columns") {
+		t.Errorf("T without an age column: notice %q", m.notice)
+	}
+	// Without a status-since to measure from, the column shows ?.
+	m.track.since = map[string]time.Time{}
+	setConfig(t, func(c *Config) { c.ShowAge = true })
+	if got := row(m); !strings.Contains(got, " ? ") {
+		t.Errorf("unknown status-since: row %q, want ?", got)
+	}
+}
+
+func TestUnreadSessionsInFooter(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "1 session could not be read"},
+		{3, "3 sessions could not be read"},
+	}
+	for _, tt := range tests {
+		if got := unreadLabel(tt.n); got != tt.want {
+			t.Errorf("unreadLabel(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+
+	setConfig(t, nil)
+	f := &fakeRunner{
+		panes:    paneLine("a:0.0", "✳ task") + paneLine("b:0.0", "✳ task") + paneLine("c:0.0", "✳ task"),
+		captures: map[string]string{"a:0.0": "❯ \n"}, // b and c fail
+	}
+	sessions, stats := detect(f)
+	if len(sessions) != 1 || stats.unread != 2 {
+		t.Fatalf("detected %d sessions with %d unread, want 1 and 2", len(sessions), stats.unread)
+	}
+	m := update(newModel(), sessionsMsg{sessions: sessions, stats: stats, at: testTime})
+	if view := m.View(); !strings.Contains(view, "2 sessions could not be read") {
+		t.Errorf("no unread count in the footer:\n%s", view)
+	}
+	if view := update(m, scanned(sessions...)).View(); strings.Contains(view, "could not be read") {
+		t.Errorf("a clean scan still reports unread sessions:\n%s", view)
+	}
+}
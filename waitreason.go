@@ -0,0 +1,80 @@
+package main
+
+import "strings"
+
+// WaitReason classifies what a Waiting session is asking approval for.
+type WaitReason int
+
+const (
+	WaitUnknown WaitReason = iota
+	WaitEdit
+	WaitBash
+	WaitFetch
+)
+
+// WaitPattern maps prompt text to a wait reason. Pattern is matched
+// case-insensitively as a substring of the text after the input prompt,
+// which includes the dialog question above its ❯ 1. Yes selector.
+type WaitPattern struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+}
+
+var waitReasonNames = map[string]WaitReason{
+	"edit":  WaitEdit,
+	"bash":  WaitBash,
+	"fetch": WaitFetch,
+	"other": WaitUnknown,
+}
+
+// defaultWaitPatterns are checked after any configured wait_patterns.
+var defaultWaitPatterns = []WaitPattern{
+	{Pattern: "Do you want to make this edit", Reason: "edit"},
+	{Pattern: "Do you want to create", Reason: "edit"},
+	{Pattern: "Do you want to overwrite", Reason: "edit"},
+	{Pattern: "Bash command", Reason: "bash"},
+	{Pattern: "Do you want to run", Reason: "bash"},
+	{Pattern: "Fetch", Reason: "fetch"},
+	{Pattern: "Do you want to allow Claude to fetch", Reason: "fetch"},
+}
+
+// matchWaitPattern returns the first configured, then built-in, pattern
+// found in text, ignoring case.
+func matchWaitPattern(text string, patterns []WaitPattern) (WaitPattern, bool) {
+	lower := strings.ToLower(text)
+	for _, list := range [][]WaitPattern{patterns, defaultWaitPatterns} {
+		for _, p := range list {
+			if p.Pattern != "" && strings.Contains(lower, strings.ToLower(p.Pattern)) {
+				return p, true
+			}
+		}
+	}
+	return WaitPattern{}, false
+}
+
+func waitIcon(r WaitReason) string {
+	switch r {
+	case WaitEdit:
+		return asciiOnly("✎")
+	case WaitBash:
+		return "$"
+	case WaitFetch:
+		return asciiOnly("⇣")
+	default:
+		return " "
+	}
+}
+
+// waitQuestion returns the last line of content that reads as a question,
+// such as "Do you want to make this edit to main.go?", without the dialog
+// border. It is "" when no line ends in a question mark.
+func waitQuestion(content string) string {
+	lines := strings.Split(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.Trim(lines[i], " \t│╭╮╰╯─")
+		if strings.HasSuffix(line, "?") {
+			return line
+		}
+	}
+	return ""
+}
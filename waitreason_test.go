@@ -0,0 +1,224 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetermineStatusWaitReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		status  int
+		reason  WaitReason
+	}{
+		{"edit", "❯ fix the tests\n\n⏺ Update(main.go)\n\n Do you want to make this edit to main.go?\n ❯ 1. Yes\n   2. No\n\n Esc to cancel\n",
+			StatusWaiting, WaitEdit},
+		{"boxed edit", "❯ fix the tests\n\n╭──────────╮\n│ Do you want to make this edit to main.go? │\n│ ❯ 1. Yes │\n│   2. No  │\n╰──────────╯\n Esc to cancel\n",
+			StatusWaiting, WaitEdit},
+		{"bash", "❯ run the tests\n\n Bash command\n   go test ./...\n Do you want to proceed?\n ❯ 1. Yes\n   2. No\n Esc to cancel\n",
+			StatusWaiting, WaitBash},
+		{"fetch", "❯ read the docs\n\n Do you want to allow Claude to fetch this content?\n ❯ 1. Yes\n   2. No\n Esc to cancel\n",
+			StatusWaiting, WaitFetch},
+		{"unclassified", "❯ go on\n\n Continue with the migration?\n ❯ 1. Yes\n   2. No\n Esc to cancel\n",
+			StatusWaiting, WaitUnknown},
+		// A dialog from an earlier turn, above the input prompt, is stale.
+		{"stale dialog", " Do you want to make this edit to main.go?\n ❯ 1. Yes\n Esc to cancel\n\n⏺ Done.\n\n❯ \n",
+			StatusIdle, WaitUnknown},
+		{"idle", "❯ fix the tests\n\n⏺ All tests pass.\n\n❯ \n", StatusIdle, WaitUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, nil)
+			v := determineStatus(tt.content)
+			if v.status != tt.status || v.reason != tt.reason {
+				t.Errorf("status, reason = %d, %d; want %d, %d", v.status, v.reason, tt.status, tt.reason)
+			}
+		})
+	}
+}
+
+func TestWaitPatternsBeforeBuiltins(t *testing.T) {
+	setConfig(t, func(c *Config) {
+		c.WaitPatterns = []WaitPattern{{Pattern: "go test", Reason: "other"}}
+	})
+	v := determineStatus("❯ run the tests\n\n Bash command\n   go test ./...\n ❯ 1. Yes\n Esc to cancel\n")
+	if v.reason != WaitUnknown || v.pattern.Pattern != "go test" {
+		t.Errorf("reason %d from %q, want the configured pattern", v.reason, v.pattern.Pattern)
+	}
+}
+
+func TestIsOptionLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"❯ 1. Yes", true},
+		{"│ ❯ 2. No, and tell Claude what to do │", true},
+		{"   ❯ 12. Other", true},
+		{"❯ fix the tests", false},
+		{"❯ 2024 was a year", false},
+		{"❯ ", false},
+		{"  1. Yes", false},
+	}
+	for _, tt := range tests {
+		if got := isOptionLine(tt.line); got != tt.want {
+			t.Errorf("isOptionLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestWaitQuestion(t *testing.T) {
+	content := "⏺ Update(main.go)\n│ Do you want to make this edit to main.go? │\n│ ❯ 1. Yes │\n"
+	if got, want := waitQuestion(content), "Do you want to make this edit to main.go?"; got != want {
+		t.Errorf("waitQuestion = %q, want %q", got, want)
+	}
+	if got := waitQuestion("❯ \n"); got != "" {
+		t.Errorf("waitQuestion with no question = %q", got)
+	}
+}
+
+func TestPromptAtLineStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		status  int
+	}{
+		// A ❯ inside output is not a prompt, so the marker below it is
+		// still read as after the real prompt.
+		{"glyph in output", "❯ explain the prompt\n\n⏺ The prompt is drawn as ❯ at the start.\n  esc to cancel\n", StatusWaiting},
+		{"glyph in a later line", "❯ go on\n\n  1. Yes\n  run `echo ❯`?\n  Esc to cancel\n", StatusWaiting},
+		{"boxed prompt", "│ esc to cancel │\n│ ❯            │\n", StatusIdle},
+		{"indented prompt", "  esc to cancel\n\t❯ \n", StatusIdle},
+		{"no prompt", "⏺ esc to cancel\n", StatusIdle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, nil)
+			if got := determineStatus(tt.content).status; got != tt.status {
+				t.Errorf("status = %d, want %d", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestIsPromptLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"❯ fix the tests", true},
+		{"  ❯ ", true},
+		{"│ ❯ fix │", true},
+		{"> fix the tests", false},
+		{"the ❯ glyph", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isPromptLine(tt.line); got != tt.want {
+			t.Errorf("isPromptLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+// splitAfterLastPrompt is afterLastPrompt as it was first written, splitting
+// the whole capture into lines: the reference it must agree with.
+func splitAfterLastPrompt(content string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	last := -1
+	for i, line := range lines {
+		if isPromptLine(line) {
+			last = i
+		}
+	}
+	if last < 0 || last == len(lines)-1 {
+		return "", false
+	}
+	return strings.Join(lines[last+1:], "\n"), true
+}
+
+func TestAfterLastPrompt(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"\n",
+		"❯",
+		"❯ ",
+		"❯ \n",
+		"❯ a\nb\n❯ c\nd\ne",
+		"❯ a\nb\n❯ c\nd\ne\n",
+		"no prompt\nat all\n",
+		"\n\n❯ \n\n",
+		"output\n│ ❯ boxed │\n│ tail │",
+		"❯ first\nmiddle ❯ inline\nlast",
+	} {
+		after, ok := afterLastPrompt(content)
+		wantAfter, wantOK := splitAfterLastPrompt(content)
+		if after != wantAfter || ok != wantOK {
+			t.Errorf("afterLastPrompt(%q) = %q, %v; want %q, %v", content, after, ok, wantAfter, wantOK)
+		}
+	}
+}
+
+// deepCapture is a capture_lines=2000 capture of an idle session.
+var deepCapture = strings.Repeat("⏺ Some earlier output line that is long enough to be typical\n", 2000) + "❯ \n  ? for shortcuts\n"
+
+func BenchmarkAfterLastPrompt(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		fn   func(string) (string, bool)
+	}{{"backwards", afterLastPrompt}, {"split", splitAfterLastPrompt}} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bm.fn(deepCapture)
+			}
+		})
+	}
+}
+
+func BenchmarkDetermineStatus(b *testing.B) {
+	saved := cfg
+	cfg = defaultConfig()
+	defer func() { cfg = saved }()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		determineStatus(deepCapture)
+	}
+}
+
+func TestWaitingMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		extra   []string
+		status  int
+		marker  string
+	}{
+		{"pager", "❯ show the log\n\n⏺ Bash(git log)\n  commit 1a2b3c\n  -- More --\n  Press Enter to continue\n",
+			nil, StatusWaiting, "press enter to continue"},
+		{"any key", "❯ run it\n\n  Press any key to continue...\n", nil, StatusWaiting, "press any key to continue"},
+		{"y/n", "❯ migrate\n\n  Apply 3 migrations. Continue? (y/N)\n", nil, StatusWaiting, "continue? (y/n)"},
+		// A pager prompt above the last input prompt was already answered.
+		{"stale pager", "❯ show the log\n\n  Press Enter to continue\n\n⏺ Done.\n\n❯ \n", nil, StatusIdle, ""},
+		{"extra", "❯ deploy\n\n  Type the release name to confirm:\n", []string{"to confirm:"}, StatusWaiting, "to confirm:"},
+		{"extra stale", "  Type the release name to confirm:\n\n❯ \n", []string{"to confirm:"}, StatusIdle, ""},
+		{"no extra", "❯ deploy\n\n  Type the release name to confirm:\n", nil, StatusIdle, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, func(c *Config) { c.WaitingMarkers = tt.extra })
+			v := determineStatus(tt.content)
+			if v.status != tt.status || !strings.EqualFold(v.marker, tt.marker) {
+				t.Errorf("status %d, marker %q; want %d, %q", v.status, v.marker, tt.status, tt.marker)
+			}
+		})
+	}
+}
+
+func TestValidateWaitingMarkers(t *testing.T) {
+	c := defaultConfig()
+	c.WaitingMarkers = []string{"to confirm:", " "}
+	if err := c.validate(); err == nil {
+		t.Error("validate accepted a blank waiting marker")
+	}
+}
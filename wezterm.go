@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+)
+
+// wezterm backend
+//
+// Panes come from `wezterm cli list --format json`, whose titles carry
+// Claude's ✳ and spinner markers just like tmux's. wezterm reports no
+// foreground command, so exited sessions and --deep-detect are not
+// available; everything after the listing is the same inspection as tmux.
+
+// weztermPane is one entry of wezterm cli list --format json.
+type weztermPane struct {
+	PaneID    int    `json:"pane_id"`
+	Workspace string `json:"workspace"`
+	Title     string `json:"title"`
+	Cwd       string `json:"cwd"` // file://host/path
+}
+
+// weztermBackend runs the wezterm CLI.
+type weztermBackend struct{}
+
+// checkWezterm reports a clear error when wezterm is not on $PATH.
+func checkWezterm() error {
+	if _, err := exec.LookPath("wezterm"); err != nil {
+		return fmt.Errorf("$WEZTERM_PANE is set but wezterm is not on $PATH")
+	}
+	return nil
+}
+
+// listWeztermPanes lists every pane of the wezterm mux.
+func listWeztermPanes(r CommandRunner) ([]weztermPane, error) {
+	out, err := tolerantOutput(r, "wezterm", "cli", "list", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	return parseWeztermPanes(out)
+}
+
+// parseWeztermPanes parses a wezterm cli list --format json listing.
+func parseWeztermPanes(data []byte) ([]weztermPane, error) {
+	var panes []weztermPane
+	if err := json.Unmarshal(data, &panes); err != nil {
+		return nil, fmt.Errorf("wezterm cli list: %w", err)
+	}
+	return panes, nil
+}
+
+// weztermPath turns a pane's cwd URL into a path; wezterm reports it as
+// file://host/path, or "" before the shell has told it.
+func weztermPath(cwd string) string {
+	u, err := url.Parse(cwd)
+	if err != nil || u.Scheme != "file" {
+		return cwd
+	}
+	return u.Path
+}
+
+// weztermCandidates picks the panes with a Claude title, counting them in
+// stats.
+func weztermCandidates(panes []weztermPane, stats *scanStats) []paneInfo {
+	var candidates []paneInfo
+	for _, p := range panes {
+		stats.panes++
+		if !isClaudeTitle(p.Title) {
+			continue
+		}
+		stats.titled++
+		path := weztermPath(p.Cwd)
+		if !pathInScope(path, cfg.includeRoots, cfg.excludeRoots) {
+			continue
+		}
+		id := strconv.Itoa(p.PaneID)
+		candidates = append(candidates, paneInfo{
+			id:      id,
+			key:     paneKey(p.Workspace, id, id),
+			sess:    p.Workspace,
+			path:    path,
+			title:   cleanTitle(p.Title),
+			working: isBraillePrefix(p.Title),
+			why:     explanation{source: "pane title", rawTitle: p.Title},
+		})
+	}
+	return candidates
+}
+
+func (b weztermBackend) Detect(r CommandRunner) ([]ClaudeSession, scanStats) {
+	stats := newScanStats()
+	panes, err := listWeztermPanes(r)
+	if err != nil {
+		debugLog.Print(err)
+		return nil, stats
+	}
+	stats.listed = true
+	candidates := weztermCandidates(panes, &stats)
+	if len(candidates) == 0 {
+		return nil, stats
+	}
+	sessions := inspectPanes(candidates, func(pane string) (string, error) {
+		return b.Capture(r, pane)
+	}, &stats)
+	stats.sessions = len(sessions)
+	return sessions, stats
+}
+
+// Current is the pane csm runs in, as tmux's display-message reports for
+// a client.
+func (weztermBackend) Current(CommandRunner) string { return os.Getenv("WEZTERM_PANE") }
+
+func (weztermBackend) Capture(r CommandRunner, pane string) (string, error) {
+	out, err := tolerantOutput(r, "wezterm", "cli", "get-text", "--pane-id", pane, "--start-line", fmt.Sprintf("-%d", cfg.CaptureLines))
+	return string(out), err
+}
+
+func (weztermBackend) Exists(r CommandRunner, pane string) bool {
+	panes, err := listWeztermPanes(r)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(panes, func(p weztermPane) bool { return strconv.Itoa(p.PaneID) == pane })
+}
+
+func (b weztermBackend) Switch(r CommandRunner, pane string) error {
+	if !b.Exists(r, pane) {
+		return fmt.Errorf("%s: %w", pane, errPaneGone)
+	}
+	argv := b.SwitchCommand(pane)
+	if _, err := r.Output(argv[0], argv[1:]...); err != nil {
+		return fmt.Errorf("activate-pane: %w", err)
+	}
+	return nil
+}
+
+func (weztermBackend) SwitchCommand(pane string) []string {
+	return []string{"wezterm", "cli", "activate-pane", "--pane-id", pane}
+}
+
+func (weztermBackend) Kill(r CommandRunner, pane string) error {
+	if _, err := r.Output("wezterm", "cli", "kill-pane", "--pane-id", pane); err != nil {
+		return fmt.Errorf("kill-pane: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// weztermRunner fakes the wezterm CLI: list prints list, get-text prints
+// the pane's text, and every call is recorded.
+type weztermRunner struct {
+	list  string
+	texts map[string]string
+	calls []string
+}
+
+func (w *weztermRunner) Output(name string, args ...string) ([]byte, error) {
+	w.calls = append(w.calls, strings.Join(append([]string{name}, args...), " "))
+	if name != "wezterm" || len(args) < 2 || args[0] != "cli" {
+		return nil, fmt.Errorf("fake: unexpected command %s", name)
+	}
+	switch args[1] {
+	case "list":
+		return []byte(w.list), nil
+	case "get-text":
+		text, ok := w.texts[args[3]]
+		if !ok {
+			return nil, fmt.Errorf("fake: no pane %s", args[3])
+		}
+		return []byte(text), nil
+	}
+	return nil, nil
+}
+
+const weztermList = `[
+	{"pane_id": 3, "workspace": "default", "title": "✳ fix the build", "cwd": "file://host/home/u/api"},
+	{"pane_id": 4, "workspace": "default", "title": "zsh", "cwd": "file://host/home/u"},
+	{"pane_id": 7, "workspace": "work", "title": "⠋ write docs", "cwd": ""}
+]`
+
+func TestParseWeztermPanes(t *testing.T) {
+	panes, err := parseWeztermPanes([]byte(weztermList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(panes) != 3 || panes[0].PaneID != 3 || panes[2].Workspace != "work" || panes[0].Cwd != "file://host/home/u/api" {
+		t.Errorf("parsed %+v", panes)
+	}
+	if _, err := parseWeztermPanes([]byte("not json")); err == nil {
+		t.Error("parseWeztermPanes accepted garbage")
+	}
+}
+
+func TestWeztermPath(t *testing.T) {
+	tests := []struct{ cwd, want string }{
+		{"file://host/home/u/api", "/home/u/api"},
+		{"file:///tmp/x%20y", "/tmp/x y"},
+		{"", ""},
+		{"/plain/path", "/plain/path"},
+	}
+	for _, tt := range tests {
+		if got := weztermPath(tt.cwd); got != tt.want {
+			t.Errorf("weztermPath(%q) = %q, want %q", tt.cwd, got, tt.want)
+		}
+	}
+}
+
+func TestWeztermCandidates(t *testing.T) {
+	setConfig(t, nil)
+	panes, _ := parseWeztermPanes([]byte(weztermList))
+	stats := newScanStats()
+	got := weztermCandidates(panes, &stats)
+	if stats.panes != 3 || stats.titled != 2 || len(got) != 2 {
+		t.Fatalf("%d candidates from %d panes, %d titled", len(got), stats.panes, stats.titled)
+	}
+	tests := []struct {
+		c              paneInfo
+		id, sess, path string
+		title          string
+		working        bool
+	}{
+		{got[0], "3", "default", "/home/u/api", "fix the build", false},
+		{got[1], "7", "work", "", "write docs", true},
+	}
+	for _, tt := range tests {
+		if tt.c.id != tt.id || tt.c.sess != tt.sess || tt.c.path != tt.path || tt.c.title != tt.title || tt.c.working != tt.working {
+			t.Errorf("candidate %+v, want %s %s %q %q working %v", tt.c, tt.id, tt.sess, tt.path, tt.title, tt.working)
+		}
+	}
+}
+
+func TestWeztermDetect(t *testing.T) {
+	setConfig(t, nil)
+	w := &weztermRunner{list: weztermList, texts: map[string]string{"3": "❯ \n", "7": "❯ \n"}}
+	sessions, stats := weztermBackend{}.Detect(w)
+	if !stats.listed || stats.sessions != 2 || len(sessions) != 2 {
+		t.Fatalf("detected %d sessions, stats %+v", len(sessions), stats)
+	}
+	if s := sessions[0]; s.PaneID != "3" || s.SessionName != "default" || s.Title != "fix the build" {
+		t.Errorf("first session %+v", s)
+	}
+	want := fmt.Sprintf("wezterm cli get-text --pane-id 3 --start-line -%d", cfg.CaptureLines)
+	if !slices.Contains(w.calls, want) {
+		t.Errorf("no %q in %q", want, w.calls)
+	}
+
+	sessions, stats = weztermBackend{}.Detect(&weztermRunner{list: "oops"})
+	if sessions != nil || stats.listed {
+		t.Errorf("a bad listing: %d sessions, listed %v", len(sessions), stats.listed)
+	}
+}
+
+func TestWeztermSwitch(t *testing.T) {
+	w := &weztermRunner{list: weztermList}
+	if err := (weztermBackend{}).Switch(w, "7"); err != nil {
+		t.Fatal(err)
+	}
+	if last := w.calls[len(w.calls)-1]; last != "wezterm cli activate-pane --pane-id 7" {
+		t.Errorf("switched with %q", last)
+	}
+	if err := (weztermBackend{}).Switch(w, "9"); !errors.Is(err, errPaneGone) {
+		t.Errorf("switching to a closed pane: %v, want errPaneGone", err)
+	}
+}
+
+func TestSelectBackend(t *testing.T) {
+	tests := []struct {
+		tmux, pane string
+		want       Backend
+	}{
+		{"/tmp/tmux-1/default,1,0", "3", tmuxBackend{}}, // tmux inside wezterm
+		{"", "3", weztermBackend{}},
+		{"", "", tmuxBackend{}},
+	}
+	for _, tt := range tests {
+		t.Setenv("TMUX", tt.tmux)
+		t.Setenv("WEZTERM_PANE", tt.pane)
+		if got := selectBackend(); got != tt.want {
+			t.Errorf("TMUX=%q WEZTERM_PANE=%q: %T, want %T", tt.tmux, tt.pane, got, tt.want)
+		}
+	}
+	saved := backend
+	backend = weztermBackend{}
+	defer func() { backend = saved }()
+	if err := tmuxOnly("popups"); err == nil || !strings.Contains(err.Error(), "needs tmux") {
+		t.Errorf("tmuxOnly under wezterm: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Waiting window marks
+//
+// With waiting_window_style set, each scan styles the tmux windows that
+// hold a Waiting session and restores the others. Marked windows also get
+// the window option @csm_waiting, so marks left behind when csm exits are
+// found and restored by the next run. A style the window had of its own
+// is kept in @csm_saved_style and put back on restore.
+
+// waitingWindowOption flags windows csm has styled.
+const waitingWindowOption = "@csm_waiting"
+
+// savedStyleOption holds a window's own window-status-style while csm
+// styles it.
+const savedStyleOption = "@csm_saved_style"
+
+// windowMarker tracks which windows carry the waiting style.
+type windowMarker struct {
+	style  string
+	marked map[string]bool // "session:window" targets
+}
+
+// newWindowMarker returns a marker for style, seeded with the windows
+// tmux reports as already marked. It is nil when style is empty.
+func newWindowMarker(r CommandRunner, style string) *windowMarker {
+	if style == "" {
+		return nil
+	}
+	w := &windowMarker{style: style, marked: map[string]bool{}}
+	out, err := r.Output("tmux", "list-windows", "-a", "-F",
+		"#{session_name}:#{window_index}\t#{"+waitingWindowOption+"}")
+	if err != nil {
+		debugLog.Printf("waiting_window_style: list-windows: %v", err)
+		return w
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if win, flag, ok := strings.Cut(line, "\t"); ok && flag != "" {
+			w.marked[win] = true
+		}
+	}
+	return w
+}
+
+// paneWindow returns the window target of a "session:window.pane" PaneID.
+func paneWindow(pane string) string {
+	if i := strings.LastIndex(pane, "."); i >= 0 {
+		return pane[:i]
+	}
+	return pane
+}
+
+// windowChanges are the windows a scan marks and restores.
+type windowChanges struct {
+	style         string
+	mark, restore []string // "session:window" targets
+}
+
+// update records the windows that should be marked after a scan and
+// returns the new ones to mark and those no longer waiting to restore. A
+// window stays marked while any of its panes waits.
+func (w *windowMarker) update(sessions []ClaudeSession) windowChanges {
+	if w == nil {
+		return windowChanges{}
+	}
+	waiting := map[string]bool{}
+	for _, s := range sessions {
+		// Windows on other servers (--all-users) are not ours to style.
+		if s.Status == StatusWaiting && s.Socket == "" {
+			waiting[paneWindow(s.PaneID)] = true
+		}
+	}
+	c := windowChanges{style: w.style}
+	for win := range waiting {
+		if !w.marked[win] {
+			c.mark = append(c.mark, win)
+		}
+	}
+	for win := range w.marked {
+		if !waiting[win] {
+			c.restore = append(c.restore, win)
+		}
+	}
+	slices.Sort(c.mark)
+	slices.Sort(c.restore)
+	w.marked = waiting
+	return c
+}
+
+// windowOption returns the value win itself sets for option, or "" if it
+// inherits it.
+func windowOption(r CommandRunner, win, option string) string {
+	out, err := r.Output("tmux", "show-options", "-w", "-q", "-v", "-t", win, option)
+	if err != nil {
+		debugLog.Printf("waiting_window_style: show-options %s %s: %v", win, option, err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// run marks and restores the windows through r. Marking first saves the
+// window's own style; restoring puts it back, or unsets the style if there
+// was none.
+func (c windowChanges) run(r CommandRunner) {
+	var cmds [][]string
+	for _, win := range c.mark {
+		if own := windowOption(r, win, "window-status-style"); own != "" {
+			cmds = append(cmds, []string{"set-window-option", "-t", win, savedStyleOption, own})
+		}
+		cmds = append(cmds,
+			[]string{"set-window-option", "-t", win, "window-status-style", c.style},
+			[]string{"set-window-option", "-t", win, waitingWindowOption, "1"})
+	}
+	for _, win := range c.restore {
+		if own := windowOption(r, win, savedStyleOption); own != "" {
+			cmds = append(cmds,
+				[]string{"set-window-option", "-t", win, "window-status-style", own},
+				[]string{"set-window-option", "-u", "-t", win, savedStyleOption})
+		} else {
+			cmds = append(cmds, []string{"set-window-option", "-u", "-t", win, "window-status-style"})
+		}
+		cmds = append(cmds, []string{"set-window-option", "-u", "-t", win, waitingWindowOption})
+	}
+	runTmux(r, cmds)
+}
+
+// runTmux runs each tmux invocation, logging failures such as a window
+// that closed since the scan.
+func runTmux(r CommandRunner, cmds [][]string) {
+	for _, args := range cmds {
+		if _, err := r.Output("tmux", args...); err != nil {
+			debugLog.Printf("waiting_window_style: %s: %v", strings.Join(args, " "), err)
+		}
+	}
+}
+
+// markWindows runs c in the background.
+func markWindows(c windowChanges) tea.Cmd {
+	if len(c.mark) == 0 && len(c.restore) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		c.run(sysRunner)
+		return nil
+	}
+}
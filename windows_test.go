@@ -0,0 +1,121 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPaneWindow(t *testing.T) {
+	tests := []struct{ pane, want string }{
+		{"work:1.0", "work:1"},
+		{"my.proj:2.3", "my.proj:2"},
+		{"%5", "%5"},
+	}
+	for _, tt := range tests {
+		if got := paneWindow(tt.pane); got != tt.want {
+			t.Errorf("paneWindow(%q) = %q, want %q", tt.pane, got, tt.want)
+		}
+	}
+}
+
+// marks runs w's changes for sessions against f and returns the options
+// they set and unset.
+func marks(w *windowMarker, f *fakeRunner, sessions []ClaudeSession) []string {
+	f.calls = nil
+	w.update(sessions).run(f)
+	var out []string
+	for _, c := range f.ran("tmux set-window-option") {
+		out = append(out, strings.TrimPrefix(c, "tmux "))
+	}
+	return out
+}
+
+func TestWindowMarkerTransitions(t *testing.T) {
+	f := &fakeRunner{}
+	w := newWindowMarker(f, "bg=colour214")
+	waiting := func(id string) ClaudeSession {
+		s := testSession(id, StatusWaiting)
+		s.PaneID = id
+		return s
+	}
+	idle := func(id string) ClaudeSession {
+		s := testSession(id, StatusIdle)
+		s.PaneID = id
+		return s
+	}
+	remote := waiting("far:0.0")
+	remote.Socket = "/tmp/tmux-1001/default"
+	steps := []struct {
+		name     string
+		sessions []ClaudeSession
+		want     []string
+	}{
+		{"starts waiting", []ClaudeSession{waiting("a:1.0"), idle("b:0.0")}, []string{
+			"set-window-option -t a:1 window-status-style bg=colour214",
+			"set-window-option -t a:1 @csm_waiting 1",
+		}},
+		{"still waiting", []ClaudeSession{waiting("a:1.0"), idle("b:0.0")}, nil},
+		// a second waiting pane in the same window changes nothing
+		{"second pane", []ClaudeSession{waiting("a:1.0"), waiting("a:1.1")}, nil},
+		{"other servers", []ClaudeSession{waiting("a:1.0"), remote}, nil},
+		{"stops waiting", []ClaudeSession{idle("a:1.0")}, []string{
+			"set-window-option -u -t a:1 window-status-style",
+			"set-window-option -u -t a:1 @csm_waiting",
+		}},
+	}
+	for _, st := range steps {
+		if got := marks(w, f, st.sessions); !slices.Equal(got, st.want) {
+			t.Errorf("%s: ran %q, want %q", st.name, got, st.want)
+		}
+	}
+}
+
+func TestWindowMarkerKeepsOwnStyle(t *testing.T) {
+	f := &fakeRunner{options: map[string]string{"a:1 window-status-style": "fg=blue,bold"}}
+	w := newWindowMarker(f, "bg=red")
+	s := testSession("a", StatusWaiting)
+	s.PaneID = "a:1.0"
+	want := []string{
+		"set-window-option -t a:1 @csm_saved_style fg=blue,bold",
+		"set-window-option -t a:1 window-status-style bg=red",
+		"set-window-option -t a:1 @csm_waiting 1",
+	}
+	if got := marks(w, f, []ClaudeSession{s}); !slices.Equal(got, want) {
+		t.Errorf("marking ran %q, want %q", got, want)
+	}
+
+	f.options["a:1 @csm_saved_style"] = "fg=blue,bold"
+	want = []string{
+		"set-window-option -t a:1 window-status-style fg=blue,bold",
+		"set-window-option -u -t a:1 @csm_saved_style",
+		"set-window-option -u -t a:1 @csm_waiting",
+	}
+	if got := marks(w, f, nil); !slices.Equal(got, want) {
+		t.Errorf("restoring ran %q, want %q", got, want)
+	}
+}
+
+func TestWindowMarkerRestoresLeftoverMarks(t *testing.T) {
+	// A previous run exited with work:2 still marked.
+	f := &fakeRunner{windows: "work:1\t\nwork:2\t1\n"}
+	w := newWindowMarker(f, "bg=red")
+	want := []string{
+		"set-window-option -u -t work:2 window-status-style",
+		"set-window-option -u -t work:2 @csm_waiting",
+	}
+	if got := marks(w, f, nil); !slices.Equal(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+}
+
+func TestWindowMarkerOff(t *testing.T) {
+	f := &fakeRunner{}
+	w := newWindowMarker(f, "")
+	if w != nil || len(f.calls) != 0 {
+		t.Errorf("without a style: marker %v, ran %q", w, f.calls)
+	}
+	if c := w.update([]ClaudeSession{testSession("a", StatusWaiting)}); c.mark != nil || c.restore != nil {
+		t.Errorf("a nil marker changed %+v", c)
+	}
+}
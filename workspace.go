@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Workspace layouts (L)
+//
+// A layout is a named sequence of tmux commands, such as a new window
+// split into an editor and Claude. L lists the layouts; picking one by
+// number asks for a directory like n does, then runs the commands there
+// in a single tmux invocation, so later commands act on the window the
+// first one created.
+
+// maxLayouts is how many layouts the L menu can offer, one per digit.
+const maxLayouts = 9
+
+// Layout is a named workspace of tmux commands. Each command is split
+// into words; {dir} and {cmd} in a word become the chosen directory and
+// launch_cmd.
+type Layout struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands"`
+}
+
+// validateLayouts checks the layouts config.
+func validateLayouts(layouts []Layout) error {
+	if len(layouts) > maxLayouts {
+		return fmt.Errorf("layouts: at most %d, got %d", maxLayouts, len(layouts))
+	}
+	for i, l := range layouts {
+		if strings.TrimSpace(l.Name) == "" {
+			return fmt.Errorf("layouts[%d]: name is empty", i)
+		}
+		if len(l.Commands) == 0 {
+			return fmt.Errorf("layout %q: no commands", l.Name)
+		}
+		for _, c := range l.Commands {
+			if len(strings.Fields(c)) == 0 {
+				return fmt.Errorf("layout %q: empty command", l.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// layoutArgs returns the tmux arguments that run l's commands in dir,
+// joined by ";" so tmux runs them in order as one command sequence.
+// Words are substituted after splitting, so a dir with spaces stays one
+// argument.
+func layoutArgs(l Layout, dir, cmd string) []string {
+	r := strings.NewReplacer("{dir}", dir, "{cmd}", cmd)
+	var args []string
+	for i, c := range l.Commands {
+		if i > 0 {
+			args = append(args, ";")
+		}
+		for _, w := range strings.Fields(c) {
+			args = append(args, r.Replace(w))
+		}
+	}
+	return args
+}
+
+// launchLayout runs layout l in dir.
+func launchLayout(l Layout, dir string) tea.Cmd {
+	return func() tea.Msg {
+		dir = expandPath(dir)
+		if _, err := sysRunner.Output("tmux", layoutArgs(l, dir, cfg.LaunchCmd)...); err != nil {
+			return actionMsg{err: fmt.Errorf("layout %s: %w", l.Name, err)}
+		}
+		return actionMsg{notice: "Opened layout " + l.Name + " in " + shortenPath(dir)}
+	}
+}
+
+// layoutMenu renders the L menu for the help line.
+func layoutMenu(layouts []Layout) string {
+	items := make([]string, len(layouts))
+	for i, l := range layouts {
+		items[i] = strconv.Itoa(i+1) + " " + l.Name
+	}
+	return " Layout: " + strings.Join(items, asciiOnly(" · ")) + asciiOnly(" · esc cancel")
+}
+
+// updateLayoutMenu handles keys while the L menu is open: a digit picks
+// that layout and opens the directory prompt.
+func (m model) updateLayoutMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "esc", "q":
+		m.mode = modeNormal
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	default:
+		n, err := strconv.Atoi(key)
+		if err != nil || n < 1 || n > len(cfg.Layouts) {
+			break
+		}
+		m.layout = n
+		m.mode = modeLaunch
+		m.input = m.launchDir()
+	}
+	return m, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var testLayouts = []Layout{
+	{Name: "solo", Commands: []string{"new-window -c {dir} {cmd}"}},
+	{Name: "editor", Commands: []string{
+		"new-window -c {dir} -n edit",
+		"send-keys nvim Enter",
+		"split-window -h -c {dir} {cmd}",
+	}},
+}
+
+func TestLayoutArgs(t *testing.T) {
+	tests := []struct {
+		l        Layout
+		dir, cmd string
+		want     []string
+	}{
+		{testLayouts[0], "/src/api", "claude", []string{"new-window", "-c", "/src/api", "claude"}},
+		{testLayouts[1], "/my src", "claude --continue", []string{ // substituted words stay whole
+			"new-window", "-c", "/my src", "-n", "edit", ";",
+			"send-keys", "nvim", "Enter", ";",
+			"split-window", "-h", "-c", "/my src", "claude --continue",
+		}},
+	}
+	for _, tt := range tests {
+		if got := layoutArgs(tt.l, tt.dir, tt.cmd); !slices.Equal(got, tt.want) {
+			t.Errorf("layoutArgs(%s) = %q, want %q", tt.l.Name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLayouts(t *testing.T) {
+	tests := []struct {
+		layouts []Layout
+		ok      bool
+	}{
+		{testLayouts, true},
+		{nil, true},
+		{[]Layout{{Name: " ", Commands: []string{"new-window"}}}, false},
+		{[]Layout{{Name: "x"}}, false},
+		{[]Layout{{Name: "x", Commands: []string{"new-window", "  "}}}, false},
+		{slices.Repeat([]Layout{testLayouts[0]}, maxLayouts+1), false},
+	}
+	for _, tt := range tests {
+		if err := validateLayouts(tt.layouts); (err == nil) != tt.ok {
+			t.Errorf("validateLayouts(%v) = %v, want ok %v", tt.layouts, err, tt.ok)
+		}
+	}
+}
+
+func TestLayoutMenu(t *testing.T) {
+	setConfig(t, nil)
+	m := update(newModel(), scanned(testSession("api", StatusIdle)), press("L"))
+	if m.mode != modeNormal || !strings.Contains(m.notice, "No layouts configured") {
+		t.Errorf("L without layouts: mode %d, notice %q", m.mode, m.notice)
+	}
+
+	setConfig(t, func(c *Config) { c.Layouts = testLayouts })
+	tests := []struct {
+		key    string
+		mode   int
+		layout int
+	}{
+		{"2", modeLaunch, 2}, // the picked layout, not the one before it
+		{"1", modeLaunch, 1},
+		{"3", modeLayout, 0}, // no third layout
+		{"esc", modeNormal, 0},
+	}
+	for _, tt := range tests {
+		m := update(newModel(), scanned(testSession("api", StatusIdle)), press("L"))
+		if help := m.helpLine(); !strings.Contains(help, "1 solo · 2 editor") {
+			t.Errorf("menu = %q", help)
+		}
+		m = update(m, press(tt.key))
+		if m.mode != tt.mode || m.layout != tt.layout {
+			t.Errorf("L %s: mode %d, layout %d; want %d, %d", tt.key, m.mode, m.layout, tt.mode, tt.layout)
+		}
+		if tt.mode == modeLaunch && m.input != "~/api" {
+			t.Errorf("L %s: the prompt starts at %q, want the selected session's directory", tt.key, m.input)
+		}
+	}
+}
+
+func TestLaunchLayout(t *testing.T) {
+	setConfig(t, func(c *Config) { c.LaunchCmd = "claude" })
+	f := &fakeRunner{}
+	useRunner(t, f)
+	msg := launchLayout(testLayouts[1], "/src/api")().(actionMsg)
+	if msg.err != nil || msg.notice != "Opened layout editor in /src/api" {
+		t.Errorf("launchLayout reported %+v", msg)
+	}
+	want := "tmux new-window -c /src/api -n edit ; send-keys nvim Enter ; split-window -h -c /src/api claude"
+	if !slices.Equal(f.calls, []string{want}) {
+		t.Errorf("ran %q, want %q in one invocation", f.calls, want)
+	}
+
+	useRunner(t, &fakeRunner{fail: map[string]bool{"new-window": true}})
+	if msg := launchLayout(testLayouts[1], "/src/api")().(actionMsg); msg.err == nil || !strings.HasPrefix(msg.err.Error(), "layout editor:") {
+		t.Errorf("a failed layout reported %+v", msg)
+	}
+}